@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"payment/cmd/payment/service"
+	"payment/cmd/payment/usecase"
+	"payment/infrastructure/log"
+	"payment/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type AnomalyHandler interface {
+	HandlerGetPaymentAnomalies(c *gin.Context)
+	HandlerGetPaymentAnomalyDetail(c *gin.Context)
+	HandlerResolvePaymentAnomaly(c *gin.Context)
+}
+
+type anomalyHandler struct {
+	Usecase usecase.AnomalyUsecase
+}
+
+func NewAnomalyHandler(usecase usecase.AnomalyUsecase) AnomalyHandler {
+	return &anomalyHandler{
+		Usecase: usecase,
+	}
+}
+
+// query params: type, status, from, to (YYYY-MM-DD, inclusive), limit, offset
+func (h *anomalyHandler) HandlerGetPaymentAnomalies(c *gin.Context) {
+	var filter models.PaymentAnomalyFilter
+	var err error
+
+	intParams := map[string]*int{
+		"type":   &filter.AnomalyType,
+		"status": &filter.Status,
+		"limit":  &filter.Limit,
+		"offset": &filter.Offset,
+	}
+	for key, value := range intParams {
+		if c.Query(key) == "" {
+			continue
+		}
+
+		*value, err = strconv.Atoi(c.Query(key))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid " + key,
+			})
+
+			return
+		}
+	}
+
+	filter.StartTime, filter.EndTime, err = parseDateRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Invalid date range",
+			"error_message": err.Error(),
+		})
+
+		return
+	}
+
+	anomalies, err := h.Usecase.GetPaymentAnomalies(c.Request.Context(), filter)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("GetPaymentAnomalies got error: %v", err)
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get payment anomalies",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": anomalies,
+	})
+}
+
+func (h *anomalyHandler) HandlerGetPaymentAnomalyDetail(c *gin.Context) {
+	anomalyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid anomaly ID",
+		})
+
+		return
+	}
+
+	detail, err := h.Usecase.GetPaymentAnomalyDetail(c.Request.Context(), anomalyID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrPaymentAnomalyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Payment anomaly not found",
+			})
+
+			return
+		}
+
+		log.Logger.WithFields(logrus.Fields{
+			"id": anomalyID,
+		}).Errorf("GetPaymentAnomalyDetail got error: %v", err)
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get payment anomaly",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+func (h *anomalyHandler) HandlerResolvePaymentAnomaly(c *gin.Context) {
+	anomalyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid anomaly ID",
+		})
+
+		return
+	}
+
+	var payload models.ResolvePaymentAnomalyRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Invalid payload",
+			"error_message": err.Error(),
+		})
+
+		return
+	}
+
+	payload.ResolvedBy = int64(c.GetFloat64("user_id"))
+
+	err = h.Usecase.ResolvePaymentAnomaly(c.Request.Context(), anomalyID, payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrPaymentAnomalyNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Payment anomaly not found",
+			})
+		case errors.Is(err, service.ErrInvalidAnomalyResolution):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error_message": err.Error(),
+			})
+		case errors.Is(err, service.ErrAnomalyAlreadyResolved):
+			c.JSON(http.StatusConflict, gin.H{
+				"error_message": err.Error(),
+			})
+		default:
+			log.Logger.WithFields(logrus.Fields{
+				"id":      anomalyID,
+				"payload": payload,
+			}).Errorf("ResolvePaymentAnomaly got error: %v", err)
+
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to resolve payment anomaly",
+			})
+		}
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Success.",
+	})
+}
+
+// parse date range from YYYY-MM-DD, end date is inclusive so it is moved to the next day
+func parseDateRange(from string, to string) (time.Time, time.Time, error) {
+	var startTime, endTime time.Time
+	var err error
+
+	if from != "" {
+		startTime, err = time.ParseInLocation(time.DateOnly, from, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	if to != "" {
+		endTime, err = time.ParseInLocation(time.DateOnly, to, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+
+		endTime = endTime.AddDate(0, 0, 1)
+	}
+
+	if !startTime.IsZero() && !endTime.IsZero() && !startTime.Before(endTime) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+
+	return startTime, endTime, nil
+}
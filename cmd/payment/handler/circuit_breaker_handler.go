@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+	"payment/cmd/payment/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CircuitBreakerHandler interface {
+	HandlerGetCircuitBreakers(c *gin.Context)
+}
+
+type circuitBreakerHandler struct {
+	Usecase usecase.CircuitBreakerUsecase
+}
+
+func NewCircuitBreakerHandler(usecase usecase.CircuitBreakerUsecase) CircuitBreakerHandler {
+	return &circuitBreakerHandler{
+		Usecase: usecase,
+	}
+}
+
+func (h *circuitBreakerHandler) HandlerGetCircuitBreakers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": h.Usecase.GetCircuitBreakers(c.Request.Context()),
+	})
+}
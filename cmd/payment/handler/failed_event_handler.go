@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"payment/cmd/payment/usecase"
+	"payment/infrastructure/log"
+	"payment/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type FailedEventHandler interface {
+	HandlerGetFailedEvents(c *gin.Context)
+	HandlerReplayFailedEvents(c *gin.Context)
+	HandlerCloseFailedEvents(c *gin.Context)
+}
+
+type failedEventHandler struct {
+	Usecase usecase.FailedEventUsecase
+}
+
+type failedEventBulkRequest struct {
+	IDs   []int64 `json:"ids" binding:"required"`
+	Notes string  `json:"notes"`
+}
+
+func NewFailedEventHandler(usecase usecase.FailedEventUsecase) FailedEventHandler {
+	return &failedEventHandler{
+		Usecase: usecase,
+	}
+}
+
+// query params: status, limit, offset
+func (h *failedEventHandler) HandlerGetFailedEvents(c *gin.Context) {
+	var filter models.FailedEventFilter
+	var err error
+
+	intParams := map[string]*int{
+		"status": &filter.Status,
+		"limit":  &filter.Limit,
+		"offset": &filter.Offset,
+	}
+	for key, value := range intParams {
+		if c.Query(key) == "" {
+			continue
+		}
+
+		*value, err = strconv.Atoi(c.Query(key))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid " + key,
+			})
+
+			return
+		}
+	}
+
+	failedEvents, err := h.Usecase.GetFailedEvents(c.Request.Context(), filter)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("GetFailedEvents got error: %v", err)
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get failed events",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": failedEvents,
+	})
+}
+
+func (h *failedEventHandler) HandlerReplayFailedEvents(c *gin.Context) {
+	var payload failedEventBulkRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Invalid payload",
+			"error_message": err.Error(),
+		})
+
+		return
+	}
+
+	actor := fmt.Sprintf("user:%d", int64(c.GetFloat64("user_id")))
+	results, err := h.Usecase.ReplayFailedEvents(c.Request.Context(), payload.IDs, actor)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidFailedEventIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error_message": err.Error(),
+			})
+
+			return
+		}
+
+		log.Logger.WithFields(logrus.Fields{
+			"payload": payload,
+		}).Errorf("ReplayFailedEvents got error: %v", err)
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to replay failed events",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": results,
+	})
+}
+
+func (h *failedEventHandler) HandlerCloseFailedEvents(c *gin.Context) {
+	var payload failedEventBulkRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Invalid payload",
+			"error_message": err.Error(),
+		})
+
+		return
+	}
+
+	if payload.Notes == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error_message": "notes is required",
+		})
+
+		return
+	}
+
+	actor := fmt.Sprintf("user:%d", int64(c.GetFloat64("user_id")))
+	closed, err := h.Usecase.CloseFailedEvents(c.Request.Context(), payload.IDs, actor, payload.Notes)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidFailedEventIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error_message": err.Error(),
+			})
+
+			return
+		}
+
+		log.Logger.WithFields(logrus.Fields{
+			"payload": payload,
+		}).Errorf("CloseFailedEvents got error: %v", err)
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to close failed events",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"closed": closed,
+	})
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"payment/cmd/payment/usecase"
+	"payment/infrastructure/job"
+	"payment/infrastructure/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type JobHandler interface {
+	HandlerGetJobs(c *gin.Context)
+	HandlerControlJob(c *gin.Context)
+}
+
+type jobHandler struct {
+	Usecase usecase.JobUsecase
+}
+
+func NewJobHandler(usecase usecase.JobUsecase) JobHandler {
+	return &jobHandler{
+		Usecase: usecase,
+	}
+}
+
+func (h *jobHandler) HandlerGetJobs(c *gin.Context) {
+	jobs, err := h.Usecase.GetJobs(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get jobs",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": jobs,
+	})
+}
+
+// path params: name, action (trigger, pause, resume)
+func (h *jobHandler) HandlerControlJob(c *gin.Context) {
+	name := c.Param("name")
+	action := c.Param("action")
+
+	actor := fmt.Sprintf("user:%d", int64(c.GetFloat64("user_id")))
+	err := h.Usecase.ControlJob(c.Request.Context(), name, action, actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidJobAction), errors.Is(err, job.ErrJobDisabled):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error_message": err.Error(),
+			})
+		case errors.Is(err, job.ErrJobNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error_message": err.Error(),
+			})
+		default:
+			log.Logger.WithFields(logrus.Fields{
+				"job":    name,
+				"action": action,
+			}).Errorf("ControlJob got error: %v", err)
+
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to " + action + " job",
+			})
+		}
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Success.",
+	})
+}
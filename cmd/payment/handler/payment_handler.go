@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"payment/cmd/payment/usecase"
+	"payment/infrastructure/constant"
 	"payment/infrastructure/log"
 	"payment/models"
 	"strconv"
@@ -13,68 +15,108 @@ import (
 
 type PaymentHandler interface {
 	HandleXenditWebhook(c *gin.Context)
+	HandleMidtransWebhook(c *gin.Context)
 	HandlerDownloadPDFInvoice(c *gin.Context)
+	HandlerGetPaymentInfo(c *gin.Context)
 }
 
 type paymentHandler struct {
-	Usecase            usecase.PaymentUsecase
-	XenditWebhookToken string
+	Usecase             usecase.PaymentUsecase
+	WebhookInboxUsecase usecase.WebhookInboxUsecase
 }
 
-func NewPaymentHandler(usecase usecase.PaymentUsecase, xenditWebhookToken string) PaymentHandler {
+func NewPaymentHandler(usecase usecase.PaymentUsecase, webhookInboxUsecase usecase.WebhookInboxUsecase) PaymentHandler {
 	return &paymentHandler{
-		Usecase:            usecase,
-		XenditWebhookToken: xenditWebhookToken,
+		Usecase:             usecase,
+		WebhookInboxUsecase: webhookInboxUsecase,
 	}
 }
 
 func (h *paymentHandler) HandleXenditWebhook(c *gin.Context) {
-	var payload models.XenditWebhookPayload
-	if err := c.ShouldBindJSON(&payload); err != nil {
-		log.Logger.WithFields(logrus.Fields{
-			"payload": payload,
-		})
-
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":         "Invalid payload",
-			"error_message": err.Error(),
-		})
+	h.receiveWebhook(c, constant.GatewayProviderXendit)
+}
 
-		return
-	}
+func (h *paymentHandler) HandleMidtransWebhook(c *gin.Context) {
+	h.receiveWebhook(c, constant.GatewayProviderMidtrans)
+}
 
-	// validate webhook token
-	headerWebhookToken := c.GetHeader("x-callback-token")
-	if h.XenditWebhookToken != headerWebhookToken {
-		log.Logger.WithFields(logrus.Fields{
-			"xendit_callback_webhook_token": headerWebhookToken,
-		}).Errorf("Invalid Webhook token: %s", headerWebhookToken)
+// receiveWebhook only store the webhook into inbox, it is processed asynchronously so the provider is acknowledged quickly.
+func (h *paymentHandler) receiveWebhook(c *gin.Context, provider string) {
+	rawBody, err := c.GetRawData()
+	if err != nil {
+		log.Logger.Errorf("receiveWebhook => c.GetRawData() got error: %v", err)
 
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error_message": "Invalid webhook token",
+			"error":         "Invalid payload",
+			"error_message": err.Error(),
 		})
 
 		return
 	}
 
-	err := h.Usecase.ProcessPaymentWebhook(c.Request.Context(), payload)
+	inbox, err := h.WebhookInboxUsecase.ReceiveWebhook(c.Request.Context(), provider, c.Request.Header, rawBody)
 	if err != nil {
+		if errors.Is(err, usecase.ErrDuplicateWebhook) {
+			c.JSON(http.StatusOK, gin.H{
+				"message":   "Success.",
+				"duplicate": true,
+			})
+
+			return
+		}
+
+		if errors.Is(err, usecase.ErrInvalidWebhookSignature) {
+			log.Logger.WithFields(logrus.Fields{
+				"provider":  provider,
+				"client_ip": c.ClientIP(),
+			}).Error("Invalid Webhook token")
+
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error_message": "Invalid webhook token",
+			})
+
+			return
+		}
+
+		if errors.Is(err, usecase.ErrUnknownWebhookSource) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error_message": "Payment gateway is not enabled",
+			})
+
+			return
+		}
+
+		if errors.Is(err, usecase.ErrInvalidWebhookPayload) {
+			log.Logger.WithFields(logrus.Fields{
+				"provider": provider,
+				"payload":  string(rawBody),
+			}).Errorf("receiveWebhook got invalid payload: %v", err)
+
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":         "Invalid payload",
+				"error_message": err.Error(),
+			})
+
+			return
+		}
+
 		log.Logger.WithFields(logrus.Fields{
-			"payload": payload,
-		})
+			"provider": provider,
+			"payload":  string(rawBody),
+		}).Errorf("ReceiveWebhook got error: %v", err)
 
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error_message": err.Error(),
+		// not stored, let the provider retry the webhook
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error_message": "Failed to receive webhook",
 		})
 
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Success.",
+		"message":  "Success.",
+		"inbox_id": inbox.ID,
 	})
-
-	return
 }
 
 func (h *paymentHandler) HandlerCreateInvoice(c *gin.Context) {
@@ -115,3 +157,40 @@ func (h *paymentHandler) HandlerDownloadPDFInvoice(c *gin.Context) {
 
 	c.FileAttachment(filePath, filePath)
 }
+
+func (h *paymentHandler) HandlerGetPaymentInfo(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("order_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid order ID",
+		})
+
+		return
+	}
+
+	userID := int64(c.GetFloat64("user_id"))
+
+	paymentInfo, err := h.Usecase.GetPaymentInfo(c.Request.Context(), orderID, userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrPaymentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Payment not found",
+			})
+
+			return
+		}
+
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+			"user_id":  userID,
+		}).Errorf("GetPaymentInfo got error: %v", err)
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get payment info",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, paymentInfo)
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"payment/cmd/payment/usecase"
+	"payment/infrastructure/log"
+	"payment/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type ReconciliationHandler interface {
+	HandlerGetReconciliationRuns(c *gin.Context)
+	HandlerGetReconciliationDiscrepancies(c *gin.Context)
+}
+
+type reconciliationHandler struct {
+	Usecase usecase.ReconciliationUsecase
+}
+
+func NewReconciliationHandler(usecase usecase.ReconciliationUsecase) ReconciliationHandler {
+	return &reconciliationHandler{
+		Usecase: usecase,
+	}
+}
+
+// query params: from, to (YYYY-MM-DD, inclusive, filter by period start), limit, offset
+func (h *reconciliationHandler) HandlerGetReconciliationRuns(c *gin.Context) {
+	var filter models.ReconciliationRunFilter
+	var err error
+
+	intParams := map[string]*int{
+		"limit":  &filter.Limit,
+		"offset": &filter.Offset,
+	}
+	for key, value := range intParams {
+		if c.Query(key) == "" {
+			continue
+		}
+
+		*value, err = strconv.Atoi(c.Query(key))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid " + key,
+			})
+
+			return
+		}
+	}
+
+	filter.StartTime, filter.EndTime, err = parseDateRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Invalid date range",
+			"error_message": err.Error(),
+		})
+
+		return
+	}
+
+	runs, err := h.Usecase.GetReconciliationRuns(c.Request.Context(), filter)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("GetReconciliationRuns got error: %v", err)
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get reconciliation runs",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": runs,
+	})
+}
+
+// query params: format (json or csv)
+func (h *reconciliationHandler) HandlerGetReconciliationDiscrepancies(c *gin.Context) {
+	runID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid reconciliation run ID",
+		})
+
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	switch format {
+	case "json":
+		discrepancies, err := h.Usecase.GetReconciliationDiscrepancies(c.Request.Context(), runID)
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"run_id": runID,
+			}).Errorf("GetReconciliationDiscrepancies got error: %v", err)
+
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get reconciliation discrepancies",
+			})
+
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data": discrepancies,
+		})
+	case "csv":
+		// csv is buffered so error can still be returned as json
+		var buf bytes.Buffer
+		err = h.Usecase.ExportReconciliationDiscrepancies(c.Request.Context(), runID, &buf)
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"run_id": runID,
+			}).Errorf("ExportReconciliationDiscrepancies got error: %v", err)
+
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to export reconciliation discrepancies",
+			})
+
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=reconciliation_%d.csv", runID))
+		c.Data(http.StatusOK, "text/csv", buf.Bytes())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid format",
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"payment/cmd/payment/service"
+	"payment/cmd/payment/usecase"
+	"payment/infrastructure/breaker"
+	"payment/infrastructure/log"
+	"payment/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type RefundHandler interface {
+	HandlerCreateRefund(c *gin.Context)
+}
+
+type refundHandler struct {
+	Usecase usecase.RefundUsecase
+}
+
+func NewRefundHandler(usecase usecase.RefundUsecase) RefundHandler {
+	return &refundHandler{
+		Usecase: usecase,
+	}
+}
+
+func (h *refundHandler) HandlerCreateRefund(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("order_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid order ID",
+		})
+
+		return
+	}
+
+	var payload models.RefundRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Invalid payload",
+			"error_message": err.Error(),
+		})
+
+		return
+	}
+
+	payload.OrderID = orderID
+	payload.RequestedBy = int64(c.GetFloat64("user_id"))
+
+	refund, err := h.Usecase.CreateRefund(c.Request.Context(), payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrPaymentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Payment not found",
+			})
+		case errors.Is(err, service.ErrPaymentNotRefundable),
+			errors.Is(err, service.ErrInvalidRefundAmount),
+			errors.Is(err, service.ErrRefundAmountExceeded),
+			errors.Is(err, service.ErrInvalidRefundReason):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error_message": err.Error(),
+			})
+		case errors.Is(err, breaker.ErrOpen):
+			// refund is marked as failed, it can be requested again once the gateway is healthy
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Payment gateway is unavailable, please try again later",
+			})
+		default:
+			log.Logger.WithFields(logrus.Fields{
+				"payload": payload,
+			}).Errorf("CreateRefund got error: %v", err)
+
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to create refund",
+			})
+		}
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, refund)
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"payment/cmd/payment/usecase"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+var reportContentTypes = map[string]string{
+	constant.ReportFormatCSV:  "text/csv",
+	constant.ReportFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+type ReportHandler interface {
+	HandlerGetSettlementReport(c *gin.Context)
+}
+
+type reportHandler struct {
+	Usecase usecase.ReportUsecase
+}
+
+func NewReportHandler(usecase usecase.ReportUsecase) ReportHandler {
+	return &reportHandler{
+		Usecase: usecase,
+	}
+}
+
+// query params: from, to (YYYY-MM-DD, inclusive), format (csv or xlsx)
+func (h *reportHandler) HandlerGetSettlementReport(c *gin.Context) {
+	startTime, endTime, err := parseDateRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Invalid date range",
+			"error_message": err.Error(),
+		})
+
+		return
+	}
+
+	format := c.DefaultQuery("format", constant.ReportFormatCSV)
+
+	// report is buffered so error can still be returned as json
+	var buf bytes.Buffer
+	err = h.Usecase.ExportSettlementReport(c.Request.Context(), startTime, endTime, format, &buf)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidReportFormat) || errors.Is(err, usecase.ErrInvalidReportPeriod) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error_message": err.Error(),
+			})
+
+			return
+		}
+
+		log.Logger.WithFields(logrus.Fields{
+			"from":   c.Query("from"),
+			"to":     c.Query("to"),
+			"format": format,
+		}).Errorf("ExportSettlementReport got error: %v", err)
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to export settlement report",
+		})
+
+		return
+	}
+
+	filename := fmt.Sprintf("settlement_%s_%s.%s", c.Query("from"), c.Query("to"), format)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, reportContentTypes[format], buf.Bytes())
+}
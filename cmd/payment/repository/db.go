@@ -2,26 +2,31 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"payment/infrastructure/constant"
 	"payment/infrastructure/log"
 	"payment/models"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaymentDatabase interface {
-	MarkPaid(ctx context.Context, orderID int64) error
-	MarkExpired(ctx context.Context, paymentID int64) error
+	MarkPaid(ctx context.Context, orderID int64, detail models.PaymentPaidDetail) error
+	MarkExpired(ctx context.Context, orderID int64) error
+	MarkFailed(ctx context.Context, orderID int64) error
 	SavePayment(ctx context.Context, param models.Payment) error
-	IsAlreadyPaid(ctx context.Context, orderID int64) (bool, error)
-	CheckPaymentAmountByOrderID(ctx context.Context, orderID int64) (float64, error)
+	CheckPaymentAmountByOrderID(ctx context.Context, orderID int64) (models.Money, error)
 	SavePaymentAnomaly(ctx context.Context, param models.PaymentAnomaly) error
 	SaveFailedPublishEvent(ctx context.Context, param models.FailedEvents) error
 	SavePaymentRequest(ctx context.Context, param models.PaymentRequests) error
 	GetPendingInvoices(ctx context.Context) ([]models.Payment, error)
 	GetPaymentInfoByOrderID(ctx context.Context, orderID int64) (*models.Payment, error)
-	GetPendingPaymentRequests(ctx context.Context, paymentRequests *[]models.PaymentRequests) error
+	GetPaymentInfoByOrderIDForUpdate(ctx context.Context, orderID int64) (*models.Payment, error)
+	ClaimPaymentRequests(ctx context.Context, claimedBy string, leaseExpireTime time.Time, limit int) ([]models.PaymentRequests, error)
 	GetFailedPaymentRequests(ctx context.Context, paymentRequests *[]models.PaymentRequests) error
 	GetExpiredPendingPayments(ctx context.Context) ([]models.Payment, error)
 	UpdateSuccessPaymentRequest(ctx context.Context, paymentRequestID int64) error
@@ -30,19 +35,82 @@ type PaymentDatabase interface {
 
 	// audit logs
 	InsertAuditLog(ctx context.Context, param models.PaymentAuditLog) error
+	GetAuditLogsByOrderID(ctx context.Context, orderID int64) ([]models.PaymentAuditLog, error)
+
+	// failed events
+	GetFailedEvents(ctx context.Context, filter models.FailedEventFilter) ([]models.FailedEvents, error)
+	GetFailedEventsByIDs(ctx context.Context, ids []int64) ([]models.FailedEvents, error)
+	UpdateFailedEventsStatus(ctx context.Context, ids []int64, fromStatuses []int, status int, notes string) (int64, error)
+	UpdateFailedEventRetry(ctx context.Context, id int64, status int, notes string) error
+
+	// transaction
+	WithTransaction(ctx context.Context, fn func(tx PaymentDatabase) error) error
+
+	// outbox
+	SaveOutbox(ctx context.Context, param *models.Outbox) error
+	GetPendingOutbox(ctx context.Context, limit int) ([]models.Outbox, error)
+	MarkOutboxPublished(ctx context.Context, id int64) error
+	UpdateOutboxFailed(ctx context.Context, id int64, lastError string) error
+
+	// webhook inbox
+	SaveWebhookInbox(ctx context.Context, param *models.WebhookInbox) error
+	GetWebhookInboxByID(ctx context.Context, id int64) (*models.WebhookInbox, error)
+	GetWebhookInboxes(ctx context.Context, filter models.WebhookInboxFilter) ([]models.WebhookInbox, error)
+	GetRetryableWebhookInboxes(ctx context.Context, receivedBefore time.Time, staleBefore time.Time, maxAttempts int, limit int) ([]models.WebhookInbox, error)
+	ClaimWebhookInbox(ctx context.Context, id int64, staleBefore time.Time) (bool, error)
+	UpdateWebhookInboxResult(ctx context.Context, id int64, status string, result string) error
+
+	// payment anomalies
+	GetPaymentAnomalies(ctx context.Context, filter models.PaymentAnomalyFilter) ([]models.PaymentAnomaly, error)
+	GetPaymentAnomalyByID(ctx context.Context, anomalyID int64) (*models.PaymentAnomaly, error)
+	ResolvePaymentAnomaly(ctx context.Context, anomalyID int64, status int, resolvedBy int64, notes string) (bool, error)
+
+	// refunds
+	MarkRefunded(ctx context.Context, orderID int64) error
+	SaveRefund(ctx context.Context, param *models.Refund) error
+	GetRefundedAmountByOrderID(ctx context.Context, orderID int64) (int64, error)
+	GetSucceededRefundAmountByOrderID(ctx context.Context, orderID int64) (int64, error)
+	GetPendingRefunds(ctx context.Context) ([]models.Refund, error)
+	UpdateRefund(ctx context.Context, refundID int64, gatewayRefundID string, status string, notes string) error
+
+	// reconciliation
+	GetPaymentsByCreateTime(ctx context.Context, startTime time.Time, endTime time.Time) ([]models.Payment, error)
+	GetPaymentsByExternalIDs(ctx context.Context, externalIDs []string) ([]models.Payment, error)
+	SaveReconciliationRun(ctx context.Context, param *models.ReconciliationRun) error
+	SaveReconciliationDiscrepancies(ctx context.Context, discrepancies []models.ReconciliationDiscrepancy) error
+	GetReconciliationRuns(ctx context.Context, filter models.ReconciliationRunFilter) ([]models.ReconciliationRun, error)
+	GetReconciliationRunByPeriod(ctx context.Context, periodStart time.Time, periodEnd time.Time) (*models.ReconciliationRun, error)
+	GetReconciliationDiscrepancies(ctx context.Context, runID int64) ([]models.ReconciliationDiscrepancy, error)
+
+	// reports
+	GetSettlementSummaries(ctx context.Context, startTime time.Time, endTime time.Time) ([]models.SettlementSummary, error)
 }
 
 type paymentDatabase struct {
 	DB *gorm.DB
+
+	// connection outside of transaction, used to store data which must survive a rollback
+	rootDB *gorm.DB
 }
 
 func NewPaymentDatabase(db *gorm.DB) PaymentDatabase {
 	return &paymentDatabase{
-		DB: db,
+		DB:     db,
+		rootDB: db,
 	}
 }
 
-func (r *paymentDatabase) CheckPaymentAmountByOrderID(ctx context.Context, orderID int64) (float64, error) {
+// WithTransaction run fn in a single DB transaction, it is rolled back when fn return error.
+func (r *paymentDatabase) WithTransaction(ctx context.Context, fn func(tx PaymentDatabase) error) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&paymentDatabase{
+			DB:     tx,
+			rootDB: r.rootDB,
+		})
+	})
+}
+
+func (r *paymentDatabase) CheckPaymentAmountByOrderID(ctx context.Context, orderID int64) (models.Money, error) {
 	var result models.Payment
 	err := r.DB.Table("payments").WithContext(ctx).Where("order_id = ?", orderID).First(&result).Error
 	if err != nil {
@@ -50,23 +118,102 @@ func (r *paymentDatabase) CheckPaymentAmountByOrderID(ctx context.Context, order
 			"order_id": orderID,
 		}).Errorf("Repository => CheckPaymentAmountByOrderID got error: %v", err)
 
-		return 0, err
+		return models.Money{}, err
 	}
 
 	return result.Amount, nil
 }
 
-func (r *paymentDatabase) MarkPaid(ctx context.Context, orderID int64) error {
-	err := r.DB.Model(&models.Payment{}).Table("payments").WithContext(ctx).Where("order_id = ?", orderID).Update("status", "paid").Error
+func (r *paymentDatabase) MarkPaid(ctx context.Context, orderID int64, detail models.PaymentPaidDetail) error {
+	return r.updatePaymentStatus(ctx, orderID, models.PaymentStatusPaid, map[string]interface{}{
+		"payment_method": detail.PaymentMethod,
+		"paid_time":      detail.PaidAt,
+	})
+}
+
+func (r *paymentDatabase) MarkExpired(ctx context.Context, orderID int64) error {
+	return r.updatePaymentStatus(ctx, orderID, models.PaymentStatusExpired, nil)
+}
+
+func (r *paymentDatabase) MarkFailed(ctx context.Context, orderID int64) error {
+	return r.updatePaymentStatus(ctx, orderID, models.PaymentStatusFailed, nil)
+}
+
+func (r *paymentDatabase) MarkRefunded(ctx context.Context, orderID int64) error {
+	return r.updatePaymentStatus(ctx, orderID, models.PaymentStatusRefunded, nil)
+}
+
+// updatePaymentStatus only update payment which current status is allowed to move to the given status.
+// Rejected transition is stored as payment anomaly, updating to the current status is a no-op.
+func (r *paymentDatabase) updatePaymentStatus(ctx context.Context, orderID int64, status models.PaymentStatus, fields map[string]interface{}) error {
+	updates := map[string]interface{}{
+		"status":      status,
+		"update_time": time.Now(),
+	}
+	for column, value := range fields {
+		updates[column] = value
+	}
+
+	result := r.DB.Model(&models.Payment{}).Table("payments").WithContext(ctx).
+		Where("order_id = ? AND status IN ?", orderID, models.PreviousPaymentStatuses(status)).
+		Updates(updates)
+	if result.Error != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+			"status":   status,
+		}).Errorf("updatePaymentStatus => r.DB.Update() got error: %v", result.Error)
+
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var current models.Payment
+	err := r.DB.Table("payments").WithContext(ctx).Where("order_id = ?", orderID).First(&current).Error
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
 			"order_id": orderID,
-		}).Errorf("MarkPaid => r.DB.Update() MarkPaid got error: %v", err)
+			"status":   status,
+		}).Errorf("updatePaymentStatus => r.DB.First() got error: %v", err)
 
 		return err
 	}
 
-	return nil
+	if current.Status == status {
+		return nil
+	}
+
+	err = current.Status.ValidateTransition(status)
+	if err == nil {
+		// status changed between update and select, let caller retry
+		return fmt.Errorf("payment status of order %d changed concurrently", orderID)
+	}
+
+	log.Logger.WithFields(logrus.Fields{
+		"order_id":       orderID,
+		"current_status": current.Status,
+		"status":         status,
+	}).Warnf("updatePaymentStatus => %v", err)
+
+	// anomaly must be kept even when the caller transaction is rolled back
+	root := &paymentDatabase{DB: r.rootDB, rootDB: r.rootDB}
+	errSaveAnomaly := root.SavePaymentAnomaly(ctx, models.PaymentAnomaly{
+		OrderID:     orderID,
+		ExternalID:  current.ExternalID,
+		AnomalyType: constant.AnomalyTypeInvalidStatusTransition,
+		Notes:       err.Error(),
+		Status:      constant.PaymentAnomalyStatusNeedToCheck,
+		CreateTime:  time.Now(),
+	})
+	if errSaveAnomaly != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+		}).Errorf("updatePaymentStatus => r.SavePaymentAnomaly() got error: %v", errSaveAnomaly)
+	}
+
+	return err
 }
 
 func (r *paymentDatabase) GetPaymentInfoByOrderID(ctx context.Context, orderID int64) (*models.Payment, error) {
@@ -83,6 +230,21 @@ func (r *paymentDatabase) GetPaymentInfoByOrderID(ctx context.Context, orderID i
 	return &payment, nil
 }
 
+// GetPaymentInfoByOrderIDForUpdate lock the payment row until the transaction ends, it must be called inside WithTransaction.
+func (r *paymentDatabase) GetPaymentInfoByOrderIDForUpdate(ctx context.Context, orderID int64) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.DB.Table("payments").WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", orderID).First(&payment).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+		}).Errorf("GetPaymentInfoByOrderIDForUpdate => r.DB.First() got error: %v", err)
+
+		return &models.Payment{}, err
+	}
+
+	return &payment, nil
+}
+
 func (r *paymentDatabase) SavePayment(ctx context.Context, param models.Payment) error {
 	err := r.DB.Create(param).Error
 	if err != nil {
@@ -109,19 +271,9 @@ func (r *paymentDatabase) SaveFailedPublishEvent(ctx context.Context, param mode
 	return nil
 }
 
-func (r *paymentDatabase) IsAlreadyPaid(ctx context.Context, orderID int64) (bool, error) {
-	var result models.Payment
-	err := r.DB.Table("payments").WithContext(ctx).Where("external_id = ?", orderID).First(&result).Error
-	if err != nil {
-		return false, err
-	}
-
-	return result.Status == "PAID", nil
-}
-
 func (r *paymentDatabase) GetPendingInvoices(ctx context.Context) ([]models.Payment, error) {
 	var result []models.Payment
-	err := r.DB.Table("payments").WithContext(ctx).Where("status = ? AND create_time >= now() - interval '1 day'", "PENDING").Find(&result).Error
+	err := r.DB.Table("payments").WithContext(ctx).Where("status = ? AND create_time >= now() - interval '1 day'", models.PaymentStatusPending).Find(&result).Error
 	if err != nil {
 		return nil, err
 	}
@@ -144,11 +296,15 @@ func (r *paymentDatabase) SavePaymentAnomaly(ctx context.Context, param models.P
 
 func (r *paymentDatabase) SavePaymentRequest(ctx context.Context, param models.PaymentRequests) error {
 	err := r.DB.Table("payment_requests").WithContext(ctx).Create(models.PaymentRequests{
-		OrderID:    param.OrderID,
-		UserID:     param.UserID,
-		Amount:     param.Amount,
-		Status:     param.Status,
-		CreateTime: param.CreateTime,
+		OrderID:       param.OrderID,
+		UserID:        param.UserID,
+		Amount:        param.Amount,
+		PaymentMethod: param.PaymentMethod,
+		CustomerName:  param.CustomerName,
+		CustomerPhone: param.CustomerPhone,
+		Items:         param.Items,
+		Status:        param.Status,
+		CreateTime:    param.CreateTime,
 	}).Error
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
@@ -161,22 +317,40 @@ func (r *paymentDatabase) SavePaymentRequest(ctx context.Context, param models.P
 	return nil
 }
 
-func (r *paymentDatabase) GetPendingPaymentRequests(ctx context.Context, paymentRequests *[]models.PaymentRequests) error {
-	// batch size of 5
-	err := r.DB.Table("payment_requests").WithContext(ctx).Where("status = ?", "PENDING").Order("create_time ASC").Limit(5).Find(paymentRequests).Error
+// ClaimPaymentRequests mark pending requests as processing by claimedBy and return them. Request which is
+// locked by other worker is skipped, processing request whose lease is expired is claimed again.
+func (r *paymentDatabase) ClaimPaymentRequests(ctx context.Context, claimedBy string, leaseExpireTime time.Time, limit int) ([]models.PaymentRequests, error) {
+	now := time.Now()
+	claimable := r.DB.Table("payment_requests").Select("id").
+		Where("status = ? OR (status = ? AND lease_expire_time < ?)",
+			constant.PaymentRequestStatusPending, constant.PaymentRequestStatusProcessing, now).
+		Order("create_time ASC").Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+
+	var paymentRequests []models.PaymentRequests
+	err := r.DB.Table("payment_requests").WithContext(ctx).Model(&paymentRequests).
+		Clauses(clause.Returning{}).
+		Where("id IN (?)", claimable).
+		Updates(map[string]interface{}{
+			"status":            constant.PaymentRequestStatusProcessing,
+			"claimed_by":        claimedBy,
+			"lease_expire_time": leaseExpireTime,
+			"update_time":       now,
+		}).Error
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
-			"error": err,
-		}).Errorf("GetPendingPaymentRequests => r.DB.Find() got error: %v", err)
+			"claimed_by": claimedBy,
+			"limit":      limit,
+		}).Errorf("ClaimPaymentRequests => r.DB.Update() got error: %v", err)
 
-		return err
+		return nil, err
 	}
 
-	return nil
+	return paymentRequests, nil
 }
 
 func (r *paymentDatabase) GetFailedPaymentRequests(ctx context.Context, paymentRequests *[]models.PaymentRequests) error {
-	err := r.DB.Table("payment_requests").WithContext(ctx).Where("status = ?", "FAILED").Where("retry_count <= ?", 3).Order("create_time ASC").Limit(5).Find(paymentRequests).Error
+	err := r.DB.Table("payment_requests").WithContext(ctx).Where("status = ?", constant.PaymentRequestStatusFailed).Where("retry_count <= ?", 3).Order("create_time ASC").Limit(5).Find(paymentRequests).Error
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
 			"error": err,
@@ -190,13 +364,14 @@ func (r *paymentDatabase) GetFailedPaymentRequests(ctx context.Context, paymentR
 
 func (r *paymentDatabase) UpdateSuccessPaymentRequest(ctx context.Context, paymentRequestID int64) error {
 	err := r.DB.Table("payment_requests").WithContext(ctx).Where("id = ?", paymentRequestID).Updates(map[string]interface{}{
-		"status":      "SUCCESS",
-		"update_time": time.Now(),
+		"status":            constant.PaymentRequestStatusSuccess,
+		"lease_expire_time": nil,
+		"update_time":       time.Now(),
 	}).Error
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
 			"id":          paymentRequestID,
-			"status":      "SUCCESS",
+			"status":      constant.PaymentRequestStatusSuccess,
 			"update_time": time.Now(),
 		}).Errorf("UpdateSuccessPaymentRequest => r.DB.Update() got error: %v", err)
 
@@ -208,15 +383,16 @@ func (r *paymentDatabase) UpdateSuccessPaymentRequest(ctx context.Context, payme
 
 func (r *paymentDatabase) UpdateFailedPaymentRequest(ctx context.Context, paymentRequestID int64, notes string) error {
 	err := r.DB.Table("payment_requests").WithContext(ctx).Where("id = ?", paymentRequestID).Updates(map[string]interface{}{
-		"status":      "FAILED",
-		"notes":       notes,
-		"retry_count": gorm.Expr("retry_count + 1"),
-		"update_time": time.Now(),
+		"status":            constant.PaymentRequestStatusFailed,
+		"lease_expire_time": nil,
+		"notes":             notes,
+		"retry_count":       gorm.Expr("retry_count + 1"),
+		"update_time":       time.Now(),
 	}).Error
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
 			"id":          paymentRequestID,
-			"status":      "FAILED",
+			"status":      constant.PaymentRequestStatusFailed,
 			"update_time": time.Now(),
 			"notes":       notes,
 		}).Errorf("UpdateFailedPaymentRequest => r.DB.Update() got error: %v", err)
@@ -229,13 +405,14 @@ func (r *paymentDatabase) UpdateFailedPaymentRequest(ctx context.Context, paymen
 
 func (r *paymentDatabase) UpdatePendingPaymentRequest(ctx context.Context, paymentRequestID int64) error {
 	err := r.DB.Table("payment_requests").WithContext(ctx).Where("id = ?", paymentRequestID).Updates(map[string]interface{}{
-		"status":      "PENDING",
-		"update_time": time.Now(),
+		"status":            constant.PaymentRequestStatusPending,
+		"lease_expire_time": nil,
+		"update_time":       time.Now(),
 	}).Error
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
 			"id":          paymentRequestID,
-			"status":      "PENDING",
+			"status":      constant.PaymentRequestStatusPending,
 			"update_time": time.Now(),
 		}).Errorf("UpdatePendingPaymentRequest => r.DB.Update() got error: %v", err)
 
@@ -247,7 +424,7 @@ func (r *paymentDatabase) UpdatePendingPaymentRequest(ctx context.Context, payme
 
 func (r *paymentDatabase) GetExpiredPendingPayments(ctx context.Context) ([]models.Payment, error) {
 	var payments []models.Payment
-	err := r.DB.Table("payments").WithContext(ctx).Where("status = ? AND expired_time < ?", "PENDING", time.Now()).Find(&payments).Error
+	err := r.DB.Table("payments").WithContext(ctx).Where("status = ? AND expired_time < ?", models.PaymentStatusPending, time.Now()).Find(&payments).Error
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
 			"error": err,
@@ -259,15 +436,102 @@ func (r *paymentDatabase) GetExpiredPendingPayments(ctx context.Context) ([]mode
 	return payments, nil
 }
 
-func (r *paymentDatabase) MarkExpired(ctx context.Context, paymentID int64) error {
-	err := r.DB.Model(&models.Payment{}).Table("payments").WithContext(ctx).Where("id = ?", paymentID).Updates(map[string]interface{}{
-		"status":      "EXPIRED",
+func (r *paymentDatabase) InsertAuditLog(ctx context.Context, param models.PaymentAuditLog) error {
+	err := r.DB.Table("payment_audit_logs").WithContext(ctx).Create(param).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"param": param,
+		}).Errorf("InsertAuditLog => r.DB.Create() got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *paymentDatabase) GetAuditLogsByOrderID(ctx context.Context, orderID int64) ([]models.PaymentAuditLog, error) {
+	var auditLogs []models.PaymentAuditLog
+	err := r.DB.Table("payment_audit_logs").WithContext(ctx).Where("order_id = ?", orderID).Order("create_time ASC").Find(&auditLogs).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+		}).Errorf("GetAuditLogsByOrderID => r.DB.Find() got error: %v", err)
+
+		return nil, err
+	}
+
+	return auditLogs, nil
+}
+
+func (r *paymentDatabase) GetFailedEvents(ctx context.Context, filter models.FailedEventFilter) ([]models.FailedEvents, error) {
+	var failedEvents []models.FailedEvents
+	query := r.DB.Table("failed_events").WithContext(ctx)
+
+	if filter.Status != 0 {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	err := query.Order("create_time ASC").Limit(filter.Limit).Offset(filter.Offset).Find(&failedEvents).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("GetFailedEvents => r.DB.Find() got error: %v", err)
+
+		return nil, err
+	}
+
+	return failedEvents, nil
+}
+
+func (r *paymentDatabase) GetFailedEventsByIDs(ctx context.Context, ids []int64) ([]models.FailedEvents, error) {
+	var failedEvents []models.FailedEvents
+	err := r.DB.Table("failed_events").WithContext(ctx).Where("id IN ?", ids).Order("create_time ASC").Find(&failedEvents).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"ids": ids,
+		}).Errorf("GetFailedEventsByIDs => r.DB.Find() got error: %v", err)
+
+		return nil, err
+	}
+
+	return failedEvents, nil
+}
+
+// update status of failed events which currently in one of fromStatuses, return number of updated rows.
+func (r *paymentDatabase) UpdateFailedEventsStatus(ctx context.Context, ids []int64, fromStatuses []int, status int, notes string) (int64, error) {
+	result := r.DB.Table("failed_events").WithContext(ctx).
+		Where("id IN ? AND status IN ?", ids, fromStatuses).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"notes":       notes,
+			"update_time": time.Now(),
+		})
+	if result.Error != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"ids":    ids,
+			"status": status,
+			"notes":  notes,
+		}).Errorf("UpdateFailedEventsStatus => r.DB.Update() got error: %v", result.Error)
+
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+func (r *paymentDatabase) UpdateFailedEventRetry(ctx context.Context, id int64, status int, notes string) error {
+	err := r.DB.Table("failed_events").WithContext(ctx).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      status,
+		"notes":       notes,
+		"retry_count": gorm.Expr("retry_count + 1"),
 		"update_time": time.Now(),
 	}).Error
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
-			"id": paymentID,
-		}).Errorf("MarkExpired => r.DB.Update() got error: %v", err)
+			"id":     id,
+			"status": status,
+			"notes":  notes,
+		}).Errorf("UpdateFailedEventRetry => r.DB.Update() got error: %v", err)
 
 		return err
 	}
@@ -275,15 +539,532 @@ func (r *paymentDatabase) MarkExpired(ctx context.Context, paymentID int64) erro
 	return nil
 }
 
-func (r *paymentDatabase) InsertAuditLog(ctx context.Context, param models.PaymentAuditLog) error {
-	err := r.DB.Table("payment_audit_logs").WithContext(ctx).Create(param).Error
+func (r *paymentDatabase) SaveOutbox(ctx context.Context, param *models.Outbox) error {
+	err := r.DB.Table("outbox").WithContext(ctx).Create(param).Error
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
 			"param": param,
-		}).Errorf("InsertAuditLog => r.DB.Create() got error: %v", err)
+		}).Errorf("SaveOutbox => r.DB.Create() got error: %v", err)
 
 		return err
 	}
 
 	return nil
 }
+
+// GetPendingOutbox return pending outbox ordered by id, so event is published in the order it is stored.
+func (r *paymentDatabase) GetPendingOutbox(ctx context.Context, limit int) ([]models.Outbox, error) {
+	var outboxes []models.Outbox
+	err := r.DB.Table("outbox").WithContext(ctx).
+		Where("status = ?", constant.OutboxStatusPending).
+		Order("id ASC").Limit(limit).Find(&outboxes).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"limit": limit,
+		}).Errorf("GetPendingOutbox => r.DB.Find() got error: %v", err)
+
+		return nil, err
+	}
+
+	return outboxes, nil
+}
+
+func (r *paymentDatabase) MarkOutboxPublished(ctx context.Context, id int64) error {
+	now := time.Now()
+	err := r.DB.Table("outbox").WithContext(ctx).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       constant.OutboxStatusPublished,
+		"attempts":     gorm.Expr("attempts + 1"),
+		"publish_time": now,
+		"update_time":  now,
+	}).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id": id,
+		}).Errorf("MarkOutboxPublished => r.DB.Update() got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *paymentDatabase) UpdateOutboxFailed(ctx context.Context, id int64, lastError string) error {
+	err := r.DB.Table("outbox").WithContext(ctx).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":    gorm.Expr("attempts + 1"),
+		"last_error":  lastError,
+		"update_time": time.Now(),
+	}).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id":         id,
+			"last_error": lastError,
+		}).Errorf("UpdateOutboxFailed => r.DB.Update() got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *paymentDatabase) SaveWebhookInbox(ctx context.Context, param *models.WebhookInbox) error {
+	err := r.DB.Table("webhook_inbox").WithContext(ctx).Create(param).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"param": param,
+		}).Errorf("SaveWebhookInbox => r.DB.Create() got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *paymentDatabase) GetWebhookInboxByID(ctx context.Context, id int64) (*models.WebhookInbox, error) {
+	var inbox models.WebhookInbox
+	err := r.DB.Table("webhook_inbox").WithContext(ctx).Where("id = ?", id).First(&inbox).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id": id,
+		}).Errorf("GetWebhookInboxByID => r.DB.First() got error: %v", err)
+
+		return nil, err
+	}
+
+	return &inbox, nil
+}
+
+func (r *paymentDatabase) GetWebhookInboxes(ctx context.Context, filter models.WebhookInboxFilter) ([]models.WebhookInbox, error) {
+	var inboxes []models.WebhookInbox
+	query := r.DB.Table("webhook_inbox").WithContext(ctx)
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if !filter.StartTime.IsZero() {
+		query = query.Where("received_time >= ?", filter.StartTime)
+	}
+
+	if !filter.EndTime.IsZero() {
+		query = query.Where("received_time < ?", filter.EndTime)
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	err := query.Order("received_time ASC").Find(&inboxes).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("GetWebhookInboxes => r.DB.Find() got error: %v", err)
+
+		return nil, err
+	}
+
+	return inboxes, nil
+}
+
+// inbox which is never picked up, failed but still can be retried, or stuck in processing
+func (r *paymentDatabase) GetRetryableWebhookInboxes(ctx context.Context, receivedBefore time.Time, staleBefore time.Time, maxAttempts int, limit int) ([]models.WebhookInbox, error) {
+	var inboxes []models.WebhookInbox
+	err := r.DB.Table("webhook_inbox").WithContext(ctx).
+		Where("(status = ? AND received_time < ?) OR (status = ? AND attempts < ?) OR (status = ? AND update_time < ?)",
+			constant.WebhookInboxStatusReceived, receivedBefore,
+			constant.WebhookInboxStatusFailed, maxAttempts,
+			constant.WebhookInboxStatusProcessing, staleBefore).
+		Order("received_time ASC").Limit(limit).Find(&inboxes).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"error": err,
+		}).Errorf("GetRetryableWebhookInboxes => r.DB.Find() got error: %v", err)
+
+		return nil, err
+	}
+
+	return inboxes, nil
+}
+
+// ClaimWebhookInbox mark inbox as processing, return false when it is already processed or claimed by others.
+func (r *paymentDatabase) ClaimWebhookInbox(ctx context.Context, id int64, staleBefore time.Time) (bool, error) {
+	result := r.DB.Table("webhook_inbox").WithContext(ctx).
+		Where("id = ? AND (status IN ? OR (status = ? AND update_time < ?))", id,
+			[]string{constant.WebhookInboxStatusReceived, constant.WebhookInboxStatusFailed},
+			constant.WebhookInboxStatusProcessing, staleBefore).
+		Updates(map[string]interface{}{
+			"status":      constant.WebhookInboxStatusProcessing,
+			"update_time": time.Now(),
+		})
+	if result.Error != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id": id,
+		}).Errorf("ClaimWebhookInbox => r.DB.Update() got error: %v", result.Error)
+
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *paymentDatabase) UpdateWebhookInboxResult(ctx context.Context, id int64, status string, result string) error {
+	now := time.Now()
+	err := r.DB.Table("webhook_inbox").WithContext(ctx).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":         status,
+		"result":         result,
+		"attempts":       gorm.Expr("attempts + 1"),
+		"processed_time": now,
+		"update_time":    now,
+	}).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id":     id,
+			"status": status,
+			"result": result,
+		}).Errorf("UpdateWebhookInboxResult => r.DB.Update() got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *paymentDatabase) GetPaymentAnomalies(ctx context.Context, filter models.PaymentAnomalyFilter) ([]models.PaymentAnomaly, error) {
+	var anomalies []models.PaymentAnomaly
+	query := r.DB.Table("payment_anomalies").WithContext(ctx)
+
+	if filter.AnomalyType != 0 {
+		query = query.Where("anomaly_type = ?", filter.AnomalyType)
+	}
+
+	if filter.Status != 0 {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if !filter.StartTime.IsZero() {
+		query = query.Where("create_time >= ?", filter.StartTime)
+	}
+
+	if !filter.EndTime.IsZero() {
+		query = query.Where("create_time < ?", filter.EndTime)
+	}
+
+	err := query.Order("create_time DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&anomalies).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("GetPaymentAnomalies => r.DB.Find() got error: %v", err)
+
+		return nil, err
+	}
+
+	return anomalies, nil
+}
+
+func (r *paymentDatabase) GetPaymentAnomalyByID(ctx context.Context, anomalyID int64) (*models.PaymentAnomaly, error) {
+	var anomaly models.PaymentAnomaly
+	err := r.DB.Table("payment_anomalies").WithContext(ctx).Where("id = ?", anomalyID).First(&anomaly).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id": anomalyID,
+		}).Errorf("GetPaymentAnomalyByID => r.DB.First() got error: %v", err)
+
+		return nil, err
+	}
+
+	return &anomaly, nil
+}
+
+// only anomaly which still need to check can be resolved, return false when it is already resolved.
+func (r *paymentDatabase) ResolvePaymentAnomaly(ctx context.Context, anomalyID int64, status int, resolvedBy int64, notes string) (bool, error) {
+	now := time.Now()
+	result := r.DB.Table("payment_anomalies").WithContext(ctx).
+		Where("id = ? AND status = ?", anomalyID, constant.PaymentAnomalyStatusNeedToCheck).
+		Updates(map[string]interface{}{
+			"status":           status,
+			"resolved_by":      resolvedBy,
+			"resolution_notes": notes,
+			"resolved_time":    now,
+			"update_time":      now,
+		})
+	if result.Error != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id":          anomalyID,
+			"status":      status,
+			"resolved_by": resolvedBy,
+		}).Errorf("ResolvePaymentAnomaly => r.DB.Update() got error: %v", result.Error)
+
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *paymentDatabase) SaveRefund(ctx context.Context, param *models.Refund) error {
+	err := r.DB.Table("refunds").WithContext(ctx).Create(param).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"param": param,
+		}).Errorf("SaveRefund => r.DB.Create() got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// sum of refund amount which is still in progress or already succeeded, in minor unit of the payment currency
+func (r *paymentDatabase) GetRefundedAmountByOrderID(ctx context.Context, orderID int64) (int64, error) {
+	var amount int64
+	err := r.DB.Table("refunds").WithContext(ctx).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("order_id = ? AND status IN ?", orderID, []string{constant.RefundStatusPending, constant.RefundStatusSucceeded}).
+		Scan(&amount).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+		}).Errorf("GetRefundedAmountByOrderID => r.DB.Scan() got error: %v", err)
+
+		return 0, err
+	}
+
+	return amount, nil
+}
+
+// GetSucceededRefundAmountByOrderID sum refunds confirmed by the gateway, pending refunds are not counted.
+func (r *paymentDatabase) GetSucceededRefundAmountByOrderID(ctx context.Context, orderID int64) (int64, error) {
+	var amount int64
+	err := r.DB.Table("refunds").WithContext(ctx).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("order_id = ? AND status = ?", orderID, constant.RefundStatusSucceeded).
+		Scan(&amount).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+		}).Errorf("GetSucceededRefundAmountByOrderID => r.DB.Scan() got error: %v", err)
+
+		return 0, err
+	}
+
+	return amount, nil
+}
+
+// GetPendingRefunds return refunds still processed by the gateway, and refunds whose create result is unknown
+// after RefundRetryDelay.
+func (r *paymentDatabase) GetPendingRefunds(ctx context.Context) ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := r.DB.Table("refunds").WithContext(ctx).
+		Where("status = ? AND (gateway_refund_id <> '' OR create_time < ?)", constant.RefundStatusPending, time.Now().Add(-constant.RefundRetryDelay)).
+		Order("create_time ASC").Limit(20).Find(&refunds).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"error": err,
+		}).Errorf("GetPendingRefunds => r.DB.Find() got error: %v", err)
+
+		return nil, err
+	}
+
+	return refunds, nil
+}
+
+func (r *paymentDatabase) UpdateRefund(ctx context.Context, refundID int64, gatewayRefundID string, status string, notes string) error {
+	err := r.DB.Table("refunds").WithContext(ctx).Where("id = ?", refundID).Updates(map[string]interface{}{
+		"gateway_refund_id": gatewayRefundID,
+		"status":            status,
+		"notes":             notes,
+		"update_time":       time.Now(),
+	}).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id":                refundID,
+			"gateway_refund_id": gatewayRefundID,
+			"status":            status,
+			"notes":             notes,
+		}).Errorf("UpdateRefund => r.DB.Update() got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// GetPaymentsByCreateTime return payments created in [startTime, endTime).
+func (r *paymentDatabase) GetPaymentsByCreateTime(ctx context.Context, startTime time.Time, endTime time.Time) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.DB.Table("payments").WithContext(ctx).
+		Where("create_time >= ? AND create_time < ?", startTime, endTime).
+		Order("id ASC").Find(&payments).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"start_time": startTime,
+			"end_time":   endTime,
+		}).Errorf("GetPaymentsByCreateTime => r.DB.Find() got error: %v", err)
+
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (r *paymentDatabase) GetPaymentsByExternalIDs(ctx context.Context, externalIDs []string) ([]models.Payment, error) {
+	var payments []models.Payment
+	if len(externalIDs) == 0 {
+		return payments, nil
+	}
+
+	err := r.DB.Table("payments").WithContext(ctx).Where("external_id IN ?", externalIDs).Find(&payments).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"external_ids": externalIDs,
+		}).Errorf("GetPaymentsByExternalIDs => r.DB.Find() got error: %v", err)
+
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (r *paymentDatabase) SaveReconciliationRun(ctx context.Context, param *models.ReconciliationRun) error {
+	err := r.DB.Table("reconciliation_runs").WithContext(ctx).Create(param).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"param": param,
+		}).Errorf("SaveReconciliationRun => r.DB.Create() got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *paymentDatabase) SaveReconciliationDiscrepancies(ctx context.Context, discrepancies []models.ReconciliationDiscrepancy) error {
+	if len(discrepancies) == 0 {
+		return nil
+	}
+
+	err := r.DB.Table("reconciliation_discrepancies").WithContext(ctx).CreateInBatches(discrepancies, 100).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"count": len(discrepancies),
+		}).Errorf("SaveReconciliationDiscrepancies => r.DB.Create() got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *paymentDatabase) GetReconciliationRuns(ctx context.Context, filter models.ReconciliationRunFilter) ([]models.ReconciliationRun, error) {
+	var runs []models.ReconciliationRun
+	query := r.DB.Table("reconciliation_runs").WithContext(ctx)
+
+	if !filter.StartTime.IsZero() {
+		query = query.Where("period_start >= ?", filter.StartTime)
+	}
+
+	if !filter.EndTime.IsZero() {
+		query = query.Where("period_start < ?", filter.EndTime)
+	}
+
+	err := query.Order("id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&runs).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("GetReconciliationRuns => r.DB.Find() got error: %v", err)
+
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// GetReconciliationRunByPeriod return the latest run of exactly the given period.
+func (r *paymentDatabase) GetReconciliationRunByPeriod(ctx context.Context, periodStart time.Time, periodEnd time.Time) (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	err := r.DB.Table("reconciliation_runs").WithContext(ctx).
+		Where("period_start = ? AND period_end = ?", periodStart, periodEnd).
+		Order("id DESC").First(&run).Error
+	if err != nil {
+		// period which is not reconciled yet is expected
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		log.Logger.WithFields(logrus.Fields{
+			"period_start": periodStart,
+			"period_end":   periodEnd,
+		}).Errorf("GetReconciliationRunByPeriod => r.DB.First() got error: %v", err)
+
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+func (r *paymentDatabase) GetReconciliationDiscrepancies(ctx context.Context, runID int64) ([]models.ReconciliationDiscrepancy, error) {
+	var discrepancies []models.ReconciliationDiscrepancy
+	err := r.DB.Table("reconciliation_discrepancies").WithContext(ctx).Where("run_id = ?", runID).Order("id ASC").Find(&discrepancies).Error
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"run_id": runID,
+		}).Errorf("GetReconciliationDiscrepancies => r.DB.Find() got error: %v", err)
+
+		return nil, err
+	}
+
+	return discrepancies, nil
+}
+
+// GetSettlementSummaries aggregate payments paid, refunds succeeded and payments expired in [startTime, endTime)
+// by day, payment method and currency. Refunded payment is still counted as paid on the day it was paid.
+func (r *paymentDatabase) GetSettlementSummaries(ctx context.Context, startTime time.Time, endTime time.Time) ([]models.SettlementSummary, error) {
+	var summaries []models.SettlementSummary
+
+	queries := []struct {
+		category string
+		query    *gorm.DB
+	}{
+		{
+			category: constant.SettlementCategoryPaid,
+			query: r.DB.Table("payments").WithContext(ctx).
+				Select("TO_CHAR(paid_time, 'YYYY-MM-DD') AS day, COALESCE(payment_method, '') AS payment_method, currency, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+				Where("status IN ? AND paid_time >= ? AND paid_time < ?", []models.PaymentStatus{models.PaymentStatusPaid, models.PaymentStatusRefunded}, startTime, endTime),
+		},
+		{
+			// succeeded refund is not updated anymore, update time is the time it succeeded
+			category: constant.SettlementCategoryRefunded,
+			query: r.DB.Table("refunds").WithContext(ctx).
+				Select("TO_CHAR(refunds.update_time, 'YYYY-MM-DD') AS day, COALESCE(payments.payment_method, '') AS payment_method, refunds.currency, COUNT(*) AS count, COALESCE(SUM(refunds.amount), 0) AS amount").
+				Joins("JOIN payments ON payments.order_id = refunds.order_id").
+				Where("refunds.status = ? AND refunds.update_time >= ? AND refunds.update_time < ?", constant.RefundStatusSucceeded, startTime, endTime),
+		},
+		{
+			// expired is a final status, update time is the time it expired
+			category: constant.SettlementCategoryExpired,
+			query: r.DB.Table("payments").WithContext(ctx).
+				Select("TO_CHAR(update_time, 'YYYY-MM-DD') AS day, COALESCE(payment_method, '') AS payment_method, currency, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+				Where("status = ? AND update_time >= ? AND update_time < ?", models.PaymentStatusExpired, startTime, endTime),
+		},
+	}
+
+	for _, q := range queries {
+		var rows []models.SettlementSummary
+		err := q.query.Group("1, 2, 3").Scan(&rows).Error
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"category":   q.category,
+				"start_time": startTime,
+				"end_time":   endTime,
+			}).Errorf("GetSettlementSummaries => r.DB.Scan() got error: %v", err)
+
+			return nil, err
+		}
+
+		for i := range rows {
+			rows[i].Category = q.category
+		}
+
+		summaries = append(summaries, rows...)
+	}
+
+	return summaries, nil
+}
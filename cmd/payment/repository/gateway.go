@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"payment/infrastructure/constant"
+	"payment/models"
+	"strings"
+)
+
+var (
+	ErrChargeNotFound          = errors.New("charge not found")
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	ErrUnknownGateway          = errors.New("unknown payment gateway")
+)
+
+// PaymentGateway is a payment provider, every provider specific status is mapped to constant.GatewayStatus*
+// so service does not depend on the provider.
+type PaymentGateway interface {
+	Name() string
+	CreateCharge(ctx context.Context, param models.ChargeRequest) (models.Charge, error)
+	// GetCharge return ErrChargeNotFound when the provider does not know the external id
+	GetCharge(ctx context.Context, externalID string) (models.Charge, error)
+	ExpireCharge(ctx context.Context, externalID string) error
+	// CreateRefund is idempotent by reference id, refund sent again with the same reference id return the
+	// refund which is already created
+	CreateRefund(ctx context.Context, param models.GatewayRefundRequest) (models.GatewayRefund, error)
+	GetRefund(ctx context.Context, externalID string, refundID string) (models.GatewayRefund, error)
+	// VerifyWebhook return ErrInvalidWebhookSignature when the webhook is not sent by the provider
+	VerifyWebhook(headers http.Header, rawBody []byte) error
+	ParseWebhook(rawBody []byte) (models.GatewayWebhook, error)
+}
+
+// retryableError is implemented by provider errors which know whether the same request may succeed later.
+type retryableError interface {
+	Retryable() bool
+}
+
+// IsRejected is true when the gateway definitively reject the request, e.g. validation error. Timeout, 5xx and
+// open breaker are not, the request may have been handled by the gateway so its result is unknown.
+func IsRejected(err error) bool {
+	if errors.Is(err, models.ErrUnsupportedCurrency) || errors.Is(err, ErrChargeNotFound) {
+		return true
+	}
+
+	var retryable retryableError
+	return errors.As(err, &retryable) && !retryable.Retryable()
+}
+
+type PaymentGatewayResolver interface {
+	// ForPaymentMethod return the gateway which handle the payment method, fallback to the default gateway
+	ForPaymentMethod(paymentMethod string) (PaymentGateway, error)
+	// Get return the gateway by provider name, empty provider is the default gateway
+	Get(provider string) (PaymentGateway, error)
+}
+
+type paymentGateways struct {
+	defaultProvider string
+	methodProviders map[string]string
+	gateways        map[string]PaymentGateway
+}
+
+// NewPaymentGateways route payment method to provider by methodProviders, method and provider are case insensitive.
+func NewPaymentGateways(defaultProvider string, methodProviders map[string]string, gateways ...PaymentGateway) PaymentGatewayResolver {
+	if defaultProvider == "" {
+		defaultProvider = constant.DefaultGatewayProvider
+	}
+
+	r := &paymentGateways{
+		defaultProvider: strings.ToLower(defaultProvider),
+		methodProviders: make(map[string]string, len(methodProviders)),
+		gateways:        make(map[string]PaymentGateway, len(gateways)),
+	}
+
+	for method, provider := range methodProviders {
+		r.methodProviders[strings.ToLower(method)] = strings.ToLower(provider)
+	}
+
+	for _, gateway := range gateways {
+		r.gateways[gateway.Name()] = gateway
+	}
+
+	return r
+}
+
+func (r *paymentGateways) ForPaymentMethod(paymentMethod string) (PaymentGateway, error) {
+	provider, ok := r.methodProviders[strings.ToLower(paymentMethod)]
+	if !ok {
+		provider = r.defaultProvider
+	}
+
+	return r.Get(provider)
+}
+
+func (r *paymentGateways) Get(provider string) (PaymentGateway, error) {
+	// payment created before provider is stored does not have provider
+	if provider == "" {
+		provider = r.defaultProvider
+	}
+
+	gateway, ok := r.gateways[strings.ToLower(provider)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGateway, provider)
+	}
+
+	return gateway, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/job"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type jobStore struct {
+	client *redis.Client
+}
+
+// NewJobStore store job state in redis, so it is shared by every replica.
+func NewJobStore(client *redis.Client) job.Store {
+	return &jobStore{
+		client: client,
+	}
+}
+
+func (s *jobStore) SaveRunStatus(ctx context.Context, name string, status job.RunStatus) error {
+	value, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(ctx, fmt.Sprintf(constant.RedisKeyJobStatus, name), value, 0).Err()
+}
+
+func (s *jobStore) GetRunStatus(ctx context.Context, name string) (job.RunStatus, error) {
+	var status job.RunStatus
+
+	value, err := s.client.Get(ctx, fmt.Sprintf(constant.RedisKeyJobStatus, name)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return status, nil
+	}
+
+	if err != nil {
+		return status, err
+	}
+
+	err = json.Unmarshal(value, &status)
+	return status, err
+}
+
+func (s *jobStore) SetPaused(ctx context.Context, name string, paused bool) error {
+	key := fmt.Sprintf(constant.RedisKeyJobPaused, name)
+	if !paused {
+		return s.client.Del(ctx, key).Err()
+	}
+
+	return s.client.Set(ctx, key, 1, 0).Err()
+}
+
+func (s *jobStore) IsPaused(ctx context.Context, name string) (bool, error) {
+	exists, err := s.client.Exists(ctx, fmt.Sprintf(constant.RedisKeyJobPaused, name)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return exists == 1, nil
+}
+
+func (s *jobStore) RequestTrigger(ctx context.Context, name string) error {
+	return s.client.Set(ctx, fmt.Sprintf(constant.RedisKeyJobTrigger, name), 1, constant.JobTriggerTTL).Err()
+}
+
+func (s *jobStore) TakeTrigger(ctx context.Context, name string) (bool, error) {
+	deleted, err := s.client.Del(ctx, fmt.Sprintf(constant.RedisKeyJobTrigger, name)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted == 1, nil
+}
@@ -2,36 +2,44 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"payment/config"
+	"payment/infrastructure/constant"
+	"payment/models"
 
 	"github.com/segmentio/kafka-go"
 )
 
 type PaymentEventPublisher interface {
-	PublishPaymentSuccess(ctx context.Context, orderID int64) error
+	PublishOutbox(ctx context.Context, outbox models.Outbox) error
 }
 
 type kafkaPublisher struct {
 	writer *kafka.Writer
+	config config.KafkaConfig
 }
 
-func NewKafkaPublisher(writer *kafka.Writer) PaymentEventPublisher {
+// writer must be created without topic, topic is set per message.
+func NewKafkaPublisher(writer *kafka.Writer, kafkaConfig config.KafkaConfig) PaymentEventPublisher {
 	return &kafkaPublisher{
 		writer: writer,
+		config: kafkaConfig,
 	}
 }
 
-// publish payment success
-func (k *kafkaPublisher) PublishPaymentSuccess(ctx context.Context, orderID int64) error {
-	payload := map[string]interface{}{
-		"order_id": orderID,
-		"status":   "paid",
-	}
-
-	data, _ := json.Marshal(payload)
+// PublishOutbox publish event envelope stored in outbox as is, used by outbox relay.
+func (k *kafkaPublisher) PublishOutbox(ctx context.Context, outbox models.Outbox) error {
 	return k.writer.WriteMessages(ctx, kafka.Message{
-		Key:   []byte(fmt.Sprintf("order-%d", orderID)),
-		Value: data,
+		Topic: k.config.Topic(outbox.EventType),
+		Key:   []byte(outbox.MessageKey),
+		Value: []byte(outbox.Payload),
+		Headers: []kafka.Header{
+			{Key: constant.KafkaHeaderEventID, Value: []byte(outbox.EventID)},
+		},
 	})
 }
+
+// PaymentEventKey keep every event of the same order in the same partition.
+func PaymentEventKey(orderID int64) string {
+	return fmt.Sprintf("order-%d", orderID)
+}
@@ -0,0 +1,440 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"payment/config"
+	"payment/infrastructure/constant"
+	"payment/models"
+	"strconv"
+	"time"
+)
+
+const (
+	midtransSandboxSnapURL    = "https://app.sandbox.midtrans.com"
+	midtransSandboxAPIURL     = "https://api.sandbox.midtrans.com"
+	midtransProductionSnapURL = "https://app.midtrans.com"
+	midtransProductionAPIURL  = "https://api.midtrans.com"
+
+	midtransTimeLayout = "2006-01-02 15:04:05"
+
+	// same as default snap expiry, it is set explicitly so expired time of the payment is known
+	midtransChargeExpiryHours = 24
+)
+
+// midtrans time has no zone, it is always in Asia/Jakarta
+var midtransLocation = time.FixedZone("WIB", 7*60*60)
+
+// MidtransError is returned when midtrans respond with non 2xx http status or status_code.
+type MidtransError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *MidtransError) Error() string {
+	return fmt.Sprintf("midtrans got status_code %d: %s", e.StatusCode, e.Message)
+}
+
+// Retryable is true when the same request may succeed later.
+func (e *MidtransError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+type midtransGateway struct {
+	ServerKey  string
+	snapURL    string
+	apiURL     string
+	httpClient *http.Client
+}
+
+// NewMidtransGateway use snap to create the charge and core api for the rest, order id at midtrans is our external id.
+func NewMidtransGateway(midtransConfig config.MidtransConfig) PaymentGateway {
+	timeout := midtransConfig.Timeout
+	if timeout <= 0 {
+		timeout = constant.DefaultMidtransTimeout
+	}
+
+	gateway := &midtransGateway{
+		ServerKey:  midtransConfig.ServerKey,
+		snapURL:    midtransSandboxSnapURL,
+		apiURL:     midtransSandboxAPIURL,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+
+	if midtransConfig.Production {
+		gateway.snapURL = midtransProductionSnapURL
+		gateway.apiURL = midtransProductionAPIURL
+	}
+
+	return gateway
+}
+
+func (g *midtransGateway) Name() string {
+	return constant.GatewayProviderMidtrans
+}
+
+func (g *midtransGateway) CreateCharge(ctx context.Context, param models.ChargeRequest) (models.Charge, error) {
+	grossAmount, err := midtransAmount(param.Amount)
+	if err != nil {
+		return models.Charge{}, fmt.Errorf("midtrans.CreateCharge() got error %w", err)
+	}
+
+	payload := models.MidtransSnapRequest{
+		TransactionDetails: models.MidtransTransactionDetails{
+			OrderID:     param.ExternalID,
+			GrossAmount: grossAmount,
+		},
+		Expiry: &models.MidtransExpiry{
+			Unit:     "hour",
+			Duration: midtransChargeExpiryHours,
+		},
+	}
+
+	if param.PayerName != "" || param.PayerEmail != "" || param.PayerPhone != "" {
+		payload.CustomerDetails = &models.MidtransCustomerDetails{
+			FirstName: param.PayerName,
+			Email:     param.PayerEmail,
+			Phone:     param.PayerPhone,
+		}
+	}
+
+	payload.ItemDetails = midtransItemDetails(param.Items, payload.TransactionDetails.GrossAmount)
+
+	if param.PaymentMethod != "" {
+		payload.EnabledPayments = []string{param.PaymentMethod}
+	}
+
+	expiryTime := time.Now().Add(midtransChargeExpiryHours * time.Hour)
+
+	var result models.MidtransSnapResponse
+	err = g.do(ctx, http.MethodPost, g.snapURL+"/snap/v1/transactions", payload, &result)
+	if err != nil {
+		return models.Charge{}, fmt.Errorf("midtrans.CreateCharge() got error %w", err)
+	}
+
+	// snap transaction is created at midtrans when customer choose the payment method, so it is pending until then
+	return models.Charge{
+		ID:            result.Token,
+		ExternalID:    param.ExternalID,
+		Amount:        param.Amount,
+		Status:        constant.GatewayStatusPending,
+		PaymentMethod: param.PaymentMethod,
+		PaymentURL:    result.RedirectURL,
+		ExpiryTime:    expiryTime,
+	}, nil
+}
+
+func (g *midtransGateway) GetCharge(ctx context.Context, externalID string) (models.Charge, error) {
+	status, err := g.getStatus(ctx, externalID)
+	if err != nil {
+		return models.Charge{}, err
+	}
+
+	amount, _ := models.ParseMoney(status.GrossAmount, models.CurrencyIDR)
+
+	return models.Charge{
+		ID:            status.TransactionID,
+		ExternalID:    status.OrderID,
+		Amount:        amount,
+		Status:        midtransGatewayStatus(status.TransactionStatus, status.FraudStatus),
+		PaymentMethod: status.PaymentType,
+		ExpiryTime:    parseMidtransTime(status.ExpiryTime),
+		PaidTime:      parseMidtransTime(status.SettlementTime),
+	}, nil
+}
+
+func (g *midtransGateway) ExpireCharge(ctx context.Context, externalID string) error {
+	var result models.MidtransTransactionStatus
+
+	uri := fmt.Sprintf("%s/v2/%s/expire", g.apiURL, url.PathEscape(externalID))
+	err := g.do(ctx, http.MethodPost, uri, nil, &result)
+	if err != nil {
+		return fmt.Errorf("midtrans.ExpireCharge() got error %w", err)
+	}
+
+	return midtransStatusError(result.StatusCode, result.StatusMessage)
+}
+
+func (g *midtransGateway) CreateRefund(ctx context.Context, param models.GatewayRefundRequest) (models.GatewayRefund, error) {
+	amount, err := midtransAmount(param.Amount)
+	if err != nil {
+		return models.GatewayRefund{}, fmt.Errorf("midtrans.CreateRefund() got error %w", err)
+	}
+
+	var result models.MidtransRefundResponse
+
+	uri := fmt.Sprintf("%s/v2/%s/refund", g.apiURL, url.PathEscape(param.ExternalID))
+	err = g.do(ctx, http.MethodPost, uri, models.MidtransRefundRequest{
+		// refund key is unique per refund, midtrans reject the same key twice
+		RefundKey: param.ReferenceID,
+		Amount:    amount,
+		Reason:    param.Reason,
+	}, &result)
+	if err == nil {
+		err = midtransStatusError(result.StatusCode, result.StatusMessage)
+	}
+
+	if err != nil {
+		// refund sent again after its result was unknown is rejected, return the refund created before
+		if IsRejected(err) {
+			if refund, ok := g.findRefundByKey(ctx, param.ExternalID, param.ReferenceID); ok {
+				return refund, nil
+			}
+		}
+
+		return models.GatewayRefund{}, fmt.Errorf("midtrans.CreateRefund() got error %w", err)
+	}
+
+	// refund api only return success when the refund is done
+	return models.GatewayRefund{
+		ID:          strconv.FormatInt(result.RefundChargebackID, 10),
+		ReferenceID: result.RefundKey,
+		Amount:      param.Amount,
+		Status:      constant.RefundStatusSucceeded,
+	}, nil
+}
+
+func (g *midtransGateway) GetRefund(ctx context.Context, externalID string, refundID string) (models.GatewayRefund, error) {
+	status, err := g.getStatus(ctx, externalID)
+	if err != nil {
+		return models.GatewayRefund{}, err
+	}
+
+	for _, refund := range status.Refunds {
+		if strconv.FormatInt(refund.RefundChargebackID, 10) != refundID {
+			continue
+		}
+
+		amount, _ := models.ParseMoney(refund.RefundAmount, models.CurrencyIDR)
+
+		return models.GatewayRefund{
+			ID:          refundID,
+			ReferenceID: refund.RefundKey,
+			Amount:      amount,
+			Status:      constant.RefundStatusSucceeded,
+		}, nil
+	}
+
+	return models.GatewayRefund{ID: refundID, Status: constant.RefundStatusPending}, nil
+}
+
+func (g *midtransGateway) findRefundByKey(ctx context.Context, externalID string, refundKey string) (models.GatewayRefund, bool) {
+	status, err := g.getStatus(ctx, externalID)
+	if err != nil {
+		return models.GatewayRefund{}, false
+	}
+
+	for _, refund := range status.Refunds {
+		if refund.RefundKey != refundKey {
+			continue
+		}
+
+		amount, _ := models.ParseMoney(refund.RefundAmount, models.CurrencyIDR)
+
+		return models.GatewayRefund{
+			ID:          strconv.FormatInt(refund.RefundChargebackID, 10),
+			ReferenceID: refund.RefundKey,
+			Amount:      amount,
+			Status:      constant.RefundStatusSucceeded,
+		}, true
+	}
+
+	return models.GatewayRefund{}, false
+}
+
+// VerifyWebhook check signature_key of the notification, it is sha512 of order_id + status_code + gross_amount + server key.
+func (g *midtransGateway) VerifyWebhook(headers http.Header, rawBody []byte) error {
+	var notification models.MidtransTransactionStatus
+	if err := json.Unmarshal(rawBody, &notification); err != nil {
+		return ErrInvalidWebhookSignature
+	}
+
+	signature := sha512.Sum512([]byte(notification.OrderID + notification.StatusCode + notification.GrossAmount + g.ServerKey))
+	expected := hex.EncodeToString(signature[:])
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(notification.SignatureKey)) != 1 {
+		return ErrInvalidWebhookSignature
+	}
+
+	return nil
+}
+
+func (g *midtransGateway) ParseWebhook(rawBody []byte) (models.GatewayWebhook, error) {
+	var notification models.MidtransTransactionStatus
+	if err := json.Unmarshal(rawBody, &notification); err != nil {
+		return models.GatewayWebhook{}, err
+	}
+
+	amount, err := models.ParseMoney(notification.GrossAmount, models.CurrencyIDR)
+	if err != nil {
+		return models.GatewayWebhook{}, fmt.Errorf("invalid gross_amount %q: %w", notification.GrossAmount, err)
+	}
+
+	status := midtransGatewayStatus(notification.TransactionStatus, notification.FraudStatus)
+
+	var paidAt time.Time
+	if status == constant.GatewayStatusPaid {
+		paidAt = parseMidtransTime(notification.SettlementTime)
+		if paidAt.IsZero() {
+			paidAt = parseMidtransTime(notification.TransactionTime)
+		}
+	}
+
+	return models.GatewayWebhook{
+		Provider:      constant.GatewayProviderMidtrans,
+		CallbackID:    notification.TransactionID,
+		ExternalID:    notification.OrderID,
+		Status:        status,
+		Amount:        amount,
+		PaymentMethod: notification.PaymentType,
+		PaidAt:        paidAt,
+	}, nil
+}
+
+func (g *midtransGateway) getStatus(ctx context.Context, externalID string) (models.MidtransTransactionStatus, error) {
+	var result models.MidtransTransactionStatus
+
+	uri := fmt.Sprintf("%s/v2/%s/status", g.apiURL, url.PathEscape(externalID))
+	err := g.do(ctx, http.MethodGet, uri, nil, &result)
+	if err != nil {
+		return models.MidtransTransactionStatus{}, fmt.Errorf("midtrans.GetStatus() got error %w", err)
+	}
+
+	// snap transaction does not exist until customer choose the payment method
+	if result.StatusCode == "404" {
+		return models.MidtransTransactionStatus{}, fmt.Errorf("%w: order_id %s", ErrChargeNotFound, externalID)
+	}
+
+	if err := midtransStatusError(result.StatusCode, result.StatusMessage); err != nil {
+		return models.MidtransTransactionStatus{}, err
+	}
+
+	return result, nil
+}
+
+// do send the request with server key as basic auth. Core api return http 200 with the error in status_code,
+// so caller must check status_code of the result too.
+func (g *midtransGateway) do(ctx context.Context, method string, uri string, payload any, result any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewBuffer(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, uri, body)
+	if err != nil {
+		return err
+	}
+
+	req.SetBasicAuth(g.ServerKey, "")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 && res.StatusCode != http.StatusNotFound {
+		resBody, _ := io.ReadAll(res.Body)
+		return &MidtransError{StatusCode: res.StatusCode, Message: string(resBody)}
+	}
+
+	return json.NewDecoder(res.Body).Decode(result)
+}
+
+func midtransStatusError(statusCode string, statusMessage string) error {
+	code, err := strconv.Atoi(statusCode)
+	if err != nil {
+		return fmt.Errorf("midtrans got status_code %s: %s", statusCode, statusMessage)
+	}
+
+	if code >= 300 {
+		return &MidtransError{StatusCode: code, Message: statusMessage}
+	}
+
+	return nil
+}
+
+// midtrans reject item details whose total is not the gross amount, e.g. order with shipping fee, items are
+// omitted in that case
+func midtransItemDetails(items []models.OrderItem, grossAmount int64) []models.MidtransItemDetails {
+	var total int64
+	details := make([]models.MidtransItemDetails, 0, len(items))
+	for _, item := range items {
+		price, err := models.ParseMoney(item.Price.String(), models.CurrencyIDR)
+		if err != nil {
+			return nil
+		}
+
+		detail := models.MidtransItemDetails{
+			Name:     item.Name,
+			Price:    price.Amount,
+			Quantity: item.Quantity,
+			Category: item.Category,
+		}
+
+		total += detail.Price * int64(detail.Quantity)
+		details = append(details, detail)
+	}
+
+	if len(details) == 0 || total != grossAmount {
+		return nil
+	}
+
+	return details
+}
+
+// midtrans only accept IDR, whose minor unit is rupiah
+func midtransAmount(amount models.Money) (int64, error) {
+	if amount.Currency != models.CurrencyIDR {
+		return 0, fmt.Errorf("%w: midtrans does not accept %s", models.ErrUnsupportedCurrency, amount.Currency)
+	}
+
+	return amount.Amount, nil
+}
+
+func parseMidtransTime(value string) time.Time {
+	t, err := time.ParseInLocation(midtransTimeLayout, value, midtransLocation)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+// captured card payment is only paid when it pass fraud detection, unknown status is kept as is
+func midtransGatewayStatus(transactionStatus string, fraudStatus string) string {
+	switch transactionStatus {
+	case "capture":
+		if fraudStatus == "challenge" {
+			return constant.GatewayStatusPending
+		}
+
+		return constant.GatewayStatusPaid
+	case "settlement":
+		return constant.GatewayStatusPaid
+	case "pending":
+		return constant.GatewayStatusPending
+	case "expire":
+		return constant.GatewayStatusExpired
+	case "deny", "cancel", "failure":
+		return constant.GatewayStatusFailed
+	case "refund", "partial_refund":
+		return constant.GatewayStatusRefunded
+	default:
+		return transactionStatus
+	}
+}
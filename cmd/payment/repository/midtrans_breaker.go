@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"payment/infrastructure/breaker"
+	"payment/models"
+)
+
+// breakerMidtransGateway wrap every midtrans api call with a circuit breaker, webhook is handled as is.
+type breakerMidtransGateway struct {
+	PaymentGateway
+	breaker *breaker.Breaker
+}
+
+// NewBreakerMidtransGateway wrap the gateway with a circuit breaker, breaker.ErrOpen is returned while it is open.
+func NewBreakerMidtransGateway(gateway PaymentGateway, cb *breaker.Breaker) PaymentGateway {
+	return &breakerMidtransGateway{
+		PaymentGateway: gateway,
+		breaker:        cb,
+	}
+}
+
+// IsMidtransFailure is true when the error means midtrans is unhealthy, rejected request e.g. 4xx is not.
+func IsMidtransFailure(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrChargeNotFound) || errors.Is(err, models.ErrUnsupportedCurrency) {
+		return false
+	}
+
+	var midtransErr *MidtransError
+	if errors.As(err, &midtransErr) {
+		return midtransErr.Retryable()
+	}
+
+	return true
+}
+
+func (g *breakerMidtransGateway) CreateCharge(ctx context.Context, param models.ChargeRequest) (result models.Charge, err error) {
+	err = g.breaker.Execute(func() (err error) {
+		result, err = g.PaymentGateway.CreateCharge(ctx, param)
+		return err
+	})
+
+	return result, err
+}
+
+func (g *breakerMidtransGateway) GetCharge(ctx context.Context, externalID string) (result models.Charge, err error) {
+	err = g.breaker.Execute(func() (err error) {
+		result, err = g.PaymentGateway.GetCharge(ctx, externalID)
+		return err
+	})
+
+	return result, err
+}
+
+func (g *breakerMidtransGateway) ExpireCharge(ctx context.Context, externalID string) error {
+	return g.breaker.Execute(func() error {
+		return g.PaymentGateway.ExpireCharge(ctx, externalID)
+	})
+}
+
+func (g *breakerMidtransGateway) CreateRefund(ctx context.Context, param models.GatewayRefundRequest) (result models.GatewayRefund, err error) {
+	err = g.breaker.Execute(func() (err error) {
+		result, err = g.PaymentGateway.CreateRefund(ctx, param)
+		return err
+	})
+
+	return result, err
+}
+
+func (g *breakerMidtransGateway) GetRefund(ctx context.Context, externalID string, refundID string) (result models.GatewayRefund, err error) {
+	err = g.breaker.Execute(func() (err error) {
+		result, err = g.PaymentGateway.GetRefund(ctx, externalID, refundID)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// only delete the lock when it is still owned by the caller
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// only extend the lock when it is still owned by the caller
+var renewLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+type PaymentRedis interface {
+	SetIfNotExists(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	Delete(ctx context.Context, key string) error
+	AcquireLock(ctx context.Context, key string, ttl time.Duration) (string, bool, error)
+	ReleaseLock(ctx context.Context, key string, token string) error
+	RenewLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error)
+}
+
+type paymentRedis struct {
+	client *redis.Client
+}
+
+func NewPaymentRedis(client *redis.Client) PaymentRedis {
+	return &paymentRedis{
+		client: client,
+	}
+}
+
+// SetIfNotExists return false when the key is already exists.
+func (r *paymentRedis) SetIfNotExists(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, time.Now().Unix(), ttl).Result()
+}
+
+func (r *paymentRedis) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, key).Err()
+}
+
+// AcquireLock return the lock token which is needed to release the lock.
+func (r *paymentRedis) AcquireLock(ctx context.Context, key string, ttl time.Duration) (string, bool, error) {
+	token := uuid.NewString()
+	acquired, err := r.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return "", false, err
+	}
+
+	return token, acquired, nil
+}
+
+func (r *paymentRedis) ReleaseLock(ctx context.Context, key string, token string) error {
+	return releaseLockScript.Run(ctx, r.client, []string{key}, token).Err()
+}
+
+// RenewLock return false when the lock is expired or owned by other token.
+func (r *paymentRedis) RenewLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	renewed, err := renewLockScript.Run(ctx, r.client, []string{key}, token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+
+	return renewed == 1, nil
+}
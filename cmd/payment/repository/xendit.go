@@ -7,54 +7,186 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"payment/config"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
 	"payment/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
+// DefaultXenditBaseURL is used when base url is not configured, it is overridden to point at the emulator.
+const DefaultXenditBaseURL = "https://api.xendit.co"
+
+var ErrXenditInvoiceNotFound = errors.New("xendit invoice not found")
+
+// XenditError is returned when xendit respond with non 2xx status, error code is taken from the response body.
+type XenditError struct {
+	Operation  string
+	StatusCode int
+	ErrorCode  string `json:"error_code"`
+	Message    string `json:"message"`
+	// taken from Retry-After header of 429 response
+	RetryAfter time.Duration
+}
+
+func (e *XenditError) Error() string {
+	return fmt.Sprintf("xendit.%s() got error %d %s: %s", e.Operation, e.StatusCode, e.ErrorCode, e.Message)
+}
+
+// Retryable is true when the same request may succeed later.
+func (e *XenditError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
 type XenditClient interface {
 	CreateInvoice(ctx context.Context, param models.XenditInvoiceRequest) (models.XenditInvoiceResponse, error)
 	CheckInvoiceStatus(ctx context.Context, externalID string) (string, error)
+	GetInvoiceByExternalID(ctx context.Context, externalID string) (models.XenditInvoiceResponse, error)
+	ListInvoices(ctx context.Context, param models.XenditListInvoicesRequest) ([]models.XenditInvoiceResponse, error)
+	ExpireInvoice(ctx context.Context, invoiceID string) (models.XenditInvoiceResponse, error)
+	CreateRefund(ctx context.Context, param models.XenditRefundRequest) (models.XenditRefundResponse, error)
+	GetRefund(ctx context.Context, refundID string) (models.XenditRefundResponse, error)
 }
 
 type xenditClient struct {
 	APISecretKey string
+	BaseURL      string
+
+	httpClient   *http.Client
+	timeout      time.Duration
+	maxRetries   int
+	retryBackoff time.Duration
 }
 
-func NewXenditClient(apiSecretKey string) XenditClient {
-	return &xenditClient{
-		APISecretKey: apiSecretKey,
+func NewXenditClient(xenditConfig config.XenditConfig) XenditClient {
+	xc := &xenditClient{
+		APISecretKey: xenditConfig.SecretApiKey,
+		BaseURL:      strings.TrimSuffix(xenditConfig.BaseURL, "/"),
+		httpClient:   &http.Client{},
+		timeout:      xenditConfig.Timeout,
+		maxRetries:   constant.DefaultXenditMaxRetries,
+		retryBackoff: xenditConfig.RetryBackoff,
 	}
+
+	if xc.BaseURL == "" {
+		xc.BaseURL = DefaultXenditBaseURL
+	}
+
+	if xc.timeout <= 0 {
+		xc.timeout = constant.DefaultXenditTimeout
+	}
+
+	if xenditConfig.MaxRetries != nil {
+		xc.maxRetries = max(*xenditConfig.MaxRetries, 0)
+	}
+
+	if xc.retryBackoff <= 0 {
+		xc.retryBackoff = constant.DefaultXenditRetryBackoff
+	}
+
+	return xc
+}
+
+// xenditRequest describe a call, post request is only retried when it has an idempotency key.
+type xenditRequest struct {
+	operation string
+	method    string
+	path      string
+	query     url.Values
+	payload   any
+	// header name differ per xendit api
+	idempotencyHeader string
+	idempotencyKey    string
 }
 
 func (xc *xenditClient) CreateInvoice(ctx context.Context, param models.XenditInvoiceRequest) (models.XenditInvoiceResponse, error) {
 	var result models.XenditInvoiceResponse
 
-	payload, err := json.Marshal(param)
+	// external id is unique per payment, so retried request never create a second invoice
+	err := xc.do(ctx, xenditRequest{
+		operation:         "CreateInvoice",
+		method:            http.MethodPost,
+		path:              "/v2/invoices",
+		payload:           param,
+		idempotencyHeader: "X-IDEMPOTENCY-KEY",
+		idempotencyKey:    param.ExternalID,
+	}, &result)
 	if err != nil {
 		return models.XenditInvoiceResponse{}, err
 	}
 
-	uri := "https://api.xendit.co/v2/invoices"
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewBuffer(payload))
+	return result, nil
+}
+
+func (xc *xenditClient) CheckInvoiceStatus(ctx context.Context, externalID string) (string, error) {
+	invoice, err := xc.GetInvoiceByExternalID(ctx, externalID)
 	if err != nil {
-		return models.XenditInvoiceResponse{}, err
+		return "", err
 	}
 
-	req.SetBasicAuth(xc.APISecretKey, "")
-	req.Header.Set("Content-Type", "application/json")
+	return invoice.Status, nil
+}
+
+func (xc *xenditClient) GetInvoiceByExternalID(ctx context.Context, externalID string) (models.XenditInvoiceResponse, error) {
+	var response []models.XenditInvoiceResponse
 
-	res, err := http.DefaultClient.Do(req)
+	err := xc.do(ctx, xenditRequest{
+		operation: "GetInvoiceByExternalID",
+		method:    http.MethodGet,
+		path:      "/v2/invoices",
+		query:     url.Values{"external_id": []string{externalID}},
+	}, &response)
 	if err != nil {
 		return models.XenditInvoiceResponse{}, err
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode >= 300 {
-		body, _ := io.ReadAll(res.Body)
-		return models.XenditInvoiceResponse{}, errors.New(fmt.Sprintf("xendit.CreateInvoice() got error %s", body))
+	if len(response) == 0 {
+		return models.XenditInvoiceResponse{}, fmt.Errorf("%w: external_id %s", ErrXenditInvoiceNotFound, externalID)
+	}
+
+	return response[0], nil
+}
+
+func (xc *xenditClient) ListInvoices(ctx context.Context, param models.XenditListInvoicesRequest) ([]models.XenditInvoiceResponse, error) {
+	query := url.Values{}
+	query.Set("created_after", param.CreatedAfter.UTC().Format(time.RFC3339Nano))
+	// created_before is inclusive at xendit, move it back so range is half open
+	query.Set("created_before", param.CreatedBefore.Add(-time.Millisecond).UTC().Format(time.RFC3339Nano))
+	query.Set("limit", strconv.Itoa(param.Limit))
+	if param.LastInvoiceID != "" {
+		query.Set("last_invoice_id", param.LastInvoiceID)
+	}
+
+	var response []models.XenditInvoiceResponse
+	err := xc.do(ctx, xenditRequest{
+		operation: "ListInvoices",
+		method:    http.MethodGet,
+		path:      "/v2/invoices",
+		query:     query,
+	}, &response)
+	if err != nil {
+		return nil, err
 	}
 
-	err = json.NewDecoder(res.Body).Decode(&result)
+	return response, nil
+}
+
+func (xc *xenditClient) ExpireInvoice(ctx context.Context, invoiceID string) (models.XenditInvoiceResponse, error) {
+	var result models.XenditInvoiceResponse
+
+	// not retried, expiring an invoice which is already expired return error
+	err := xc.do(ctx, xenditRequest{
+		operation: "ExpireInvoice",
+		method:    http.MethodPost,
+		path:      fmt.Sprintf("/invoices/%s/expire!", url.PathEscape(invoiceID)),
+	}, &result)
 	if err != nil {
 		return models.XenditInvoiceResponse{}, err
 	}
@@ -62,28 +194,162 @@ func (xc *xenditClient) CreateInvoice(ctx context.Context, param models.XenditIn
 	return result, nil
 }
 
-func (xc *xenditClient) CheckInvoiceStatus(ctx context.Context, externalID string) (string, error) {
-	uri := fmt.Sprintf("https://api.xendit.co/v2/invoices?external_id=%s", externalID)
+func (xc *xenditClient) CreateRefund(ctx context.Context, param models.XenditRefundRequest) (models.XenditRefundResponse, error) {
+	var result models.XenditRefundResponse
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	// reference id is unique per refund, safe to reuse as idempotency key
+	err := xc.do(ctx, xenditRequest{
+		operation:         "CreateRefund",
+		method:            http.MethodPost,
+		path:              "/refunds",
+		payload:           param,
+		idempotencyHeader: "Idempotency-key",
+		idempotencyKey:    param.ReferenceID,
+	}, &result)
 	if err != nil {
-		return "", err
+		return models.XenditRefundResponse{}, err
 	}
 
-	req.SetBasicAuth(xc.APISecretKey, "")
-	req.Header.Set("Content-Type", "application/json")
+	return result, nil
+}
+
+func (xc *xenditClient) GetRefund(ctx context.Context, refundID string) (models.XenditRefundResponse, error) {
+	var result models.XenditRefundResponse
 
-	res, err := http.DefaultClient.Do(req)
+	err := xc.do(ctx, xenditRequest{
+		operation: "GetRefund",
+		method:    http.MethodGet,
+		path:      fmt.Sprintf("/refunds/%s", url.PathEscape(refundID)),
+	}, &result)
 	if err != nil {
-		return "", err
+		return models.XenditRefundResponse{}, err
+	}
+
+	return result, nil
+}
+
+// do send the request and decode the response into result, failed attempt is retried with backoff
+// when the request is safe to repeat and the error is temporary.
+func (xc *xenditClient) do(ctx context.Context, req xenditRequest, result any) error {
+	var body []byte
+	if req.payload != nil {
+		payload, err := json.Marshal(req.payload)
+		if err != nil {
+			return err
+		}
+
+		body = payload
+	}
+
+	canRetry := req.method == http.MethodGet || req.idempotencyKey != ""
+	for attempt := 0; ; attempt++ {
+		err := xc.doOnce(ctx, req, body, result)
+		if err == nil {
+			return nil
+		}
+
+		if !canRetry || attempt >= xc.maxRetries || ctx.Err() != nil || !isRetryableXenditError(err) {
+			return err
+		}
+
+		backoff := xc.backoff(attempt, err)
+		log.Logger.WithFields(logrus.Fields{
+			"operation": req.operation,
+			"attempt":   attempt + 1,
+			"backoff":   backoff.String(),
+		}).Warnf("xendit.%s() got error, retrying: %v", req.operation, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (xc *xenditClient) doOnce(ctx context.Context, req xenditRequest, body []byte, result any) error {
+	ctx, cancel := context.WithTimeout(ctx, xc.timeout)
+	defer cancel()
+
+	uri := xc.BaseURL + req.path
+	if len(req.query) > 0 {
+		uri += "?" + req.query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, uri, reqBody)
+	if err != nil {
+		return err
+	}
+
+	httpReq.SetBasicAuth(xc.APISecretKey, "")
+	httpReq.Header.Set("Content-Type", "application/json")
+	if req.idempotencyKey != "" {
+		httpReq.Header.Set(req.idempotencyHeader, req.idempotencyKey)
+	}
+
+	res, err := xc.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("xendit.%s() got error: %w", req.operation, err)
 	}
 	defer res.Body.Close()
 
-	var response []models.XenditInvoiceResponse
-	err = json.NewDecoder(res.Body).Decode(&response)
+	if res.StatusCode >= 300 {
+		return parseXenditError(req.operation, res)
+	}
+
+	err = json.NewDecoder(res.Body).Decode(result)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("xendit.%s() got invalid response: %w", req.operation, err)
+	}
+
+	return nil
+}
+
+// backoff is doubled per attempt and capped, half of it is jitter so retries of many calls are spread.
+func (xc *xenditClient) backoff(attempt int, err error) time.Duration {
+	backoff := min(xc.retryBackoff<<attempt, constant.MaxXenditRetryBackoff)
+	backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+
+	var xenditErr *XenditError
+	if errors.As(err, &xenditErr) && xenditErr.RetryAfter > backoff {
+		return min(xenditErr.RetryAfter, constant.MaxXenditRetryBackoff)
+	}
+
+	return backoff
+}
+
+func parseXenditError(operation string, res *http.Response) error {
+	xenditErr := &XenditError{
+		Operation:  operation,
+		StatusCode: res.StatusCode,
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 64<<10))
+	if json.Unmarshal(body, xenditErr) != nil || xenditErr.ErrorCode == "" {
+		xenditErr.Message = string(body)
+	}
+
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		xenditErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return xenditErr
+}
+
+// network error and timeout of the attempt are retried, invalid response is not since the call may have succeeded.
+func isRetryableXenditError(err error) bool {
+	var xenditErr *XenditError
+	if errors.As(err, &xenditErr) {
+		return xenditErr.Retryable()
 	}
 
-	return response[0].Status, nil
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
 }
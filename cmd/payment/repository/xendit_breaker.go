@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"payment/infrastructure/breaker"
+	"payment/models"
+)
+
+type breakerXenditClient struct {
+	client  XenditClient
+	breaker *breaker.Breaker
+}
+
+// NewBreakerXenditClient wrap the client with a circuit breaker, breaker.ErrOpen is returned while it is open.
+func NewBreakerXenditClient(client XenditClient, cb *breaker.Breaker) XenditClient {
+	return &breakerXenditClient{
+		client:  client,
+		breaker: cb,
+	}
+}
+
+// IsXenditFailure is true when the error means xendit is unhealthy, rejected request e.g. 4xx is not.
+func IsXenditFailure(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrXenditInvoiceNotFound) {
+		return false
+	}
+
+	var xenditErr *XenditError
+	if errors.As(err, &xenditErr) {
+		return xenditErr.Retryable()
+	}
+
+	return true
+}
+
+func (c *breakerXenditClient) CreateInvoice(ctx context.Context, param models.XenditInvoiceRequest) (result models.XenditInvoiceResponse, err error) {
+	err = c.breaker.Execute(func() (err error) {
+		result, err = c.client.CreateInvoice(ctx, param)
+		return err
+	})
+
+	return result, err
+}
+
+func (c *breakerXenditClient) CheckInvoiceStatus(ctx context.Context, externalID string) (status string, err error) {
+	err = c.breaker.Execute(func() (err error) {
+		status, err = c.client.CheckInvoiceStatus(ctx, externalID)
+		return err
+	})
+
+	return status, err
+}
+
+func (c *breakerXenditClient) GetInvoiceByExternalID(ctx context.Context, externalID string) (result models.XenditInvoiceResponse, err error) {
+	err = c.breaker.Execute(func() (err error) {
+		result, err = c.client.GetInvoiceByExternalID(ctx, externalID)
+		return err
+	})
+
+	return result, err
+}
+
+func (c *breakerXenditClient) ListInvoices(ctx context.Context, param models.XenditListInvoicesRequest) (result []models.XenditInvoiceResponse, err error) {
+	err = c.breaker.Execute(func() (err error) {
+		result, err = c.client.ListInvoices(ctx, param)
+		return err
+	})
+
+	return result, err
+}
+
+func (c *breakerXenditClient) ExpireInvoice(ctx context.Context, invoiceID string) (result models.XenditInvoiceResponse, err error) {
+	err = c.breaker.Execute(func() (err error) {
+		result, err = c.client.ExpireInvoice(ctx, invoiceID)
+		return err
+	})
+
+	return result, err
+}
+
+func (c *breakerXenditClient) CreateRefund(ctx context.Context, param models.XenditRefundRequest) (result models.XenditRefundResponse, err error) {
+	err = c.breaker.Execute(func() (err error) {
+		result, err = c.client.CreateRefund(ctx, param)
+		return err
+	})
+
+	return result, err
+}
+
+func (c *breakerXenditClient) GetRefund(ctx context.Context, refundID string) (result models.XenditRefundResponse, err error) {
+	err = c.breaker.Execute(func() (err error) {
+		result, err = c.client.GetRefund(ctx, refundID)
+		return err
+	})
+
+	return result, err
+}
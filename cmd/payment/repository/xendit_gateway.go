@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"payment/config"
+	"payment/infrastructure/constant"
+	"payment/models"
+	"strings"
+	"time"
+)
+
+type xenditGateway struct {
+	client        XenditClient
+	webhookToken  string
+	invoiceConfig config.XenditInvoiceConfig
+}
+
+// NewXenditGateway adapt xendit invoice api as payment gateway, webhook is verified by x-callback-token.
+// invoiceConfig is applied to every created invoice.
+func NewXenditGateway(client XenditClient, webhookToken string, invoiceConfig config.XenditInvoiceConfig) PaymentGateway {
+	return &xenditGateway{
+		client:        client,
+		webhookToken:  webhookToken,
+		invoiceConfig: invoiceConfig,
+	}
+}
+
+func (g *xenditGateway) Name() string {
+	return constant.GatewayProviderXendit
+}
+
+func (g *xenditGateway) CreateCharge(ctx context.Context, param models.ChargeRequest) (models.Charge, error) {
+	req, err := g.invoiceRequest(param)
+	if err != nil {
+		return models.Charge{}, err
+	}
+
+	invoice, err := g.client.CreateInvoice(ctx, req)
+	if err != nil {
+		return models.Charge{}, err
+	}
+
+	return xenditInvoiceToCharge(invoice), nil
+}
+
+func (g *xenditGateway) GetCharge(ctx context.Context, externalID string) (models.Charge, error) {
+	invoice, err := g.client.GetInvoiceByExternalID(ctx, externalID)
+	if errors.Is(err, ErrXenditInvoiceNotFound) {
+		return models.Charge{}, fmt.Errorf("%w: %v", ErrChargeNotFound, err)
+	}
+
+	if err != nil {
+		return models.Charge{}, err
+	}
+
+	return xenditInvoiceToCharge(invoice), nil
+}
+
+func (g *xenditGateway) ExpireCharge(ctx context.Context, externalID string) error {
+	charge, err := g.GetCharge(ctx, externalID)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.client.ExpireInvoice(ctx, charge.ID)
+	return err
+}
+
+func (g *xenditGateway) CreateRefund(ctx context.Context, param models.GatewayRefundRequest) (models.GatewayRefund, error) {
+	// xendit refund api need the invoice id, not our external id
+	invoiceID := param.ChargeID
+	if invoiceID == "" {
+		charge, err := g.GetCharge(ctx, param.ExternalID)
+		if err != nil {
+			return models.GatewayRefund{}, err
+		}
+
+		invoiceID = charge.ID
+	}
+
+	refund, err := g.client.CreateRefund(ctx, models.XenditRefundRequest{
+		InvoiceID:   invoiceID,
+		ReferenceID: param.ReferenceID,
+		Amount:      param.Amount.Decimal(),
+		Reason:      param.Reason,
+	})
+	if err != nil {
+		return models.GatewayRefund{}, err
+	}
+
+	return xenditRefundToGatewayRefund(refund), nil
+}
+
+func (g *xenditGateway) GetRefund(ctx context.Context, externalID string, refundID string) (models.GatewayRefund, error) {
+	refund, err := g.client.GetRefund(ctx, refundID)
+	if err != nil {
+		return models.GatewayRefund{}, err
+	}
+
+	return xenditRefundToGatewayRefund(refund), nil
+}
+
+func (g *xenditGateway) VerifyWebhook(headers http.Header, rawBody []byte) error {
+	token := headers.Get("x-callback-token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(g.webhookToken)) != 1 {
+		return ErrInvalidWebhookSignature
+	}
+
+	return nil
+}
+
+func (g *xenditGateway) ParseWebhook(rawBody []byte) (models.GatewayWebhook, error) {
+	var payload models.XenditWebhookPayload
+	if err := json.Unmarshal(rawBody, &payload); err != nil {
+		return models.GatewayWebhook{}, err
+	}
+
+	amount, err := payload.Total()
+	if err != nil {
+		return models.GatewayWebhook{}, fmt.Errorf("invalid amount %q: %w", payload.Amount, err)
+	}
+
+	// xendit invoice id, fallback to external id for payload without id
+	callbackID := payload.ID
+	if callbackID == "" {
+		callbackID = payload.ExternalID
+	}
+
+	return models.GatewayWebhook{
+		Provider:      constant.GatewayProviderXendit,
+		CallbackID:    callbackID,
+		ExternalID:    payload.ExternalID,
+		Status:        xenditGatewayStatus(payload.Status),
+		Amount:        amount,
+		PaymentMethod: payload.PaymentMethod,
+		PaidAt:        payload.PaidAt,
+	}, nil
+}
+
+// invoiceRequest round item prices to the currency of the amount, like the amount itself.
+func (g *xenditGateway) invoiceRequest(param models.ChargeRequest) (models.XenditInvoiceRequest, error) {
+	req := models.XenditInvoiceRequest{
+		ExternalID:         param.ExternalID,
+		Amount:             param.Amount.Decimal(),
+		Description:        param.Description,
+		PayerEmail:         param.PayerEmail,
+		Currency:           param.Amount.Currency,
+		InvoiceDuration:    int64(g.invoiceConfig.InvoiceDuration / time.Second),
+		PaymentMethods:     g.invoiceConfig.PaymentMethods,
+		SuccessRedirectURL: strings.ReplaceAll(g.invoiceConfig.SuccessRedirectURL, "{external_id}", url.QueryEscape(param.ExternalID)),
+		FailureRedirectURL: strings.ReplaceAll(g.invoiceConfig.FailureRedirectURL, "{external_id}", url.QueryEscape(param.ExternalID)),
+	}
+
+	if param.PayerName != "" || param.PayerEmail != "" || param.PayerPhone != "" {
+		req.Customer = &models.XenditCustomer{
+			GivenNames:   param.PayerName,
+			Email:        param.PayerEmail,
+			MobileNumber: param.PayerPhone,
+		}
+	}
+
+	for _, item := range param.Items {
+		price, err := models.ParseMoney(item.Price.String(), param.Amount.Currency)
+		if err != nil {
+			return models.XenditInvoiceRequest{}, fmt.Errorf("invalid price of item %q: %w", item.Name, err)
+		}
+
+		req.Items = append(req.Items, models.XenditInvoiceItem{
+			Name:     item.Name,
+			Quantity: item.Quantity,
+			Price:    price.Decimal(),
+			Category: item.Category,
+		})
+	}
+
+	return req, nil
+}
+
+func xenditInvoiceToCharge(invoice models.XenditInvoiceResponse) models.Charge {
+	amount, _ := invoice.Total()
+
+	return models.Charge{
+		ID:            invoice.ID,
+		ExternalID:    invoice.ExternalID,
+		Amount:        amount,
+		Status:        xenditGatewayStatus(invoice.Status),
+		PaymentMethod: invoice.PaymentMethod,
+		PaymentURL:    invoice.InvoiceURL,
+		ExpiryTime:    invoice.ExpiryDate,
+		PaidTime:      invoice.PaidAt,
+	}
+}
+
+func xenditRefundToGatewayRefund(refund models.XenditRefundResponse) models.GatewayRefund {
+	status := constant.RefundStatusPending
+	switch refund.Status {
+	case constant.RefundStatusSucceeded, constant.RefundStatusFailed:
+		status = refund.Status
+	}
+
+	amount, _ := refund.Total()
+
+	return models.GatewayRefund{
+		ID:          refund.ID,
+		ReferenceID: refund.ReferenceID,
+		Amount:      amount,
+		Status:      status,
+		FailureCode: refund.FailureCode,
+	}
+}
+
+// settled invoice is paid invoice which fund is already transferred, unknown status is kept as is
+func xenditGatewayStatus(status string) string {
+	switch status {
+	case constant.XenditInvoiceStatusPending:
+		return constant.GatewayStatusPending
+	case constant.XenditInvoiceStatusPaid, constant.XenditInvoiceStatusSettled:
+		return constant.GatewayStatusPaid
+	case constant.XenditInvoiceStatusExpired:
+		return constant.GatewayStatusExpired
+	case constant.XenditInvoiceStatusFailed:
+		return constant.GatewayStatusFailed
+	default:
+		return status
+	}
+}
@@ -0,0 +1,19 @@
+package resource
+
+import (
+	"payment/config"
+	"payment/infrastructure/breaker"
+)
+
+// InitCircuitBreaker create the breaker with its configured thresholds, isFailure decide which error trips it.
+func InitCircuitBreaker(cfg *config.Config, name string, isFailure func(err error) bool) *breaker.Breaker {
+	breakerConfig := cfg.CircuitBreakers[name]
+
+	return breaker.New(breaker.Settings{
+		Name:             name,
+		FailureThreshold: breakerConfig.FailureThreshold,
+		OpenTimeout:      breakerConfig.OpenTimeout,
+		HalfOpenRequests: breakerConfig.HalfOpenRequests,
+		IsFailure:        isFailure,
+	})
+}
@@ -0,0 +1,23 @@
+package resource
+
+import (
+	"log"
+	"payment/cmd/payment/repository"
+	"payment/config"
+	"payment/infrastructure/breaker"
+)
+
+// InitPaymentGateways register xendit and every optional gateway which is configured.
+func InitPaymentGateways(cfg *config.Config, xenditClient repository.XenditClient, midtransBreaker *breaker.Breaker) repository.PaymentGatewayResolver {
+	gateways := []repository.PaymentGateway{
+		repository.NewXenditGateway(xenditClient, cfg.Xendit.WebhookToken, cfg.Xendit.Invoice),
+	}
+
+	if cfg.Midtrans.ServerKey != "" {
+		midtransGateway := repository.NewMidtransGateway(cfg.Midtrans)
+		gateways = append(gateways, repository.NewBreakerMidtransGateway(midtransGateway, midtransBreaker))
+		log.Println("Midtrans gateway is enabled")
+	}
+
+	return repository.NewPaymentGateways(cfg.Gateway.Default, cfg.Gateway.PaymentMethods, gateways...)
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment/cmd/payment/repository"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidAnomalyResolution = errors.New("invalid anomaly resolution status")
+	ErrAnomalyAlreadyResolved   = errors.New("anomaly already resolved")
+)
+
+type AnomalyService interface {
+	GetPaymentAnomalies(ctx context.Context, filter models.PaymentAnomalyFilter) ([]models.PaymentAnomaly, error)
+	GetPaymentAnomalyDetail(ctx context.Context, anomalyID int64) (*models.PaymentAnomalyDetail, error)
+	ResolvePaymentAnomaly(ctx context.Context, anomalyID int64, param models.ResolvePaymentAnomalyRequest) error
+}
+
+type anomalyService struct {
+	database       repository.PaymentDatabase
+	paymentService PaymentService
+}
+
+func NewAnomalyService(database repository.PaymentDatabase, paymentService PaymentService) AnomalyService {
+	return &anomalyService{
+		database:       database,
+		paymentService: paymentService,
+	}
+}
+
+func (s *anomalyService) GetPaymentAnomalies(ctx context.Context, filter models.PaymentAnomalyFilter) ([]models.PaymentAnomaly, error) {
+	anomalies, err := s.database.GetPaymentAnomalies(ctx, filter)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("s.database.GetPaymentAnomalies() got error: %v", err)
+
+		return nil, err
+	}
+
+	return anomalies, nil
+}
+
+func (s *anomalyService) GetPaymentAnomalyDetail(ctx context.Context, anomalyID int64) (*models.PaymentAnomalyDetail, error) {
+	anomaly, err := s.database.GetPaymentAnomalyByID(ctx, anomalyID)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id": anomalyID,
+		}).Errorf("s.database.GetPaymentAnomalyByID() got error: %v", err)
+
+		return nil, err
+	}
+
+	detail := models.PaymentAnomalyDetail{
+		Anomaly: *anomaly,
+	}
+
+	// anomaly can be stored before the payment exists, so payment is optional
+	paymentInfo, err := s.database.GetPaymentInfoByOrderID(ctx, anomaly.OrderID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Logger.WithFields(logrus.Fields{
+			"id":       anomalyID,
+			"order_id": anomaly.OrderID,
+		}).Errorf("s.database.GetPaymentInfoByOrderID() got error: %v", err)
+
+		return nil, err
+	}
+
+	if err == nil {
+		detail.Payment = paymentInfo
+	}
+
+	auditLogs, err := s.database.GetAuditLogsByOrderID(ctx, anomaly.OrderID)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id":       anomalyID,
+			"order_id": anomaly.OrderID,
+		}).Errorf("s.database.GetAuditLogsByOrderID() got error: %v", err)
+
+		return nil, err
+	}
+
+	detail.AuditLogs = auditLogs
+
+	return &detail, nil
+}
+
+// ResolvePaymentAnomaly mark anomaly as success, or retry which re-run the payment success process.
+func (s *anomalyService) ResolvePaymentAnomaly(ctx context.Context, anomalyID int64, param models.ResolvePaymentAnomalyRequest) error {
+	if param.Status != constant.PaymentAnomalyStatusSuccess && param.Status != constant.PaymentAnomalyStatusRetry {
+		return ErrInvalidAnomalyResolution
+	}
+
+	anomaly, err := s.database.GetPaymentAnomalyByID(ctx, anomalyID)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id": anomalyID,
+		}).Errorf("s.database.GetPaymentAnomalyByID() got error: %v", err)
+
+		return err
+	}
+
+	if anomaly.Status != constant.PaymentAnomalyStatusNeedToCheck {
+		return ErrAnomalyAlreadyResolved
+	}
+
+	if param.Status == constant.PaymentAnomalyStatusRetry {
+		err = s.paymentService.ProcessPaymentSuccess(ctx, anomaly.OrderID, models.PaymentPaidDetail{})
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"id":       anomalyID,
+				"order_id": anomaly.OrderID,
+			}).Errorf("s.paymentService.ProcessPaymentSuccess() got error: %v", err)
+
+			return err
+		}
+	}
+
+	resolved, err := s.database.ResolvePaymentAnomaly(ctx, anomalyID, param.Status, param.ResolvedBy, param.Notes)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id":    anomalyID,
+			"param": param,
+		}).Errorf("s.database.ResolvePaymentAnomaly() got error: %v", err)
+
+		return err
+	}
+
+	// resolved concurrently by another admin
+	if !resolved {
+		return ErrAnomalyAlreadyResolved
+	}
+
+	errLogAudit := s.database.InsertAuditLog(ctx, models.PaymentAuditLog{
+		OrderID:    anomaly.OrderID,
+		ExternalID: anomaly.ExternalID,
+		Event:      fmt.Sprintf("ResolvePaymentAnomaly:%d", anomalyID),
+		Actor:      fmt.Sprintf("user:%d", param.ResolvedBy),
+		CreateTime: time.Now(),
+	})
+	if errLogAudit != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id": anomalyID,
+		}).Errorf("s.database.InsertAuditLog() got error: %v", errLogAudit)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	mocks "payment/cmd/test_mock"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ResolvePaymentAnomaly(t *testing.T) {
+	type mockFields struct {
+		database       *mocks.MockPaymentDatabase
+		paymentService *mocks.MockPaymentService
+	}
+
+	type args struct {
+		ctx       context.Context
+		anomalyID int64
+		param     models.ResolvePaymentAnomalyRequest
+	}
+
+	needToCheckAnomaly := &models.PaymentAnomaly{
+		ID:          1,
+		OrderID:     123,
+		ExternalID:  "order-123",
+		AnomalyType: constant.AnomalyTypeInvalidAmount,
+		Status:      constant.PaymentAnomalyStatusNeedToCheck,
+	}
+
+	tests := []struct {
+		name      string
+		args      args
+		mock      func(mockFields)
+		wantError error
+	}{
+		{
+			name: "given_unknown_status_then_it_should_return_error_invalid_resolution",
+			args: args{
+				ctx:       context.Background(),
+				anomalyID: 1,
+				param: models.ResolvePaymentAnomalyRequest{
+					Status: constant.PaymentAnomalyStatusNeedToCheck,
+					Notes:  "checked",
+				},
+			},
+			mock:      func(mf mockFields) {},
+			wantError: ErrInvalidAnomalyResolution,
+		},
+		{
+			name: "given_already_resolved_anomaly_then_it_should_return_error_already_resolved",
+			args: args{
+				ctx:       context.Background(),
+				anomalyID: 1,
+				param: models.ResolvePaymentAnomalyRequest{
+					Status: constant.PaymentAnomalyStatusSuccess,
+					Notes:  "checked",
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentAnomalyByID(context.Background(), int64(1)).Return(&models.PaymentAnomaly{
+					ID:     1,
+					Status: constant.PaymentAnomalyStatusSuccess,
+				}, nil)
+			},
+			wantError: ErrAnomalyAlreadyResolved,
+		},
+		{
+			name: "given_retry_status_but_got_error_ProcessPaymentSuccess_then_it_should_not_resolve_anomaly",
+			args: args{
+				ctx:       context.Background(),
+				anomalyID: 1,
+				param: models.ResolvePaymentAnomalyRequest{
+					Status:     constant.PaymentAnomalyStatusRetry,
+					Notes:      "amount confirmed by finance",
+					ResolvedBy: 7,
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentAnomalyByID(context.Background(), int64(1)).Return(needToCheckAnomaly, nil)
+				mf.paymentService.EXPECT().ProcessPaymentSuccess(context.Background(), int64(123), models.PaymentPaidDetail{}).Return(assert.AnError)
+			},
+			wantError: assert.AnError,
+		},
+		{
+			name: "given_retry_status_and_success_ProcessPaymentSuccess_then_it_should_resolve_anomaly",
+			args: args{
+				ctx:       context.Background(),
+				anomalyID: 1,
+				param: models.ResolvePaymentAnomalyRequest{
+					Status:     constant.PaymentAnomalyStatusRetry,
+					Notes:      "amount confirmed by finance",
+					ResolvedBy: 7,
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentAnomalyByID(context.Background(), int64(1)).Return(needToCheckAnomaly, nil)
+				mf.paymentService.EXPECT().ProcessPaymentSuccess(context.Background(), int64(123), models.PaymentPaidDetail{}).Return(nil)
+				mf.database.EXPECT().ResolvePaymentAnomaly(context.Background(), int64(1), constant.PaymentAnomalyStatusRetry, int64(7), "amount confirmed by finance").Return(true, nil)
+				mf.database.EXPECT().InsertAuditLog(context.Background(), gomock.Any()).Return(nil)
+			},
+			wantError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			log.SetupLogger()
+
+			mock := mockFields{
+				database:       mocks.NewMockPaymentDatabase(ctrl),
+				paymentService: mocks.NewMockPaymentService(ctrl),
+			}
+
+			service := &anomalyService{
+				database:       mock.database,
+				paymentService: mock.paymentService,
+			}
+
+			test.mock(mock)
+			gotError := service.ResolvePaymentAnomaly(test.args.ctx, test.args.anomalyID, test.args.param)
+			assert.Equal(t, test.wantError, gotError)
+		})
+	}
+}
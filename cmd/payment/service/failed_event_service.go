@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"payment/cmd/payment/repository"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const RetryFailedEventBatchSize = 20
+
+type FailedEventService interface {
+	GetFailedEvents(ctx context.Context, filter models.FailedEventFilter) ([]models.FailedEvents, error)
+	ReplayFailedEvents(ctx context.Context, ids []int64, actor string) ([]models.FailedEventReplayResult, error)
+	CloseFailedEvents(ctx context.Context, ids []int64, actor string, notes string) (int64, error)
+	RetryFailedEvents(ctx context.Context) (int, error)
+}
+
+type failedEventService struct {
+	database repository.PaymentDatabase
+}
+
+func NewFailedEventService(database repository.PaymentDatabase) FailedEventService {
+	return &failedEventService{
+		database: database,
+	}
+}
+
+func (s *failedEventService) GetFailedEvents(ctx context.Context, filter models.FailedEventFilter) ([]models.FailedEvents, error) {
+	failedEvents, err := s.database.GetFailedEvents(ctx, filter)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("s.database.GetFailedEvents() got error: %v", err)
+
+		return nil, err
+	}
+
+	return failedEvents, nil
+}
+
+// ReplayFailedEvents re-publish the given failed events through outbox, used by admin API and CLI.
+func (s *failedEventService) ReplayFailedEvents(ctx context.Context, ids []int64, actor string) ([]models.FailedEventReplayResult, error) {
+	failedEvents, err := s.database.GetFailedEventsByIDs(ctx, ids)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"ids": ids,
+		}).Errorf("s.database.GetFailedEventsByIDs() got error: %v", err)
+
+		return nil, err
+	}
+
+	found := make(map[int64]bool, len(failedEvents))
+	results := make([]models.FailedEventReplayResult, 0, len(ids))
+	for _, failedEvent := range failedEvents {
+		found[failedEvent.ID] = true
+
+		if failedEvent.Status == constant.FailedPublishEventStatusSuccess || failedEvent.Status == constant.FailedPublishEventStatusClosed {
+			results = append(results, models.FailedEventReplayResult{
+				ID:      failedEvent.ID,
+				Message: "failed event already success or closed",
+			})
+			continue
+		}
+
+		err = s.replay(ctx, failedEvent, actor)
+		if err != nil {
+			errUpdate := s.database.UpdateFailedEventRetry(ctx, failedEvent.ID, failedEvent.Status, err.Error())
+			if errUpdate != nil {
+				log.Logger.WithFields(logrus.Fields{
+					"id": failedEvent.ID,
+				}).Errorf("s.database.UpdateFailedEventRetry() got error: %v", errUpdate)
+			}
+
+			results = append(results, models.FailedEventReplayResult{
+				ID:      failedEvent.ID,
+				Message: err.Error(),
+			})
+			continue
+		}
+
+		results = append(results, models.FailedEventReplayResult{
+			ID:      failedEvent.ID,
+			Success: true,
+		})
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			results = append(results, models.FailedEventReplayResult{
+				ID:      id,
+				Message: "failed event not found",
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// CloseFailedEvents close failed events which need to check without replaying them.
+func (s *failedEventService) CloseFailedEvents(ctx context.Context, ids []int64, actor string, notes string) (int64, error) {
+	closedNotes := fmt.Sprintf("closed by %s: %s", actor, notes)
+	closed, err := s.database.UpdateFailedEventsStatus(ctx, ids, []int{constant.FailedPublishEventStatusNeedToCheck}, constant.FailedPublishEventStatusClosed, closedNotes)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"ids":   ids,
+			"actor": actor,
+		}).Errorf("s.database.UpdateFailedEventsStatus() got error: %v", err)
+
+		return 0, err
+	}
+
+	return closed, nil
+}
+
+// RetryFailedEvents re-publish failed events marked as retry through outbox, used by scheduler.
+// Failed event which keep failing is moved back to need to check.
+func (s *failedEventService) RetryFailedEvents(ctx context.Context) (int, error) {
+	failedEvents, err := s.database.GetFailedEvents(ctx, models.FailedEventFilter{
+		Status: constant.FailedPublishEventStatusRetry,
+		Limit:  RetryFailedEventBatchSize,
+	})
+	if err != nil {
+		log.Logger.Printf("s.database.GetFailedEvents() got error: %v", err)
+		return 0, err
+	}
+
+	processed := 0
+	for _, failedEvent := range failedEvents {
+		// stop when the lease is lost or on shutdown, remaining items are handled by the next run
+		if ctx.Err() != nil {
+			return processed, ctx.Err()
+		}
+
+		err = s.replay(ctx, failedEvent, "scheduler_service_retry_failed_events")
+		if err != nil {
+			status := constant.FailedPublishEventStatusRetry
+			if failedEvent.RetryCount+1 >= constant.MaxRetryFailedEvent {
+				status = constant.FailedPublishEventStatusNeedToCheck
+			}
+
+			errUpdate := s.database.UpdateFailedEventRetry(ctx, failedEvent.ID, status, err.Error())
+			if errUpdate != nil {
+				log.Logger.Printf("[failed event id: %d] s.database.UpdateFailedEventRetry() got error: %v", failedEvent.ID, errUpdate)
+			}
+
+			continue
+		}
+
+		processed++
+	}
+
+	return processed, nil
+}
+
+// replay re-publish failed event through outbox, event is built from the current payment data.
+func (s *failedEventService) replay(ctx context.Context, failedEvent models.FailedEvents, actor string) error {
+	outbox, err := s.buildReplayOutbox(ctx, failedEvent)
+	if err == nil {
+		err = s.database.WithTransaction(ctx, func(tx repository.PaymentDatabase) error {
+			// payment is not marked as paid when publish payment success failed
+			if failedEvent.FailedType == constant.FailedPublishEventPaymentSuccess {
+				if err := tx.MarkPaid(ctx, failedEvent.OrderID, models.PaymentPaidDetail{PaidAt: failedEvent.CreateTime}); err != nil {
+					return err
+				}
+			}
+
+			return tx.SaveOutbox(ctx, outbox)
+		})
+	}
+
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id":          failedEvent.ID,
+			"order_id":    failedEvent.OrderID,
+			"failed_type": failedEvent.FailedType,
+		}).Errorf("replay failed event got error: %v", err)
+
+		return err
+	}
+
+	_, err = s.database.UpdateFailedEventsStatus(ctx, []int64{failedEvent.ID}, []int{failedEvent.Status}, constant.FailedPublishEventStatusSuccess, fmt.Sprintf("replayed by %s", actor))
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id": failedEvent.ID,
+		}).Errorf("s.database.UpdateFailedEventsStatus() got error: %v", err)
+
+		return err
+	}
+
+	errLogAudit := s.database.InsertAuditLog(ctx, models.PaymentAuditLog{
+		OrderID:    failedEvent.OrderID,
+		ExternalID: failedEvent.ExternalID,
+		Event:      fmt.Sprintf("ReplayFailedEvent:%d", failedEvent.ID),
+		Actor:      actor,
+		CreateTime: time.Now(),
+	})
+	if errLogAudit != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id": failedEvent.ID,
+		}).Errorf("s.database.InsertAuditLog() got error: %v", errLogAudit)
+	}
+
+	return nil
+}
+
+func (s *failedEventService) buildReplayOutbox(ctx context.Context, failedEvent models.FailedEvents) (*models.Outbox, error) {
+	if failedEvent.FailedType == constant.FailedPublishEventPaymentRefunded {
+		var payload models.FailedRefundEventPayload
+		err := json.Unmarshal([]byte(failedEvent.Payload), &payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid failed event payload: %w", err)
+		}
+
+		return newOutbox(ctx, constant.KafkaTopicPaymentRefunded, models.PaymentRefundedSchemaVersion, failedEvent.OrderID, models.NewPaymentRefundedEvent(payload.Refund, payload.FullyRefunded))
+	}
+
+	paymentInfo, err := s.database.GetPaymentInfoByOrderID(ctx, failedEvent.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch failedEvent.FailedType {
+	case constant.FailedPublishEventPaymentSuccess:
+		return newOutbox(ctx, constant.KafkaTopicPaymentSuccess, models.PaymentSuccessSchemaVersion, failedEvent.OrderID, models.NewPaymentSuccessEvent(*paymentInfo, models.PaymentPaidDetail{
+			PaymentMethod: paymentInfo.PaymentMethod,
+			PaidAt:        failedEvent.CreateTime,
+		}))
+	case constant.FailedPublishEventPaymentExpired:
+		return newOutbox(ctx, constant.KafkaTopicPaymentExpired, models.PaymentExpiredSchemaVersion, failedEvent.OrderID, models.NewPaymentClosedEvent(*paymentInfo, models.PaymentStatusExpired))
+	case constant.FailedPublishEventPaymentFailed:
+		return newOutbox(ctx, constant.KafkaTopicPaymentFailed, models.PaymentFailedSchemaVersion, failedEvent.OrderID, models.NewPaymentClosedEvent(*paymentInfo, models.PaymentStatusFailed))
+	default:
+		return nil, fmt.Errorf("unknown failed event type: %d", failedEvent.FailedType)
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"payment/cmd/payment/repository"
+	mocks "payment/cmd/test_mock"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RetryFailedEvents(t *testing.T) {
+	type mockFields struct {
+		database *mocks.MockPaymentDatabase
+	}
+
+	retryFilter := models.FailedEventFilter{
+		Status: constant.FailedPublishEventStatusRetry,
+		Limit:  RetryFailedEventBatchSize,
+	}
+
+	tests := []struct {
+		name          string
+		mock          func(mockFields)
+		wantProcessed int
+		wantError     error
+	}{
+		{
+			name: "given_error_GetFailedEvents_then_it_should_return_error",
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetFailedEvents(context.Background(), retryFilter).Return(nil, assert.AnError)
+			},
+			wantProcessed: 0,
+			wantError:     assert.AnError,
+		},
+		{
+			name: "given_payment_success_event_then_it_should_mark_paid_and_save_outbox",
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetFailedEvents(context.Background(), retryFilter).Return([]models.FailedEvents{
+					{ID: 1, OrderID: 123, FailedType: constant.FailedPublishEventPaymentSuccess, Status: constant.FailedPublishEventStatusRetry},
+				}, nil)
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(123)).Return(&models.Payment{OrderID: 123, Status: models.PaymentStatusPending}, nil)
+				mf.database.EXPECT().WithTransaction(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(tx repository.PaymentDatabase) error) error {
+					return fn(mf.database)
+				})
+				mf.database.EXPECT().MarkPaid(context.Background(), int64(123), gomock.Any()).Return(nil)
+				mf.database.EXPECT().SaveOutbox(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, param *models.Outbox) error {
+					assert.Equal(t, constant.KafkaTopicPaymentSuccess, param.EventType)
+					assert.Equal(t, "order-123", param.MessageKey)
+
+					return nil
+				})
+				mf.database.EXPECT().UpdateFailedEventsStatus(context.Background(), []int64{1}, []int{constant.FailedPublishEventStatusRetry}, constant.FailedPublishEventStatusSuccess, gomock.Any()).Return(int64(1), nil)
+				mf.database.EXPECT().InsertAuditLog(context.Background(), gomock.Any()).Return(nil)
+			},
+			wantProcessed: 1,
+			wantError:     nil,
+		},
+		{
+			name: "given_last_retry_and_got_error_save_outbox_then_it_should_move_to_need_to_check",
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetFailedEvents(context.Background(), retryFilter).Return([]models.FailedEvents{
+					{ID: 1, OrderID: 123, FailedType: constant.FailedPublishEventPaymentExpired, Status: constant.FailedPublishEventStatusRetry, RetryCount: constant.MaxRetryFailedEvent - 1},
+				}, nil)
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(123)).Return(&models.Payment{OrderID: 123, Status: models.PaymentStatusExpired}, nil)
+				mf.database.EXPECT().WithTransaction(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(tx repository.PaymentDatabase) error) error {
+					return fn(mf.database)
+				})
+				mf.database.EXPECT().SaveOutbox(context.Background(), gomock.Any()).Return(assert.AnError)
+				mf.database.EXPECT().UpdateFailedEventRetry(context.Background(), int64(1), constant.FailedPublishEventStatusNeedToCheck, assert.AnError.Error()).Return(nil)
+			},
+			wantProcessed: 0,
+			wantError:     nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			log.SetupLogger()
+
+			mock := mockFields{
+				database: mocks.NewMockPaymentDatabase(ctrl),
+			}
+
+			service := &failedEventService{
+				database: mock.database,
+			}
+
+			test.mock(mock)
+			gotProcessed, gotError := service.RetryFailedEvents(context.Background())
+			assert.Equal(t, test.wantProcessed, gotProcessed)
+			assert.Equal(t, test.wantError, gotError)
+		})
+	}
+}
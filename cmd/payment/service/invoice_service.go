@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment/cmd/payment/repository"
+	"payment/grpc"
+	"payment/infrastructure/breaker"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type InvoiceService interface {
+	CreateInvoice(ctx context.Context, param models.OrderCreatedEvent) error
+}
+
+type invoiceService struct {
+	database   repository.PaymentDatabase
+	gateways   repository.PaymentGatewayResolver
+	userClient grpc.UserClient
+}
+
+func NewInvoiceService(database repository.PaymentDatabase, gateways repository.PaymentGatewayResolver, userClient grpc.UserClient) InvoiceService {
+	return &invoiceService{
+		database:   database,
+		gateways:   gateways,
+		userClient: userClient,
+	}
+}
+
+// CreateInvoice create the charge at the gateway of the order payment method, the gateway is stored on the payment.
+// Order is queued to payment_requests when a circuit breaker is open, the scheduler create it later.
+// Redelivered event of an order whose payment is already created is skipped.
+func (s *invoiceService) CreateInvoice(ctx context.Context, param models.OrderCreatedEvent) error {
+	amount, err := param.Total()
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id":   param.OrderID,
+			"amount":     param.TotalAmount,
+			"currency":   param.Currency,
+			"error_code": "s.CI006",
+		}).Errorf("param.Total() got error: %v", err)
+
+		return err
+	}
+
+	// offset is committed after the event is handled, so the same event may be delivered again
+	paymentInfo, err := s.database.GetPaymentInfoByOrderID(ctx, param.OrderID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id":   param.OrderID,
+			"error_code": "s.CI007",
+		}).Errorf("s.database.GetPaymentInfoByOrderID() got error: %v", err)
+
+		return err
+	}
+
+	if err == nil && paymentInfo.ID != 0 {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id":   param.OrderID,
+			"payment_id": paymentInfo.ID,
+		}).Info("CreateInvoice => payment is already created, event is skipped")
+
+		return nil
+	}
+
+	gateway, err := s.gateways.ForPaymentMethod(param.PaymentMethod)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id":       param.OrderID,
+			"payment_method": param.PaymentMethod,
+			"error_code":     "s.CI004",
+		}).Errorf("s.gateways.ForPaymentMethod() got error: %v", err)
+
+		return err
+	}
+
+	// get user info from user grpc service
+	userInfo, err := s.userClient.GetUserInfoByUserId(ctx, param.UserID)
+	if errors.Is(err, breaker.ErrOpen) {
+		return s.queuePaymentRequest(ctx, param, amount, err)
+	}
+
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"user_id":    param.UserID,
+			"error_code": "s.CI001",
+		}).WithError(err).Errorf("s.userClient.GetUserInfoByUserId() got error: %v", err)
+		return err
+	}
+
+	// name of the order is the name used for shipping, fallback to the account name
+	payerName := param.CustomerName
+	if payerName == "" {
+		payerName = userInfo.Name
+	}
+
+	externalID := fmt.Sprintf("order-%d", param.OrderID)
+	req := models.ChargeRequest{
+		ExternalID:    externalID,
+		Amount:        amount,
+		Description:   fmt.Sprintf("Pembayaran Order %d", param.OrderID),
+		PayerEmail:    userInfo.Email,
+		PayerName:     payerName,
+		PayerPhone:    param.CustomerPhone,
+		PaymentMethod: param.PaymentMethod,
+		Items:         param.Items,
+	}
+
+	charge, err := gateway.CreateCharge(ctx, req)
+	if errors.Is(err, breaker.ErrOpen) {
+		return s.queuePaymentRequest(ctx, param, amount, err)
+	}
+
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"param":      param,
+			"payload":    req,
+			"provider":   gateway.Name(),
+			"error_code": "s.CI002",
+		}).Errorf("gateway.CreateCharge() got error: %v", err)
+
+		return err
+	}
+
+	// save to DB
+	newPayment := models.Payment{
+		OrderID:         param.OrderID,
+		UserID:          param.UserID,
+		ExternalID:      externalID,
+		Amount:          amount,
+		Status:          models.PaymentStatusPending,
+		Provider:        gateway.Name(),
+		GatewayChargeID: charge.ID,
+		InvoiceURL:      charge.PaymentURL,
+		ExpiredTime:     charge.ExpiryTime,
+		CreateTime:      time.Now(),
+	}
+	err = s.database.SavePayment(ctx, newPayment)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"param":      param,
+			"newPayment": newPayment,
+			"error_code": "s.CI003",
+		}).Errorf("CreateInvoice => s.database.SavePayment got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *invoiceService) queuePaymentRequest(ctx context.Context, param models.OrderCreatedEvent, amount models.Money, cause error) error {
+	log.Logger.WithFields(logrus.Fields{
+		"order_id": param.OrderID,
+	}).Warnf("CreateInvoice => order is queued to payment requests: %v", cause)
+
+	err := s.database.SavePaymentRequest(ctx, models.PaymentRequests{
+		OrderID:       param.OrderID,
+		UserID:        param.UserID,
+		Amount:        amount,
+		PaymentMethod: param.PaymentMethod,
+		CustomerName:  param.CustomerName,
+		CustomerPhone: param.CustomerPhone,
+		Items:         param.Items,
+		Status:        constant.PaymentRequestStatusPending,
+		CreateTime:    time.Now(),
+	})
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"param":      param,
+			"error_code": "s.CI005",
+		}).Errorf("CreateInvoice => s.database.SavePaymentRequest got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"payment/cmd/payment/repository"
+	mocks "payment/cmd/test_mock"
+	"payment/infrastructure/breaker"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"payment/proto/userpb"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_CreateInvoice(t *testing.T) {
+	type mockFields struct {
+		userClient *mocks.MockUserClient
+		gateways   *mocks.MockPaymentGatewayResolver
+		gateway    *mocks.MockPaymentGateway
+		database   *mocks.MockPaymentDatabase
+	}
+
+	type args struct {
+		ctx   context.Context
+		param models.OrderCreatedEvent
+	}
+
+	mockTime := time.Now()
+
+	tests := []struct {
+		name      string
+		args      args
+		mock      func(mockFields)
+		wantError error
+	}{
+		{
+			name: "given_redelivered_event_of_order_whose_payment_exists_then_it_should_skip_it",
+			args: args{
+				ctx: context.Background(),
+				param: models.OrderCreatedEvent{
+					OrderID:       1,
+					UserID:        12345,
+					TotalAmount:   "10000",
+					PaymentMethod: "GoPay",
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(1)).Return(&models.Payment{ID: 10, OrderID: 1}, nil)
+			},
+			wantError: nil,
+		},
+		{
+			name: "given_error_GetPaymentInfoByOrderID_then_it_should_return_error_s.CI007",
+			args: args{
+				ctx: context.Background(),
+				param: models.OrderCreatedEvent{
+					OrderID:       1,
+					UserID:        12345,
+					TotalAmount:   "10000",
+					PaymentMethod: "GoPay",
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(1)).Return(&models.Payment{}, assert.AnError)
+			},
+			wantError: assert.AnError,
+		},
+		{
+			name: "given_payment_method_without_gateway_then_it_should_return_error_s.CI004",
+			args: args{
+				ctx: context.Background(),
+				param: models.OrderCreatedEvent{
+					OrderID:       1,
+					UserID:        12345,
+					TotalAmount:   "10000",
+					PaymentMethod: "GoPay",
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(1)).Return(&models.Payment{}, gorm.ErrRecordNotFound)
+				mf.gateways.EXPECT().ForPaymentMethod("GoPay").Return(nil, repository.ErrUnknownGateway)
+			},
+			wantError: repository.ErrUnknownGateway,
+		},
+		{
+			name: "given_valid_param_but_got_error_GetUserInfoByUserID_then_it_should_return_error_s.CI001",
+			args: args{
+				ctx: context.Background(),
+				param: models.OrderCreatedEvent{
+					OrderID:         1,
+					UserID:          12345,
+					TotalAmount:     "10000",
+					PaymentMethod:   "GoPay",
+					ShippingAddress: "Jl. Elang Testing 123",
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(1)).Return(&models.Payment{}, gorm.ErrRecordNotFound)
+				mf.gateways.EXPECT().ForPaymentMethod("GoPay").Return(mf.gateway, nil)
+				mf.userClient.EXPECT().GetUserInfoByUserId(context.Background(), int64(12345)).Return(&userpb.GetUserInfoResult{}, assert.AnError)
+			},
+			wantError: assert.AnError,
+		},
+		{
+			name: "given_user_service_breaker_is_open_then_it_should_queue_payment_request",
+			args: args{
+				ctx: context.Background(),
+				param: models.OrderCreatedEvent{
+					OrderID:       2,
+					UserID:        12345,
+					TotalAmount:   "10000",
+					PaymentMethod: "OVO",
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(2)).Return(&models.Payment{}, gorm.ErrRecordNotFound)
+				mf.gateways.EXPECT().ForPaymentMethod("OVO").Return(mf.gateway, nil)
+				mf.userClient.EXPECT().GetUserInfoByUserId(context.Background(), int64(12345)).Return(nil, breaker.ErrOpen)
+				mf.database.EXPECT().SavePaymentRequest(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, paymentRequest models.PaymentRequests) error {
+					assert.Equal(t, int64(2), paymentRequest.OrderID)
+					assert.Equal(t, int64(12345), paymentRequest.UserID)
+					assert.Equal(t, models.NewMoney(10000, models.CurrencyIDR), paymentRequest.Amount)
+					assert.Equal(t, "OVO", paymentRequest.PaymentMethod)
+					assert.Equal(t, constant.PaymentRequestStatusPending, paymentRequest.Status)
+					return nil
+				})
+			},
+			wantError: nil,
+		},
+		{
+			name: "given_gateway_breaker_is_open_but_got_error_SavePaymentRequest_then_it_should_return_error_s.CI005",
+			args: args{
+				ctx: context.Background(),
+				param: models.OrderCreatedEvent{
+					OrderID:       3,
+					UserID:        12345,
+					TotalAmount:   "10000",
+					PaymentMethod: "OVO",
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(3)).Return(&models.Payment{}, gorm.ErrRecordNotFound)
+				mf.gateways.EXPECT().ForPaymentMethod("OVO").Return(mf.gateway, nil)
+				mf.userClient.EXPECT().GetUserInfoByUserId(context.Background(), int64(12345)).Return(&userpb.GetUserInfoResult{
+					Id:    12345,
+					Email: "ofc.denisetiawan@gmail.com",
+				}, nil)
+				mf.gateway.EXPECT().CreateCharge(context.Background(), gomock.Any()).Return(models.Charge{}, fmt.Errorf("%w: xendit", breaker.ErrOpen))
+				mf.database.EXPECT().SavePaymentRequest(context.Background(), gomock.Any()).Return(assert.AnError)
+			},
+			wantError: assert.AnError,
+		},
+		{
+			name: "given_valid_param_and_success_GetUserInfoByUserID_but_got_error_CreateInvoice_then_it_should_return_error_s.CI002",
+			args: args{
+				ctx: context.Background(),
+				param: models.OrderCreatedEvent{
+					OrderID:         123,
+					UserID:          12345,
+					TotalAmount:     "10000",
+					PaymentMethod:   "GoPay",
+					ShippingAddress: "Jl. Elang Testing 123",
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(123)).Return(&models.Payment{}, gorm.ErrRecordNotFound)
+				mf.gateways.EXPECT().ForPaymentMethod("GoPay").Return(mf.gateway, nil)
+				mf.userClient.EXPECT().GetUserInfoByUserId(context.Background(), int64(12345)).Return(&userpb.GetUserInfoResult{
+					Id:    12345,
+					Name:  "Deni Setiawan",
+					Email: "ofc.denisetiawan@gmail.com",
+					Role:  "admin",
+				}, nil)
+
+				mf.gateway.EXPECT().CreateCharge(context.Background(), models.ChargeRequest{
+					ExternalID:    fmt.Sprintf("order-%d", 123),
+					Amount:        models.NewMoney(10000, models.CurrencyIDR),
+					Description:   fmt.Sprintf("Pembayaran Order %d", 123),
+					PayerEmail:    "ofc.denisetiawan@gmail.com",
+					PayerName:     "Deni Setiawan",
+					PaymentMethod: "GoPay",
+				}).Return(models.Charge{}, assert.AnError)
+				mf.gateway.EXPECT().Name().Return(constant.GatewayProviderMidtrans)
+			},
+			wantError: assert.AnError,
+		},
+		{
+			name: "given_valid_param_and_success_GetUserInfoByUserID_then_success_CreateInvoice_but_got_error_SavePayment_then_it_should_return_error_s.CI003",
+			args: args{
+				ctx: context.Background(),
+				param: models.OrderCreatedEvent{
+					OrderID:         111,
+					UserID:          222,
+					TotalAmount:     "3000",
+					PaymentMethod:   "OVO",
+					ShippingAddress: "Jl. Elang Testing 123",
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(111)).Return(&models.Payment{}, gorm.ErrRecordNotFound)
+				mf.gateways.EXPECT().ForPaymentMethod("OVO").Return(mf.gateway, nil)
+				mf.userClient.EXPECT().GetUserInfoByUserId(context.Background(), int64(222)).Return(&userpb.GetUserInfoResult{
+					Id:    222,
+					Name:  "Deni Setiawan",
+					Email: "ofc.denisetiawan@gmail.com",
+					Role:  "user",
+				}, nil)
+
+				mf.gateway.EXPECT().CreateCharge(context.Background(), models.ChargeRequest{
+					ExternalID:    fmt.Sprintf("order-%d", 111),
+					Amount:        models.NewMoney(3000, models.CurrencyIDR),
+					Description:   fmt.Sprintf("Pembayaran Order %d", 111),
+					PayerEmail:    "ofc.denisetiawan@gmail.com",
+					PayerName:     "Deni Setiawan",
+					PaymentMethod: "OVO",
+				}).Return(models.Charge{
+					ID:         "xendit-invoice_111",
+					ExpiryTime: mockTime.AddDate(0, 0, 3),
+					PaymentURL: "/payment/invoice?id=xendit-invoice_111",
+					Status:     constant.GatewayStatusPending,
+				}, nil)
+				mf.gateway.EXPECT().Name().Return(constant.GatewayProviderXendit)
+
+				mf.database.EXPECT().SavePayment(context.Background(), gomock.Any()).Return(assert.AnError)
+			},
+			wantError: assert.AnError,
+		},
+		{
+			name: "given_valid_param_and_success_GetUserInfoByUserID_then_success_CreateInvoice_but_got_error_SavePayment_then_it_should_return_nil_error",
+			args: args{
+				ctx: context.Background(),
+				param: models.OrderCreatedEvent{
+					OrderID:         111,
+					UserID:          222,
+					TotalAmount:     "3000",
+					PaymentMethod:   "OVO",
+					ShippingAddress: "Jl. Elang Testing 123",
+					Currency:        "IDR",
+					CustomerName:    "Deni",
+					CustomerPhone:   "+6281234567890",
+					Items: []models.OrderItem{
+						{Name: "Kopi", Quantity: 2, Price: "1500", Category: "beverage"},
+					},
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(111)).Return(&models.Payment{}, gorm.ErrRecordNotFound)
+				mf.gateways.EXPECT().ForPaymentMethod("OVO").Return(mf.gateway, nil)
+				mf.userClient.EXPECT().GetUserInfoByUserId(context.Background(), int64(222)).Return(&userpb.GetUserInfoResult{
+					Id:    222,
+					Name:  "Deni Setiawan",
+					Email: "ofc.denisetiawan@gmail.com",
+					Role:  "user",
+				}, nil)
+
+				mf.gateway.EXPECT().CreateCharge(context.Background(), models.ChargeRequest{
+					ExternalID:    fmt.Sprintf("order-%d", 111),
+					Amount:        models.NewMoney(3000, models.CurrencyIDR),
+					Description:   fmt.Sprintf("Pembayaran Order %d", 111),
+					PayerEmail:    "ofc.denisetiawan@gmail.com",
+					PayerName:     "Deni",
+					PayerPhone:    "+6281234567890",
+					PaymentMethod: "OVO",
+					Items: []models.OrderItem{
+						{Name: "Kopi", Quantity: 2, Price: "1500", Category: "beverage"},
+					},
+				}).Return(models.Charge{
+					ID:         "xendit-invoice_111",
+					ExpiryTime: mockTime.AddDate(0, 0, 3),
+					PaymentURL: "/payment/invoice?id=xendit-invoice_111",
+					Status:     constant.GatewayStatusPending,
+				}, nil)
+				mf.gateway.EXPECT().Name().Return(constant.GatewayProviderXendit)
+
+				mf.database.EXPECT().SavePayment(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, payment models.Payment) error {
+					assert.Equal(t, constant.GatewayProviderXendit, payment.Provider)
+					assert.Equal(t, "/payment/invoice?id=xendit-invoice_111", payment.InvoiceURL)
+					assert.Equal(t, "xendit-invoice_111", payment.GatewayChargeID)
+					assert.Equal(t, mockTime.AddDate(0, 0, 3), payment.ExpiredTime)
+					return nil
+				})
+			},
+			wantError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			log.SetupLogger()
+
+			mock := mockFields{
+				userClient: mocks.NewMockUserClient(ctrl),
+				gateways:   mocks.NewMockPaymentGatewayResolver(ctrl),
+				gateway:    mocks.NewMockPaymentGateway(ctrl),
+				database:   mocks.NewMockPaymentDatabase(ctrl),
+			}
+
+			service := &invoiceService{
+				userClient: mock.userClient,
+				database:   mock.database,
+				gateways:   mock.gateways,
+			}
+
+			test.mock(mock)
+			gotError := service.CreateInvoice(test.args.ctx, test.args.param)
+			assert.Equal(t, gotError, test.wantError)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"payment/cmd/payment/repository"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"payment/utils"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const RelayOutboxBatchSize = 100
+
+type OutboxService interface {
+	RelayOutbox(ctx context.Context) (int, error)
+}
+
+type outboxService struct {
+	database  repository.PaymentDatabase
+	publisher repository.PaymentEventPublisher
+}
+
+func NewOutboxService(database repository.PaymentDatabase, publisher repository.PaymentEventPublisher) OutboxService {
+	return &outboxService{
+		database:  database,
+		publisher: publisher,
+	}
+}
+
+// RelayOutbox publish pending outbox to kafka (at least once), used by scheduler.
+// Batch is stopped at the first failure so later events of the same order are not published first.
+func (s *outboxService) RelayOutbox(ctx context.Context) (int, error) {
+	outboxes, err := s.database.GetPendingOutbox(ctx, RelayOutboxBatchSize)
+	if err != nil {
+		log.Logger.Printf("s.database.GetPendingOutbox() got error: %v", err)
+		return 0, err
+	}
+
+	published := 0
+	for _, outbox := range outboxes {
+		// stop when the lease is lost or on shutdown, remaining items are handled by the next run
+		if ctx.Err() != nil {
+			return published, ctx.Err()
+		}
+
+		err = s.publisher.PublishOutbox(ctx, outbox)
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"id":         outbox.ID,
+				"event_type": outbox.EventType,
+				"attempts":   outbox.Attempts + 1,
+			}).Errorf("s.publisher.PublishOutbox() got error: %v", err)
+
+			errUpdate := s.database.UpdateOutboxFailed(ctx, outbox.ID, err.Error())
+			if errUpdate != nil {
+				log.Logger.Printf("[outbox id: %d] s.database.UpdateOutboxFailed() got error: %v", outbox.ID, errUpdate)
+			}
+
+			return published, err
+		}
+
+		// event is published again on next relay when this fails, consumer must be idempotent
+		err = s.database.MarkOutboxPublished(ctx, outbox.ID)
+		if err != nil {
+			return published, err
+		}
+
+		published++
+	}
+
+	return published, nil
+}
+
+// newOutbox wrap the payload in event envelope, it must be saved in the same transaction as the status change.
+// Request id in ctx is used as correlation id.
+func newOutbox[T any](ctx context.Context, eventType string, schemaVersion int, orderID int64, payload T) (*models.Outbox, error) {
+	envelope := models.NewEventEnvelope(eventType, schemaVersion, utils.RequestIDFromContext(ctx), payload)
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &models.Outbox{
+		EventID:     envelope.EventID,
+		AggregateID: orderID,
+		EventType:   eventType,
+		MessageKey:  repository.PaymentEventKey(orderID),
+		Payload:     string(data),
+		Status:      constant.OutboxStatusPending,
+		CreateTime:  now,
+		UpdateTime:  now,
+	}, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	mocks "payment/cmd/test_mock"
+	"payment/infrastructure/log"
+	"payment/models"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RelayOutbox(t *testing.T) {
+	type mockFields struct {
+		database  *mocks.MockPaymentDatabase
+		publisher *mocks.MockPaymentEventPublisher
+	}
+
+	outboxes := []models.Outbox{
+		{ID: 1, AggregateID: 123, EventType: "payment.success", MessageKey: "order-123", Payload: `{"order_id":123,"status":"paid"}`},
+		{ID: 2, AggregateID: 123, EventType: "payment.refunded", MessageKey: "order-123", Payload: `{"order_id":123,"status":"refunded"}`},
+	}
+
+	tests := []struct {
+		name          string
+		mock          func(mockFields)
+		wantPublished int
+		wantError     error
+	}{
+		{
+			name: "given_error_GetPendingOutbox_then_it_should_return_error",
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPendingOutbox(context.Background(), RelayOutboxBatchSize).Return(nil, assert.AnError)
+			},
+			wantPublished: 0,
+			wantError:     assert.AnError,
+		},
+		{
+			name: "given_pending_outbox_then_it_should_publish_and_mark_published_in_order",
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPendingOutbox(context.Background(), RelayOutboxBatchSize).Return(outboxes, nil)
+				gomock.InOrder(
+					mf.publisher.EXPECT().PublishOutbox(context.Background(), outboxes[0]).Return(nil),
+					mf.database.EXPECT().MarkOutboxPublished(context.Background(), int64(1)).Return(nil),
+					mf.publisher.EXPECT().PublishOutbox(context.Background(), outboxes[1]).Return(nil),
+					mf.database.EXPECT().MarkOutboxPublished(context.Background(), int64(2)).Return(nil),
+				)
+			},
+			wantPublished: 2,
+			wantError:     nil,
+		},
+		{
+			name: "given_error_publish_then_it_should_stop_the_batch_and_keep_outbox_pending",
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPendingOutbox(context.Background(), RelayOutboxBatchSize).Return(outboxes, nil)
+				mf.publisher.EXPECT().PublishOutbox(context.Background(), outboxes[0]).Return(assert.AnError)
+				mf.database.EXPECT().UpdateOutboxFailed(context.Background(), int64(1), assert.AnError.Error()).Return(nil)
+			},
+			wantPublished: 0,
+			wantError:     assert.AnError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			log.SetupLogger()
+
+			mock := mockFields{
+				database:  mocks.NewMockPaymentDatabase(ctrl),
+				publisher: mocks.NewMockPaymentEventPublisher(ctrl),
+			}
+
+			service := &outboxService{
+				database:  mock.database,
+				publisher: mock.publisher,
+			}
+
+			test.mock(mock)
+			gotPublished, gotError := service.RelayOutbox(context.Background())
+			assert.Equal(t, test.wantPublished, gotPublished)
+			assert.Equal(t, test.wantError, gotError)
+		})
+	}
+}
+
+func Test_RelayOutbox_LeaseLost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log.SetupLogger()
+
+	database := mocks.NewMockPaymentDatabase(ctrl)
+	publisher := mocks.NewMockPaymentEventPublisher(ctrl)
+
+	outboxes := []models.Outbox{
+		{ID: 1, AggregateID: 123, EventType: "payment.success", MessageKey: "order-123"},
+		{ID: 2, AggregateID: 124, EventType: "payment.success", MessageKey: "order-124"},
+	}
+
+	// lease is lost while the first outbox is published, the second one is left for the next leader
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	database.EXPECT().GetPendingOutbox(ctx, RelayOutboxBatchSize).Return(outboxes, nil)
+	publisher.EXPECT().PublishOutbox(ctx, outboxes[0]).DoAndReturn(func(ctx context.Context, outbox models.Outbox) error {
+		cancel()
+		return nil
+	})
+	database.EXPECT().MarkOutboxPublished(ctx, int64(1)).Return(nil)
+
+	service := &outboxService{
+		database:  database,
+		publisher: publisher,
+	}
+
+	published, err := service.RelayOutbox(ctx)
+	assert.Equal(t, 1, published)
+	assert.ErrorIs(t, err, context.Canceled)
+}
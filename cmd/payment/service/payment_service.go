@@ -2,7 +2,8 @@ package service
 
 import (
 	"context"
-	"math"
+	"errors"
+	"fmt"
 	"payment/cmd/payment/repository"
 	"payment/infrastructure/constant"
 	"payment/infrastructure/log"
@@ -12,42 +13,46 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-const (
-	MaxTryPublishPayment = 3
-	RetryDelay           = 2 // seconds
-)
+// ErrPaymentLocked is returned when the payment is being processed by others, e.g. webhook racing the scheduler.
+var ErrPaymentLocked = errors.New("payment is being processed")
+
+// ErrPaymentAnomaly is returned when the request is recorded as payment anomaly, it is checked manually so the
+// same request must not be retried.
+var ErrPaymentAnomaly = errors.New("payment anomaly is recorded")
 
 // mockgen
 // mockgen -source=cmd/payment/service/payment_service.go -destination=cmd/test_mock/service/payment_service_mock.go -package=mocks
 
 type PaymentService interface {
-	ProcessPaymentSuccess(ctx context.Context, orderID int64) error
-	CheckPaymentAmountByOrderID(ctx context.Context, orderID int64) (float64, error)
+	ProcessPaymentSuccess(ctx context.Context, orderID int64, detail models.PaymentPaidDetail) error
+	ProcessPaymentExpired(ctx context.Context, orderID int64) error
+	ProcessPaymentFailed(ctx context.Context, orderID int64) error
+	CheckPaymentAmountByOrderID(ctx context.Context, orderID int64) (models.Money, error)
 	SavePaymentAnomaly(ctx context.Context, param models.PaymentAnomaly) error
 	SavePaymentRequest(ctx context.Context, param models.PaymentRequests) error
 	GetPaymentInfoByOrderID(ctx context.Context, orderID int64) (*models.Payment, error)
 }
 
 type paymentService struct {
-	database  repository.PaymentDatabase
-	publisher repository.PaymentEventPublisher
+	database repository.PaymentDatabase
+	redis    repository.PaymentRedis
 }
 
-func NewPaymentService(db repository.PaymentDatabase, publisher repository.PaymentEventPublisher) PaymentService {
+func NewPaymentService(db repository.PaymentDatabase, redis repository.PaymentRedis) PaymentService {
 	return &paymentService{
-		database:  db,
-		publisher: publisher,
+		database: db,
+		redis:    redis,
 	}
 }
 
-func (s *paymentService) CheckPaymentAmountByOrderID(ctx context.Context, orderID int64) (float64, error) {
+func (s *paymentService) CheckPaymentAmountByOrderID(ctx context.Context, orderID int64) (models.Money, error) {
 	amount, err := s.database.CheckPaymentAmountByOrderID(ctx, orderID)
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
 			"order_id": orderID,
 		}).Errorf("s.database.CheckPaymentAmountByOrderID() got error: %v", err)
 
-		return 0, err
+		return models.Money{}, err
 	}
 
 	return amount, nil
@@ -92,105 +97,210 @@ func (s *paymentService) GetPaymentInfoByOrderID(ctx context.Context, orderID in
 	return paymentInfo, nil
 }
 
-func (s *paymentService) ProcessPaymentSuccess(ctx context.Context, orderID int64) error {
-	// validate paid status
-	isAlreadyPaid, err := s.database.IsAlreadyPaid(ctx, orderID)
+// ProcessPaymentSuccess is guarded by per order lock, so payment success is only published once
+// when webhook and scheduler process the same payment at the same time.
+func (s *paymentService) ProcessPaymentSuccess(ctx context.Context, orderID int64, detail models.PaymentPaidDetail) error {
+	lockKey := fmt.Sprintf(constant.RedisKeyPaymentLock, orderID)
+	lockToken, acquired, err := s.redis.AcquireLock(ctx, lockKey, constant.PaymentLockTTL)
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
 			"order_id": orderID,
-		}).Errorf("s.database.IsAlreadyPaid() got error: %v", err)
+		}).Errorf("s.redis.AcquireLock() got error: %v", err)
 
 		return err
 	}
 
-	if isAlreadyPaid {
+	if !acquired {
 		log.Logger.WithFields(logrus.Fields{
 			"order_id": orderID,
-		}).Infof("Payment %d already paid.", orderID)
+		}).Warn("ProcessPaymentSuccess => payment is locked by other process")
 
-		return nil
+		return ErrPaymentLocked
 	}
 
-	// public event to kafka
-	err = retryPublishPayment(MaxTryPublishPayment, func() error {
-		errLogAudit := s.database.InsertAuditLog(ctx, models.PaymentAuditLog{
-			OrderID:    orderID,
-			Event:      "PublishPaymentSuccess",
-			Actor:      "payment_service",
-			CreateTime: time.Now(),
-		})
-		if errLogAudit != nil {
+	defer func() {
+		// do not use ctx, it may be already canceled
+		errRelease := s.redis.ReleaseLock(context.Background(), lockKey, lockToken)
+		if errRelease != nil {
 			log.Logger.WithFields(logrus.Fields{
 				"order_id": orderID,
-			}).Errorf("s.database.InsertAuditLog() got error: %v", errLogAudit)
+			}).Errorf("s.redis.ReleaseLock() got error: %v", errRelease)
 		}
+	}()
 
-		return s.publisher.PublishPaymentSuccess(ctx, orderID)
-	})
+	return s.processPaymentSuccess(ctx, orderID, detail)
+}
+
+func (s *paymentService) processPaymentSuccess(ctx context.Context, orderID int64, detail models.PaymentPaidDetail) error {
+	// validate paid status
+	paymentInfo, err := s.database.GetPaymentInfoByOrderID(ctx, orderID)
 	if err != nil {
-		// store data to failed payment event
-		failedEventParam := models.FailedEvents{
-			OrderID:    orderID,
-			FailedType: constant.FailedPublishEventPaymentSuccess,
-			Status:     constant.FailedPublishEventStatusNeedToCheck,
-			Notes:      err.Error(),
-			CreateTime: time.Now(),
-		}
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+		}).Errorf("s.database.GetPaymentInfoByOrderID() got error: %v", err)
 
-		// its also called dead letter queue
-		errSaveFailedPublish := s.database.SaveFailedPublishEvent(ctx, failedEventParam)
-		if errSaveFailedPublish != nil {
-			log.Logger.WithFields(logrus.Fields{
-				"failedEventParam": failedEventParam,
-			}).WithError(errSaveFailedPublish).Error("s.database.SaveFailedPublishEvent() got error")
+		return err
+	}
+
+	if paymentInfo.Status == models.PaymentStatusPaid {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+		}).Infof("Payment %d already paid.", orderID)
 
-			return errSaveFailedPublish
+		return nil
+	}
+
+	// do not publish payment success for payment which can not be paid anymore, e.g. already expired
+	err = paymentInfo.Status.ValidateTransition(models.PaymentStatusPaid)
+	if err != nil {
+		paymentAnomaly := models.PaymentAnomaly{
+			OrderID:     orderID,
+			ExternalID:  paymentInfo.ExternalID,
+			AnomalyType: constant.AnomalyTypeInvalidStatusTransition,
+			Notes:       err.Error(),
+			Status:      constant.PaymentAnomalyStatusNeedToCheck,
+			CreateTime:  time.Now(),
 		}
 
 		log.Logger.WithFields(logrus.Fields{
 			"order_id": orderID,
-		}).Errorf("s.publisher.PublishPaymentSuccess() got error: %v", err)
+		}).Warnf("ProcessPaymentSuccess => %v", err)
+
+		errSaveAnomaly := s.database.SavePaymentAnomaly(ctx, paymentAnomaly)
+		if errSaveAnomaly != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"paymentAnomaly": paymentAnomaly,
+			}).Errorf("s.database.SavePaymentAnomaly() got error: %v", errSaveAnomaly)
+
+			return err
+		}
+
+		return fmt.Errorf("%w: %w", ErrPaymentAnomaly, err)
+	}
 
+	// e.g. paid status found by scheduler or retried from anomaly
+	if detail.PaidAt.IsZero() {
+		detail.PaidAt = time.Now()
+	}
+
+	outbox, err := newOutbox(ctx, constant.KafkaTopicPaymentSuccess, models.PaymentSuccessSchemaVersion, orderID, models.NewPaymentSuccessEvent(*paymentInfo, detail))
+	if err != nil {
 		return err
 	}
 
-	// update status to DB
-	err = s.database.MarkPaid(ctx, orderID)
+	// payment success event is published by outbox relay, so status and event are always in sync
+	err = s.database.WithTransaction(ctx, func(tx repository.PaymentDatabase) error {
+		if err := tx.MarkPaid(ctx, orderID, detail); err != nil {
+			return err
+		}
+
+		return tx.SaveOutbox(ctx, outbox)
+	})
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
 			"order_id": orderID,
-		}).Errorf("s.database.MarkPaid got error: %v", err)
+		}).Errorf("mark paid and save outbox got error: %v", err)
 
 		return err
-	} else {
-		errLogAudit := s.database.InsertAuditLog(ctx, models.PaymentAuditLog{
-			OrderID:    orderID,
-			Event:      "MarkPaid",
-			Actor:      "payment_service",
-			CreateTime: time.Now(),
-		})
-		if errLogAudit != nil {
-			log.Logger.WithFields(logrus.Fields{
-				"order_id": orderID,
-			}).WithError(errLogAudit).Errorf("s.database.InsertAuditLog() got error: %v", errLogAudit)
-		}
+	}
+
+	errLogAudit := s.database.InsertAuditLog(ctx, models.PaymentAuditLog{
+		OrderID:    orderID,
+		UserID:     paymentInfo.UserID,
+		PaymentID:  paymentInfo.ID,
+		ExternalID: paymentInfo.ExternalID,
+		Event:      "MarkPaid",
+		Actor:      "payment_service",
+		CreateTime: time.Now(),
+	})
+	if errLogAudit != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+		}).Errorf("s.database.InsertAuditLog() got error: %v", errLogAudit)
 	}
 
 	return nil
 }
 
-func retryPublishPayment(max int, fn func() error) error {
-	var err error
-	for i := 0; i < max; i++ {
-		err = fn()
-		if err == nil {
-			return nil
+func (s *paymentService) ProcessPaymentExpired(ctx context.Context, orderID int64) error {
+	return s.processPaymentClosed(ctx, orderID, models.PaymentStatusExpired)
+}
+
+func (s *paymentService) ProcessPaymentFailed(ctx context.Context, orderID int64) error {
+	return s.processPaymentClosed(ctx, orderID, models.PaymentStatusFailed)
+}
+
+// processPaymentClosed mark unpaid payment as expired or failed, then publish the event
+// so order service can release the reserved stock.
+func (s *paymentService) processPaymentClosed(ctx context.Context, orderID int64, status models.PaymentStatus) error {
+	paymentInfo, err := s.database.GetPaymentInfoByOrderID(ctx, orderID)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+			"status":   status,
+		}).Errorf("s.database.GetPaymentInfoByOrderID() got error: %v", err)
+
+		return err
+	}
+
+	// already processed, e.g. by webhook and scheduler
+	if paymentInfo.Status == status {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+		}).Infof("Payment %d already %s.", orderID, status)
+
+		return nil
+	}
+
+	var (
+		markFn        = repository.PaymentDatabase.MarkExpired
+		eventType     = constant.KafkaTopicPaymentExpired
+		schemaVersion = models.PaymentExpiredSchemaVersion
+		auditEvent    = "MarkExpired"
+	)
+	if status == models.PaymentStatusFailed {
+		markFn = repository.PaymentDatabase.MarkFailed
+		eventType = constant.KafkaTopicPaymentFailed
+		schemaVersion = models.PaymentFailedSchemaVersion
+		auditEvent = "MarkFailed"
+	}
+
+	outbox, err := newOutbox(ctx, eventType, schemaVersion, orderID, models.NewPaymentClosedEvent(*paymentInfo, status))
+	if err != nil {
+		return err
+	}
+
+	// invalid transition is stored as anomaly by repository
+	err = s.database.WithTransaction(ctx, func(tx repository.PaymentDatabase) error {
+		if err := markFn(tx, ctx, orderID); err != nil {
+			return err
 		}
 
-		wait := time.Duration(math.Pow(2, float64(i))) * time.Second
-		log.Logger.Printf("Retrying to publish payment, attempt %d/%d, waiting %s: %v", i+1, max, wait, err)
-		time.Sleep(wait)
+		return tx.SaveOutbox(ctx, outbox)
+	})
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+			"status":   status,
+		}).Errorf("mark payment %s and save outbox got error: %v", status, err)
+
+		return err
+	}
+
+	errLogAudit := s.database.InsertAuditLog(ctx, models.PaymentAuditLog{
+		OrderID:    orderID,
+		UserID:     paymentInfo.UserID,
+		PaymentID:  paymentInfo.ID,
+		ExternalID: paymentInfo.ExternalID,
+		Event:      auditEvent,
+		Actor:      "payment_service",
+		CreateTime: time.Now(),
+	})
+	if errLogAudit != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+		}).Errorf("s.database.InsertAuditLog() got error: %v", errLogAudit)
 	}
 
-	return err
+	return nil
 }
@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"payment/cmd/payment/repository"
 	mocks "payment/cmd/test_mock"
 	mocksRepository "payment/cmd/test_mock"
+	"payment/infrastructure/constant"
 	"payment/infrastructure/log"
+	"payment/models"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -17,18 +21,16 @@ func Test_CheckPaymentAmountByOrderID_Success(t *testing.T) {
 
 	// mockPaymentService := mocksService.NewMockPaymentService(ctrl)
 	mockRepositoryDatabase := mocksRepository.NewMockPaymentDatabase(ctrl)
-	mockRepositoryPublisher := mocksRepository.NewMockPaymentEventPublisher(ctrl)
 
 	// expected result
-	var expecetedAmount float64 = 10000.0
+	expecetedAmount := models.NewMoney(10000, models.CurrencyIDR)
 
 	// mock the Repository because payment service depends on it
 	mockRepositoryDatabase.EXPECT().CheckPaymentAmountByOrderID(context.Background(), int64(1)).Return(expecetedAmount, nil)
 
 	// actual result
 	paymentService := paymentService{
-		database:  mockRepositoryDatabase,
-		publisher: mockRepositoryPublisher,
+		database: mockRepositoryDatabase,
 	}
 	actualAmount, err := paymentService.CheckPaymentAmountByOrderID(context.Background(), int64(1))
 	assert.Equal(t, actualAmount, expecetedAmount)
@@ -43,18 +45,16 @@ func Test_CheckPaymentAmountByOrderID_Error(t *testing.T) {
 	log.SetupLogger()
 
 	mockRepositoryDatabase := mocksRepository.NewMockPaymentDatabase(ctrl)
-	mockRepositoryPublisher := mocksRepository.NewMockPaymentEventPublisher(ctrl)
 
 	// expected result
-	var expecetedAmount float64 = 0.0
+	expecetedAmount := models.Money{}
 
 	// mock the Repository because payment service depends on it
 	mockRepositoryDatabase.EXPECT().CheckPaymentAmountByOrderID(context.Background(), int64(1)).Return(expecetedAmount, assert.AnError)
 
 	// actual result
 	paymentService := paymentService{
-		database:  mockRepositoryDatabase,
-		publisher: mockRepositoryPublisher,
+		database: mockRepositoryDatabase,
 	}
 	actualAmount, err := paymentService.CheckPaymentAmountByOrderID(context.Background(), int64(1))
 	assert.Equal(t, actualAmount, expecetedAmount)
@@ -63,8 +63,7 @@ func Test_CheckPaymentAmountByOrderID_Error(t *testing.T) {
 
 func Test_CheckPaymentAmountByOrderID(t *testing.T) {
 	type mockFields struct {
-		database  *mocks.MockPaymentDatabase
-		publisher *mocks.MockPaymentEventPublisher
+		database *mocks.MockPaymentDatabase
 	}
 
 	type args struct {
@@ -76,15 +75,15 @@ func Test_CheckPaymentAmountByOrderID(t *testing.T) {
 	log.SetupLogger()
 
 	// expeceted result
-	expectedAmountSuccess := float64(10000)
-	expectedAmountError := float64(0)
+	expectedAmountSuccess := models.NewMoney(10000, models.CurrencyIDR)
+	expectedAmountError := models.Money{}
 
 	// list of test cases
 	tests := []struct {
 		name       string
 		args       args
 		mock       func(fields mockFields)
-		wantResult float64
+		wantResult models.Money
 		wantError  error
 	}{
 		{
@@ -121,8 +120,7 @@ func Test_CheckPaymentAmountByOrderID(t *testing.T) {
 
 			// create mock objects
 			mockFields := mockFields{
-				database:  mocks.NewMockPaymentDatabase(ctrl),
-				publisher: mocks.NewMockPaymentEventPublisher(ctrl),
+				database: mocks.NewMockPaymentDatabase(ctrl),
 			}
 
 			// apply the mock expectations
@@ -130,8 +128,7 @@ func Test_CheckPaymentAmountByOrderID(t *testing.T) {
 
 			// create the payment service with mocked dependencies
 			paymentService := paymentService{
-				database:  mockFields.database,
-				publisher: mockFields.publisher,
+				database: mockFields.database,
 			}
 
 			// call the method under test
@@ -143,3 +140,153 @@ func Test_CheckPaymentAmountByOrderID(t *testing.T) {
 		})
 	}
 }
+
+func Test_ProcessPaymentSuccess_InvalidStatusTransition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// log logger
+	log.SetupLogger()
+
+	mockRepositoryDatabase := mocksRepository.NewMockPaymentDatabase(ctrl)
+	mockRepositoryRedis := mocksRepository.NewMockPaymentRedis(ctrl)
+
+	mockRepositoryRedis.EXPECT().AcquireLock(context.Background(), "payment:lock:order:1", constant.PaymentLockTTL).Return("token", true, nil)
+	mockRepositoryRedis.EXPECT().ReleaseLock(context.Background(), "payment:lock:order:1", "token").Return(nil)
+
+	// expired payment must not be paid and must not publish payment success
+	mockRepositoryDatabase.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(1)).Return(&models.Payment{
+		OrderID:    1,
+		ExternalID: "order-1",
+		Status:     models.PaymentStatusExpired,
+	}, nil)
+	mockRepositoryDatabase.EXPECT().SavePaymentAnomaly(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, param models.PaymentAnomaly) error {
+		assert.Equal(t, constant.AnomalyTypeInvalidStatusTransition, param.AnomalyType)
+		assert.Equal(t, constant.PaymentAnomalyStatusNeedToCheck, param.Status)
+
+		return nil
+	})
+
+	paymentService := paymentService{
+		database: mockRepositoryDatabase,
+		redis:    mockRepositoryRedis,
+	}
+	err := paymentService.ProcessPaymentSuccess(context.Background(), int64(1), models.PaymentPaidDetail{})
+	assert.ErrorIs(t, err, models.ErrInvalidPaymentStatusTransition)
+	assert.ErrorIs(t, err, ErrPaymentAnomaly)
+}
+
+func Test_ProcessPaymentSuccess_Locked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// log logger
+	log.SetupLogger()
+
+	mockRepositoryDatabase := mocksRepository.NewMockPaymentDatabase(ctrl)
+	mockRepositoryRedis := mocksRepository.NewMockPaymentRedis(ctrl)
+
+	// payment is processed by other process, it must not be read nor published
+	mockRepositoryRedis.EXPECT().AcquireLock(context.Background(), "payment:lock:order:1", constant.PaymentLockTTL).Return("", false, nil)
+
+	paymentService := paymentService{
+		database: mockRepositoryDatabase,
+		redis:    mockRepositoryRedis,
+	}
+	err := paymentService.ProcessPaymentSuccess(context.Background(), int64(1), models.PaymentPaidDetail{})
+	assert.ErrorIs(t, err, ErrPaymentLocked)
+}
+
+func Test_ProcessPaymentExpired(t *testing.T) {
+	type mockFields struct {
+		database *mocks.MockPaymentDatabase
+	}
+
+	// log logger
+	log.SetupLogger()
+
+	tests := []struct {
+		name      string
+		orderID   int64
+		mock      func(fields mockFields)
+		wantError error
+	}{
+		{
+			name:    "given_already_expired_payment_then_it_should_not_publish_again",
+			orderID: 1,
+			mock: func(fields mockFields) {
+				fields.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(1)).Return(&models.Payment{
+					OrderID: 1,
+					Status:  models.PaymentStatusExpired,
+				}, nil)
+			},
+			wantError: nil,
+		},
+		{
+			name:    "given_paid_payment_then_it_should_return_error_from_MarkExpired",
+			orderID: 1,
+			mock: func(fields mockFields) {
+				fields.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(1)).Return(&models.Payment{
+					OrderID: 1,
+					Status:  models.PaymentStatusPaid,
+				}, nil)
+				fields.database.EXPECT().WithTransaction(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(tx repository.PaymentDatabase) error) error {
+					return fn(fields.database)
+				})
+				fields.database.EXPECT().MarkExpired(context.Background(), int64(1)).Return(models.ErrInvalidPaymentStatusTransition)
+			},
+			wantError: models.ErrInvalidPaymentStatusTransition,
+		},
+		{
+			name:    "given_pending_payment_then_it_should_mark_expired_and_save_payment_expired_outbox",
+			orderID: 1,
+			mock: func(fields mockFields) {
+				fields.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(1)).Return(&models.Payment{
+					OrderID:    1,
+					UserID:     2,
+					ExternalID: "order-1",
+					Amount:     models.NewMoney(10000, models.CurrencyIDR),
+					Status:     models.PaymentStatusPending,
+				}, nil)
+				fields.database.EXPECT().WithTransaction(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(tx repository.PaymentDatabase) error) error {
+					return fn(fields.database)
+				})
+				fields.database.EXPECT().MarkExpired(context.Background(), int64(1)).Return(nil)
+				fields.database.EXPECT().SaveOutbox(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, param *models.Outbox) error {
+					assert.Equal(t, constant.KafkaTopicPaymentExpired, param.EventType)
+					var envelope models.EventEnvelope[models.PaymentClosedEvent]
+					assert.NoError(t, json.Unmarshal([]byte(param.Payload), &envelope))
+					assert.Equal(t, param.EventID, envelope.EventID)
+					assert.Equal(t, constant.KafkaTopicPaymentExpired, envelope.EventType)
+					assert.Equal(t, models.PaymentExpiredSchemaVersion, envelope.SchemaVersion)
+					assert.Equal(t, models.PaymentClosedEvent{OrderID: 1, UserID: 2, ExternalID: "order-1", Amount: "10000", Currency: models.CurrencyIDR, Status: "expired"}, envelope.Payload)
+					assert.Equal(t, constant.OutboxStatusPending, param.Status)
+
+					return nil
+				})
+				fields.database.EXPECT().InsertAuditLog(context.Background(), gomock.Any()).Return(nil)
+			},
+			wantError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFields := mockFields{
+				database: mocks.NewMockPaymentDatabase(ctrl),
+			}
+
+			test.mock(mockFields)
+
+			paymentService := paymentService{
+				database: mockFields.database,
+			}
+
+			err := paymentService.ProcessPaymentExpired(context.Background(), test.orderID)
+			assert.Equal(t, test.wantError, err)
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment/cmd/payment/repository"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ReconciliationService interface {
+	Reconcile(ctx context.Context, periodStart time.Time, periodEnd time.Time, triggeredBy string) (*models.ReconciliationRun, error)
+	GetReconciliationRuns(ctx context.Context, filter models.ReconciliationRunFilter) ([]models.ReconciliationRun, error)
+	GetReconciliationDiscrepancies(ctx context.Context, runID int64) ([]models.ReconciliationDiscrepancy, error)
+}
+
+type reconciliationService struct {
+	database repository.PaymentDatabase
+	xendit   repository.XenditClient
+}
+
+func NewReconciliationService(database repository.PaymentDatabase, xendit repository.XenditClient) ReconciliationService {
+	return &reconciliationService{
+		database: database,
+		xendit:   xendit,
+	}
+}
+
+// Reconcile compare xendit invoices and payments created in [periodStart, periodEnd), then store the run
+// with its discrepancies. Every call create a new run, so a period can be reconciled again after it is fixed.
+func (s *reconciliationService) Reconcile(ctx context.Context, periodStart time.Time, periodEnd time.Time, triggeredBy string) (*models.ReconciliationRun, error) {
+	invoices, err := s.listInvoices(ctx, periodStart, periodEnd)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"period_start": periodStart,
+			"period_end":   periodEnd,
+		}).Errorf("s.listInvoices() got error: %v", err)
+
+		return nil, err
+	}
+
+	payments, err := s.database.GetPaymentsByCreateTime(ctx, periodStart, periodEnd)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"period_start": periodStart,
+			"period_end":   periodEnd,
+		}).Errorf("s.database.GetPaymentsByCreateTime() got error: %v", err)
+
+		return nil, err
+	}
+
+	// payment through other gateway has no xendit invoice
+	payments = xenditPayments(payments)
+
+	paymentByExternalID := make(map[string]models.Payment, len(payments))
+	for _, payment := range payments {
+		paymentByExternalID[payment.ExternalID] = payment
+	}
+
+	// invoice is created at xendit after the payment is stored, so a payment created right before the period
+	// can own an invoice created inside the period
+	var unmatchedExternalIDs []string
+	for _, invoice := range invoices {
+		if _, ok := paymentByExternalID[invoice.ExternalID]; !ok {
+			unmatchedExternalIDs = append(unmatchedExternalIDs, invoice.ExternalID)
+		}
+	}
+
+	if len(unmatchedExternalIDs) > 0 {
+		outsidePayments, err := s.database.GetPaymentsByExternalIDs(ctx, unmatchedExternalIDs)
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"external_ids": unmatchedExternalIDs,
+			}).Errorf("s.database.GetPaymentsByExternalIDs() got error: %v", err)
+
+			return nil, err
+		}
+
+		for _, payment := range outsidePayments {
+			paymentByExternalID[payment.ExternalID] = payment
+		}
+	}
+
+	var discrepancies []models.ReconciliationDiscrepancy
+	invoiceByExternalID := make(map[string]models.XenditInvoiceResponse, len(invoices))
+	for _, invoice := range invoices {
+		invoiceByExternalID[invoice.ExternalID] = invoice
+
+		payment, ok := paymentByExternalID[invoice.ExternalID]
+		if !ok {
+			discrepancies = append(discrepancies, newDiscrepancy(constant.ReconciliationUnknownInvoice, nil, &invoice, "invoice has no payment"))
+			continue
+		}
+
+		discrepancies = append(discrepancies, comparePaymentInvoice(payment, invoice)...)
+	}
+
+	// paid payment must have a paid invoice, invoice which is not listed is checked one by one
+	for _, payment := range payments {
+		if _, ok := invoiceByExternalID[payment.ExternalID]; ok || !isLocalPaid(payment.Status) {
+			continue
+		}
+
+		invoice, err := s.xendit.GetInvoiceByExternalID(ctx, payment.ExternalID)
+		if errors.Is(err, repository.ErrXenditInvoiceNotFound) {
+			discrepancies = append(discrepancies, newDiscrepancy(constant.ReconciliationLocalPaidXenditUnpaid, &payment, nil, "invoice not found at xendit"))
+			continue
+		}
+
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"external_id": payment.ExternalID,
+			}).Errorf("s.xendit.GetInvoiceByExternalID() got error: %v", err)
+
+			return nil, err
+		}
+
+		discrepancies = append(discrepancies, comparePaymentInvoice(payment, invoice)...)
+	}
+
+	run := &models.ReconciliationRun{
+		PeriodStart:        periodStart,
+		PeriodEnd:          periodEnd,
+		XenditInvoiceCount: len(invoices),
+		LocalPaymentCount:  len(payments),
+		DiscrepancyCount:   len(discrepancies),
+		TriggeredBy:        triggeredBy,
+		CreateTime:         time.Now(),
+	}
+
+	err = s.database.WithTransaction(ctx, func(tx repository.PaymentDatabase) error {
+		if err := tx.SaveReconciliationRun(ctx, run); err != nil {
+			return err
+		}
+
+		for i := range discrepancies {
+			discrepancies[i].RunID = run.ID
+			discrepancies[i].CreateTime = run.CreateTime
+		}
+
+		return tx.SaveReconciliationDiscrepancies(ctx, discrepancies)
+	})
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"period_start": periodStart,
+			"period_end":   periodEnd,
+		}).Errorf("s.database.WithTransaction() got error: %v", err)
+
+		return nil, err
+	}
+
+	log.Logger.WithFields(logrus.Fields{
+		"run_id":         run.ID,
+		"period_start":   periodStart,
+		"period_end":     periodEnd,
+		"xendit_invoice": run.XenditInvoiceCount,
+		"local_payment":  run.LocalPaymentCount,
+		"discrepancy":    run.DiscrepancyCount,
+	}).Info("Reconciliation is done")
+
+	return run, nil
+}
+
+// listInvoices fetch every page of xendit invoices created in the period.
+func (s *reconciliationService) listInvoices(ctx context.Context, periodStart time.Time, periodEnd time.Time) ([]models.XenditInvoiceResponse, error) {
+	var invoices []models.XenditInvoiceResponse
+
+	param := models.XenditListInvoicesRequest{
+		CreatedAfter:  periodStart,
+		CreatedBefore: periodEnd,
+		Limit:         constant.XenditListInvoicesLimit,
+	}
+	for {
+		page, err := s.xendit.ListInvoices(ctx, param)
+		if err != nil {
+			return nil, err
+		}
+
+		invoices = append(invoices, page...)
+		if len(page) < param.Limit {
+			return invoices, nil
+		}
+
+		param.LastInvoiceID = page[len(page)-1].ID
+	}
+}
+
+func (s *reconciliationService) GetReconciliationRuns(ctx context.Context, filter models.ReconciliationRunFilter) ([]models.ReconciliationRun, error) {
+	runs, err := s.database.GetReconciliationRuns(ctx, filter)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("s.database.GetReconciliationRuns() got error: %v", err)
+
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func (s *reconciliationService) GetReconciliationDiscrepancies(ctx context.Context, runID int64) ([]models.ReconciliationDiscrepancy, error) {
+	discrepancies, err := s.database.GetReconciliationDiscrepancies(ctx, runID)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"run_id": runID,
+		}).Errorf("s.database.GetReconciliationDiscrepancies() got error: %v", err)
+
+		return nil, err
+	}
+
+	return discrepancies, nil
+}
+
+// comparePaymentInvoice return every discrepancy between the payment and its invoice, status and amount
+// are checked separately so both are reported.
+func comparePaymentInvoice(payment models.Payment, invoice models.XenditInvoiceResponse) []models.ReconciliationDiscrepancy {
+	var discrepancies []models.ReconciliationDiscrepancy
+
+	localPaid := isLocalPaid(payment.Status)
+	xenditPaid := isXenditPaid(invoice.Status)
+
+	switch {
+	case xenditPaid && !localPaid:
+		discrepancies = append(discrepancies, newDiscrepancy(constant.ReconciliationXenditPaidLocalUnpaid, &payment, &invoice, ""))
+	case localPaid && !xenditPaid:
+		discrepancies = append(discrepancies, newDiscrepancy(constant.ReconciliationLocalPaidXenditUnpaid, &payment, &invoice, ""))
+	}
+
+	invoiceAmount, err := invoice.Total()
+	if err != nil {
+		notes := fmt.Sprintf("invalid invoice amount: %v", err)
+		return append(discrepancies, newDiscrepancy(constant.ReconciliationAmountMismatch, &payment, &invoice, notes))
+	}
+
+	difference, err := invoiceAmount.Sub(payment.Amount)
+	switch {
+	case err != nil:
+		notes := fmt.Sprintf("currency %s, invoice currency %s", payment.Amount.Currency, invoiceAmount.Currency)
+		discrepancies = append(discrepancies, newDiscrepancy(constant.ReconciliationAmountMismatch, &payment, &invoice, notes))
+	case !difference.IsZero():
+		notes := fmt.Sprintf("difference %s", difference.Decimal())
+		discrepancies = append(discrepancies, newDiscrepancy(constant.ReconciliationAmountMismatch, &payment, &invoice, notes))
+	}
+
+	return discrepancies
+}
+
+func newDiscrepancy(discrepancyType string, payment *models.Payment, invoice *models.XenditInvoiceResponse, notes string) models.ReconciliationDiscrepancy {
+	discrepancy := models.ReconciliationDiscrepancy{
+		DiscrepancyType: discrepancyType,
+		Notes:           notes,
+	}
+
+	if payment != nil {
+		discrepancy.OrderID = payment.OrderID
+		discrepancy.ExternalID = payment.ExternalID
+		discrepancy.LocalStatus = string(payment.Status)
+		discrepancy.LocalAmount = payment.Amount
+	}
+
+	if invoice != nil {
+		discrepancy.ExternalID = invoice.ExternalID
+		discrepancy.XenditInvoiceID = invoice.ID
+		discrepancy.XenditStatus = invoice.Status
+		// invalid amount is kept zero, the discrepancy notes tell why
+		discrepancy.XenditAmount, _ = invoice.Total()
+	}
+
+	return discrepancy
+}
+
+func xenditPayments(payments []models.Payment) []models.Payment {
+	var result []models.Payment
+	for _, payment := range payments {
+		// payment created before provider is stored is always xendit
+		if payment.Provider == "" || payment.Provider == constant.GatewayProviderXendit {
+			result = append(result, payment)
+		}
+	}
+
+	return result
+}
+
+// refunded payment was paid, refund does not change the invoice status at xendit
+func isLocalPaid(status models.PaymentStatus) bool {
+	return status == models.PaymentStatusPaid || status == models.PaymentStatusRefunded
+}
+
+func isXenditPaid(status string) bool {
+	return status == constant.XenditInvoiceStatusPaid || status == constant.XenditInvoiceStatusSettled
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"payment/cmd/payment/repository"
+	mocks "payment/cmd/test_mock"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Reconcile(t *testing.T) {
+	type mockFields struct {
+		database *mocks.MockPaymentDatabase
+		xendit   *mocks.MockXenditClient
+	}
+
+	periodStart := time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local)
+	periodEnd := periodStart.AddDate(0, 0, 1)
+	listRequest := models.XenditListInvoicesRequest{
+		CreatedAfter:  periodStart,
+		CreatedBefore: periodEnd,
+		Limit:         constant.XenditListInvoicesLimit,
+	}
+
+	payment := func(orderID int64, status models.PaymentStatus, amount int64) models.Payment {
+		return models.Payment{OrderID: orderID, ExternalID: fmt.Sprintf("order-%d", orderID), Status: status, Amount: models.NewMoney(amount, models.CurrencyIDR)}
+	}
+	// xendit send IDR amount with decimals in some payloads, it is not a mismatch
+	invoice := func(orderID int64, status string, amount int64) models.XenditInvoiceResponse {
+		return models.XenditInvoiceResponse{ID: fmt.Sprintf("inv-%d", orderID), ExternalID: fmt.Sprintf("order-%d", orderID), Status: status, Amount: json.Number(fmt.Sprintf("%d.00", amount)), Currency: models.CurrencyIDR}
+	}
+	expectSave := func(mf mockFields, saved *[]models.ReconciliationDiscrepancy) {
+		mf.database.EXPECT().WithTransaction(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(tx repository.PaymentDatabase) error) error {
+			return fn(mf.database)
+		})
+		mf.database.EXPECT().SaveReconciliationRun(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, param *models.ReconciliationRun) error {
+			param.ID = 7
+			return nil
+		})
+		mf.database.EXPECT().SaveReconciliationDiscrepancies(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, discrepancies []models.ReconciliationDiscrepancy) error {
+			*saved = discrepancies
+			return nil
+		})
+	}
+
+	tests := []struct {
+		name      string
+		mock      func(mf mockFields, saved *[]models.ReconciliationDiscrepancy)
+		wantRun   *models.ReconciliationRun
+		wantTypes []string
+		wantError error
+	}{
+		{
+			name: "given_error_ListInvoices_then_it_should_return_error",
+			mock: func(mf mockFields, saved *[]models.ReconciliationDiscrepancy) {
+				mf.xendit.EXPECT().ListInvoices(context.Background(), listRequest).Return(nil, assert.AnError)
+			},
+			wantError: assert.AnError,
+		},
+		{
+			name: "given_matching_invoices_then_it_should_save_run_without_discrepancy",
+			mock: func(mf mockFields, saved *[]models.ReconciliationDiscrepancy) {
+				mf.xendit.EXPECT().ListInvoices(context.Background(), listRequest).Return([]models.XenditInvoiceResponse{
+					invoice(1, constant.XenditInvoiceStatusPaid, 1000),
+					invoice(2, constant.XenditInvoiceStatusSettled, 2000),
+					invoice(3, constant.XenditInvoiceStatusExpired, 3000),
+				}, nil)
+				mf.database.EXPECT().GetPaymentsByCreateTime(context.Background(), periodStart, periodEnd).Return([]models.Payment{
+					payment(1, models.PaymentStatusPaid, 1000),
+					payment(2, models.PaymentStatusRefunded, 2000),
+					payment(3, models.PaymentStatusExpired, 3000),
+					// pending payment without listed invoice is left to the pending invoice check
+					payment(4, models.PaymentStatusPending, 4000),
+				}, nil)
+				expectSave(mf, saved)
+			},
+			wantRun:   &models.ReconciliationRun{ID: 7, XenditInvoiceCount: 3, LocalPaymentCount: 4},
+			wantTypes: nil,
+		},
+		{
+			name: "given_discrepancies_then_it_should_save_every_type",
+			mock: func(mf mockFields, saved *[]models.ReconciliationDiscrepancy) {
+				mf.xendit.EXPECT().ListInvoices(context.Background(), listRequest).Return([]models.XenditInvoiceResponse{
+					invoice(1, constant.XenditInvoiceStatusPaid, 1000),
+					invoice(2, constant.XenditInvoiceStatusPending, 2000),
+					invoice(3, constant.XenditInvoiceStatusPaid, 3500),
+					invoice(9, constant.XenditInvoiceStatusPaid, 9000),
+				}, nil)
+				mf.database.EXPECT().GetPaymentsByCreateTime(context.Background(), periodStart, periodEnd).Return([]models.Payment{
+					payment(1, models.PaymentStatusExpired, 1000),
+					payment(2, models.PaymentStatusPaid, 2000),
+					payment(3, models.PaymentStatusPaid, 3000),
+					payment(4, models.PaymentStatusPaid, 4000),
+				}, nil)
+				mf.database.EXPECT().GetPaymentsByExternalIDs(context.Background(), []string{"order-9"}).Return(nil, nil)
+				mf.xendit.EXPECT().GetInvoiceByExternalID(context.Background(), "order-4").Return(models.XenditInvoiceResponse{}, repository.ErrXenditInvoiceNotFound)
+				expectSave(mf, saved)
+			},
+			wantRun: &models.ReconciliationRun{ID: 7, XenditInvoiceCount: 4, LocalPaymentCount: 4, DiscrepancyCount: 5},
+			wantTypes: []string{
+				constant.ReconciliationXenditPaidLocalUnpaid,
+				constant.ReconciliationLocalPaidXenditUnpaid,
+				constant.ReconciliationAmountMismatch,
+				constant.ReconciliationUnknownInvoice,
+				constant.ReconciliationLocalPaidXenditUnpaid,
+			},
+		},
+		{
+			name: "given_invoice_of_payment_created_before_period_then_it_should_match_by_external_id",
+			mock: func(mf mockFields, saved *[]models.ReconciliationDiscrepancy) {
+				page := make([]models.XenditInvoiceResponse, constant.XenditListInvoicesLimit)
+				for i := range page {
+					page[i] = invoice(int64(i+1), constant.XenditInvoiceStatusPaid, 1000)
+				}
+
+				nextRequest := listRequest
+				nextRequest.LastInvoiceID = page[len(page)-1].ID
+
+				var payments []models.Payment
+				for i := 1; i < constant.XenditListInvoicesLimit; i++ {
+					payments = append(payments, payment(int64(i+1), models.PaymentStatusPaid, 1000))
+				}
+
+				mf.xendit.EXPECT().ListInvoices(context.Background(), listRequest).Return(page, nil)
+				mf.xendit.EXPECT().ListInvoices(context.Background(), nextRequest).Return(nil, nil)
+				mf.database.EXPECT().GetPaymentsByCreateTime(context.Background(), periodStart, periodEnd).Return(payments, nil)
+				mf.database.EXPECT().GetPaymentsByExternalIDs(context.Background(), []string{"order-1"}).Return([]models.Payment{
+					payment(1, models.PaymentStatusPending, 1000),
+				}, nil)
+				expectSave(mf, saved)
+			},
+			wantRun:   &models.ReconciliationRun{ID: 7, XenditInvoiceCount: constant.XenditListInvoicesLimit, LocalPaymentCount: constant.XenditListInvoicesLimit - 1, DiscrepancyCount: 1},
+			wantTypes: []string{constant.ReconciliationXenditPaidLocalUnpaid},
+		},
+		{
+			name: "given_error_GetInvoiceByExternalID_then_it_should_return_error",
+			mock: func(mf mockFields, saved *[]models.ReconciliationDiscrepancy) {
+				mf.xendit.EXPECT().ListInvoices(context.Background(), listRequest).Return(nil, nil)
+				mf.database.EXPECT().GetPaymentsByCreateTime(context.Background(), periodStart, periodEnd).Return([]models.Payment{
+					payment(1, models.PaymentStatusPaid, 1000),
+				}, nil)
+				mf.xendit.EXPECT().GetInvoiceByExternalID(context.Background(), "order-1").Return(models.XenditInvoiceResponse{}, assert.AnError)
+			},
+			wantError: assert.AnError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			log.SetupLogger()
+
+			mf := mockFields{
+				database: mocks.NewMockPaymentDatabase(ctrl),
+				xendit:   mocks.NewMockXenditClient(ctrl),
+			}
+
+			var saved []models.ReconciliationDiscrepancy
+			test.mock(mf, &saved)
+
+			s := NewReconciliationService(mf.database, mf.xendit)
+			run, err := s.Reconcile(context.Background(), periodStart, periodEnd, constant.ReconciliationActorScheduler)
+			assert.ErrorIs(t, err, test.wantError)
+			if test.wantRun == nil {
+				assert.Nil(t, run)
+				return
+			}
+
+			assert.Equal(t, test.wantRun.ID, run.ID)
+			assert.Equal(t, test.wantRun.XenditInvoiceCount, run.XenditInvoiceCount)
+			assert.Equal(t, test.wantRun.LocalPaymentCount, run.LocalPaymentCount)
+			assert.Equal(t, test.wantRun.DiscrepancyCount, run.DiscrepancyCount)
+
+			var types []string
+			for _, discrepancy := range saved {
+				assert.Equal(t, run.ID, discrepancy.RunID)
+				types = append(types, discrepancy.DiscrepancyType)
+			}
+			assert.Equal(t, test.wantTypes, types)
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment/cmd/payment/repository"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrPaymentNotRefundable = errors.New("payment is not refundable")
+	ErrInvalidRefundAmount  = errors.New("invalid refund amount")
+	ErrRefundAmountExceeded = errors.New("refund amount exceeds remaining paid amount")
+	ErrInvalidRefundReason  = errors.New("invalid refund reason")
+)
+
+var refundReasons = map[string]bool{
+	constant.RefundReasonFraudulent:          true,
+	constant.RefundReasonDuplicate:           true,
+	constant.RefundReasonRequestedByCustomer: true,
+	constant.RefundReasonCancellation:        true,
+	constant.RefundReasonOthers:              true,
+}
+
+type RefundService interface {
+	CreateRefund(ctx context.Context, param models.RefundRequest) (*models.Refund, error)
+	SyncRefundStatus(ctx context.Context, refund models.Refund) error
+}
+
+type refundService struct {
+	database repository.PaymentDatabase
+	gateways repository.PaymentGatewayResolver
+}
+
+func NewRefundService(database repository.PaymentDatabase, gateways repository.PaymentGatewayResolver) RefundService {
+	return &refundService{
+		database: database,
+		gateways: gateways,
+	}
+}
+
+func (s *refundService) CreateRefund(ctx context.Context, param models.RefundRequest) (*models.Refund, error) {
+	reason := strings.ToUpper(param.Reason)
+	if reason == "" {
+		reason = constant.RefundReasonRequestedByCustomer
+	}
+
+	if !refundReasons[reason] {
+		return nil, ErrInvalidRefundReason
+	}
+
+	var (
+		paymentInfo *models.Payment
+		gateway     repository.PaymentGateway
+		refund      models.Refund
+	)
+
+	// payment row is locked until the refund is saved, so concurrent refunds of the same order are checked one by one
+	err := s.database.WithTransaction(ctx, func(tx repository.PaymentDatabase) error {
+		var err error
+		paymentInfo, err = tx.GetPaymentInfoByOrderIDForUpdate(ctx, param.OrderID)
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"order_id":   param.OrderID,
+				"error_code": "s.CR001",
+			}).Errorf("tx.GetPaymentInfoByOrderIDForUpdate() got error: %v", err)
+
+			return err
+		}
+
+		if !paymentInfo.Status.CanTransitionTo(models.PaymentStatusRefunded) {
+			return ErrPaymentNotRefundable
+		}
+
+		// requested amount is in the payment currency, it must not be rounded to zero
+		var requestedAmount models.Money
+		if param.Amount != "" {
+			requestedAmount, err = models.ParseMoney(param.Amount.String(), paymentInfo.Amount.Currency)
+			if err != nil || !requestedAmount.IsPositive() {
+				return ErrInvalidRefundAmount
+			}
+		}
+
+		// validate against paid amount minus refund in progress or already succeeded
+		refundedAmount, err := tx.GetRefundedAmountByOrderID(ctx, param.OrderID)
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"order_id":   param.OrderID,
+				"error_code": "s.CR002",
+			}).Errorf("tx.GetRefundedAmountByOrderID() got error: %v", err)
+
+			return err
+		}
+
+		remainingAmount := models.NewMoney(paymentInfo.Amount.Amount-refundedAmount, paymentInfo.Amount.Currency)
+		amount := requestedAmount
+		if param.Amount == "" {
+			amount = remainingAmount
+		}
+
+		if !amount.IsPositive() || amount.Amount > remainingAmount.Amount {
+			log.Logger.WithFields(logrus.Fields{
+				"order_id":         param.OrderID,
+				"paid_amount":      paymentInfo.Amount,
+				"refunded_amount":  refundedAmount,
+				"currency":         paymentInfo.Amount.Currency,
+				"requested_amount": param.Amount,
+			}).Warn("CreateRefund => refund amount exceeds remaining paid amount")
+
+			return ErrRefundAmountExceeded
+		}
+
+		// refund is created at the gateway which the payment is paid through
+		gateway, err = s.gateways.Get(paymentInfo.Provider)
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"order_id":   param.OrderID,
+				"provider":   paymentInfo.Provider,
+				"error_code": "s.CR003",
+			}).Errorf("s.gateways.Get() got error: %v", err)
+
+			return err
+		}
+
+		refund = models.Refund{
+			OrderID:     paymentInfo.OrderID,
+			PaymentID:   paymentInfo.ID,
+			UserID:      paymentInfo.UserID,
+			ExternalID:  paymentInfo.ExternalID,
+			ReferenceID: fmt.Sprintf("refund-%d-%s", paymentInfo.OrderID, uuid.New().String()),
+			Amount:      amount,
+			Reason:      reason,
+			Status:      constant.RefundStatusPending,
+			RequestedBy: param.RequestedBy,
+			CreateTime:  time.Now(),
+		}
+
+		err = tx.SaveRefund(ctx, &refund)
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"refund":     refund,
+				"error_code": "s.CR004",
+			}).Errorf("tx.SaveRefund() got error: %v", err)
+
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.insertAuditLog(ctx, refund, "CreateRefund", fmt.Sprintf("user:%d", param.RequestedBy))
+
+	gatewayRefund, err := s.createGatewayRefund(ctx, gateway, paymentInfo.GatewayChargeID, refund)
+	if err != nil {
+		if repository.IsRejected(err) {
+			return nil, err
+		}
+
+		// refund may be created at the gateway, it is kept pending so the amount stay reserved until scheduler
+		// create it again with the same reference id
+		return &refund, nil
+	}
+
+	err = s.applyRefundStatus(ctx, &refund, gatewayRefund)
+	if err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+func (s *refundService) SyncRefundStatus(ctx context.Context, refund models.Refund) error {
+	paymentInfo, err := s.database.GetPaymentInfoByOrderID(ctx, refund.OrderID)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"refund_id": refund.ID,
+			"order_id":  refund.OrderID,
+		}).Errorf("s.database.GetPaymentInfoByOrderID() got error: %v", err)
+
+		return err
+	}
+
+	gateway, err := s.gateways.Get(paymentInfo.Provider)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"refund_id": refund.ID,
+			"provider":  paymentInfo.Provider,
+		}).Errorf("s.gateways.Get() got error: %v", err)
+
+		return err
+	}
+
+	// refund without gateway refund id got unknown result on create, e.g. timeout
+	if refund.GatewayRefundID == "" {
+		gatewayRefund, err := s.createGatewayRefund(ctx, gateway, paymentInfo.GatewayChargeID, refund)
+		if err != nil {
+			// rejected refund is already marked as failed
+			if repository.IsRejected(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		return s.applyRefundStatus(ctx, &refund, gatewayRefund)
+	}
+
+	gatewayRefund, err := gateway.GetRefund(ctx, refund.ExternalID, refund.GatewayRefundID)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"refund_id":         refund.ID,
+			"gateway_refund_id": refund.GatewayRefundID,
+			"provider":          gateway.Name(),
+		}).Errorf("gateway.GetRefund() got error: %v", err)
+
+		return err
+	}
+
+	return s.applyRefundStatus(ctx, &refund, gatewayRefund)
+}
+
+// createGatewayRefund send the refund with its reference id, so it is created once even when it is sent again.
+// Refund is only marked as failed when the gateway reject it, otherwise the result is unknown and it stay pending.
+func (s *refundService) createGatewayRefund(ctx context.Context, gateway repository.PaymentGateway, chargeID string, refund models.Refund) (models.GatewayRefund, error) {
+	// payment created before the charge id is stored is looked up by external id at the gateway
+	gatewayRefund, err := gateway.CreateRefund(ctx, models.GatewayRefundRequest{
+		ChargeID:    chargeID,
+		ExternalID:  refund.ExternalID,
+		ReferenceID: refund.ReferenceID,
+		Amount:      refund.Amount,
+		Reason:      refund.Reason,
+	})
+	if err == nil {
+		return gatewayRefund, nil
+	}
+
+	if !repository.IsRejected(err) {
+		log.Logger.WithFields(logrus.Fields{
+			"refund":     refund,
+			"error_code": "s.CR005",
+		}).Warnf("gateway.CreateRefund() got unknown result, refund is kept pending: %v", err)
+
+		return models.GatewayRefund{}, err
+	}
+
+	log.Logger.WithFields(logrus.Fields{
+		"refund":     refund,
+		"error_code": "s.CR005",
+	}).Errorf("gateway.CreateRefund() got error: %v", err)
+
+	errUpdate := s.database.UpdateRefund(ctx, refund.ID, "", constant.RefundStatusFailed, err.Error())
+	if errUpdate != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"refund_id": refund.ID,
+		}).Errorf("s.database.UpdateRefund() got error: %v", errUpdate)
+	}
+
+	s.insertAuditLog(ctx, refund, "RefundFailed", "refund_service")
+
+	return models.GatewayRefund{}, err
+}
+
+// store gateway refund status, then mark payment and publish event when refund succeeded
+func (s *refundService) applyRefundStatus(ctx context.Context, refund *models.Refund, gatewayRefund models.GatewayRefund) error {
+	refund.GatewayRefundID = gatewayRefund.ID
+	refund.Status = gatewayRefund.Status
+	if refund.Status == constant.RefundStatusFailed {
+		refund.Notes = gatewayRefund.FailureCode
+	}
+
+	if refund.Status != constant.RefundStatusSucceeded {
+		// pending refund is still processed by the gateway, it will be synced by scheduler
+		err := s.database.UpdateRefund(ctx, refund.ID, refund.GatewayRefundID, refund.Status, refund.Notes)
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"refund_id":         refund.ID,
+				"gateway_refund_id": refund.GatewayRefundID,
+				"status":            refund.Status,
+			}).Errorf("s.database.UpdateRefund() got error: %v", err)
+
+			return err
+		}
+
+		if refund.Status == constant.RefundStatusFailed {
+			s.insertAuditLog(ctx, *refund, "RefundFailed", "refund_service")
+		}
+
+		return nil
+	}
+
+	paymentInfo, err := s.database.GetPaymentInfoByOrderID(ctx, refund.OrderID)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": refund.OrderID,
+		}).Errorf("s.database.GetPaymentInfoByOrderID() got error: %v", err)
+
+		return err
+	}
+
+	// payment refunded event is published by outbox relay
+	var fullyRefunded bool
+	err = s.database.WithTransaction(ctx, func(tx repository.PaymentDatabase) error {
+		if err := tx.UpdateRefund(ctx, refund.ID, refund.GatewayRefundID, refund.Status, refund.Notes); err != nil {
+			return err
+		}
+
+		// only refunds confirmed by the gateway are counted, this refund is already stored as succeeded above
+		succeededAmount, err := tx.GetSucceededRefundAmountByOrderID(ctx, refund.OrderID)
+		if err != nil {
+			return err
+		}
+
+		fullyRefunded = succeededAmount >= paymentInfo.Amount.Amount
+		outbox, err := newOutbox(ctx, constant.KafkaTopicPaymentRefunded, models.PaymentRefundedSchemaVersion, refund.OrderID, models.NewPaymentRefundedEvent(*refund, fullyRefunded))
+		if err != nil {
+			return err
+		}
+
+		if fullyRefunded {
+			if err := tx.MarkRefunded(ctx, refund.OrderID); err != nil {
+				return err
+			}
+		}
+
+		return tx.SaveOutbox(ctx, outbox)
+	})
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"refund_id":      refund.ID,
+			"order_id":       refund.OrderID,
+			"fully_refunded": fullyRefunded,
+		}).Errorf("update refund and save outbox got error: %v", err)
+
+		return err
+	}
+
+	s.insertAuditLog(ctx, *refund, "RefundSucceeded", "refund_service")
+	if fullyRefunded {
+		s.insertAuditLog(ctx, *refund, "MarkRefunded", "refund_service")
+	}
+
+	return nil
+}
+
+func (s *refundService) insertAuditLog(ctx context.Context, refund models.Refund, event string, actor string) {
+	errLogAudit := s.database.InsertAuditLog(ctx, models.PaymentAuditLog{
+		OrderID:    refund.OrderID,
+		UserID:     refund.UserID,
+		PaymentID:  refund.PaymentID,
+		ExternalID: refund.ExternalID,
+		Event:      event,
+		Actor:      actor,
+		CreateTime: time.Now(),
+	})
+	if errLogAudit != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"refund_id": refund.ID,
+			"event":     event,
+		}).Errorf("s.database.InsertAuditLog() got error: %v", errLogAudit)
+	}
+}
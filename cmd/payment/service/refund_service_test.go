@@ -0,0 +1,387 @@
+package service
+
+import (
+	"context"
+	"payment/cmd/payment/repository"
+	mocks "payment/cmd/test_mock"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CreateRefund(t *testing.T) {
+	type mockFields struct {
+		database *mocks.MockPaymentDatabase
+		gateways *mocks.MockPaymentGatewayResolver
+		gateway  *mocks.MockPaymentGateway
+	}
+
+	type args struct {
+		ctx   context.Context
+		param models.RefundRequest
+	}
+
+	paidPayment := &models.Payment{
+		ID:         10,
+		OrderID:    123,
+		UserID:     222,
+		ExternalID: "order-123",
+		Amount:     models.NewMoney(10000, models.CurrencyIDR),
+		Status:     "PAID",
+		Provider:   constant.GatewayProviderXendit,
+
+		GatewayChargeID: "xendit-invoice_123",
+	}
+
+	rejectedError := &repository.XenditError{Operation: "CreateRefund", StatusCode: 400, ErrorCode: "INELIGIBLE_TRANSACTION"}
+
+	tests := []struct {
+		name       string
+		args       args
+		mock       func(mockFields)
+		wantStatus string
+		wantError  error
+	}{
+		{
+			name: "given_unknown_reason_then_it_should_return_error_invalid_reason",
+			args: args{
+				ctx: context.Background(),
+				param: models.RefundRequest{
+					OrderID: 123,
+					Amount:  "1000",
+					Reason:  "BECAUSE",
+				},
+			},
+			mock:      func(mf mockFields) {},
+			wantError: ErrInvalidRefundReason,
+		},
+		{
+			name: "given_pending_payment_then_it_should_return_error_not_refundable",
+			args: args{
+				ctx: context.Background(),
+				param: models.RefundRequest{
+					OrderID: 123,
+					Amount:  "1000",
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().WithTransaction(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(tx repository.PaymentDatabase) error) error {
+					return fn(mf.database)
+				})
+				mf.database.EXPECT().GetPaymentInfoByOrderIDForUpdate(context.Background(), int64(123)).Return(&models.Payment{
+					OrderID: 123,
+					Amount:  models.NewMoney(10000, models.CurrencyIDR),
+					Status:  "PENDING",
+				}, nil)
+			},
+			wantError: ErrPaymentNotRefundable,
+		},
+		{
+			name: "given_amount_rounded_to_zero_in_payment_currency_then_it_should_return_error_invalid_amount",
+			args: args{
+				ctx: context.Background(),
+				param: models.RefundRequest{
+					OrderID: 123,
+					Amount:  "0.4",
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().WithTransaction(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(tx repository.PaymentDatabase) error) error {
+					return fn(mf.database)
+				})
+				mf.database.EXPECT().GetPaymentInfoByOrderIDForUpdate(context.Background(), int64(123)).Return(paidPayment, nil)
+			},
+			wantError: ErrInvalidRefundAmount,
+		},
+		{
+			name: "given_amount_more_than_remaining_paid_amount_then_it_should_return_error_amount_exceeded",
+			args: args{
+				ctx: context.Background(),
+				param: models.RefundRequest{
+					OrderID: 123,
+					Amount:  "6000",
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().WithTransaction(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(tx repository.PaymentDatabase) error) error {
+					return fn(mf.database)
+				})
+				mf.database.EXPECT().GetPaymentInfoByOrderIDForUpdate(context.Background(), int64(123)).Return(paidPayment, nil)
+				mf.database.EXPECT().GetRefundedAmountByOrderID(context.Background(), int64(123)).Return(int64(5000), nil)
+			},
+			wantError: ErrRefundAmountExceeded,
+		},
+		{
+			name: "given_valid_param_but_gateway_reject_refund_then_it_should_mark_refund_failed_and_return_error",
+			args: args{
+				ctx: context.Background(),
+				param: models.RefundRequest{
+					OrderID:     123,
+					Amount:      "1000",
+					RequestedBy: 1,
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().WithTransaction(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(tx repository.PaymentDatabase) error) error {
+					return fn(mf.database)
+				})
+				mf.database.EXPECT().GetPaymentInfoByOrderIDForUpdate(context.Background(), int64(123)).Return(paidPayment, nil)
+				mf.database.EXPECT().GetRefundedAmountByOrderID(context.Background(), int64(123)).Return(int64(0), nil)
+				mf.gateways.EXPECT().Get(constant.GatewayProviderXendit).Return(mf.gateway, nil)
+				mf.database.EXPECT().SaveRefund(context.Background(), gomock.Any()).Return(nil)
+				mf.database.EXPECT().InsertAuditLog(context.Background(), gomock.Any()).Return(nil).AnyTimes()
+				mf.gateway.EXPECT().CreateRefund(context.Background(), gomock.Any()).Return(models.GatewayRefund{}, rejectedError)
+				mf.database.EXPECT().UpdateRefund(context.Background(), gomock.Any(), "", constant.RefundStatusFailed, rejectedError.Error()).Return(nil)
+			},
+			wantError: rejectedError,
+		},
+		{
+			name: "given_valid_param_but_gateway_CreateRefund_timeout_then_it_should_keep_refund_pending",
+			args: args{
+				ctx: context.Background(),
+				param: models.RefundRequest{
+					OrderID:     123,
+					Amount:      "1000",
+					RequestedBy: 1,
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().WithTransaction(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(tx repository.PaymentDatabase) error) error {
+					return fn(mf.database)
+				})
+				mf.database.EXPECT().GetPaymentInfoByOrderIDForUpdate(context.Background(), int64(123)).Return(paidPayment, nil)
+				mf.database.EXPECT().GetRefundedAmountByOrderID(context.Background(), int64(123)).Return(int64(0), nil)
+				mf.gateways.EXPECT().Get(constant.GatewayProviderXendit).Return(mf.gateway, nil)
+				mf.database.EXPECT().SaveRefund(context.Background(), gomock.Any()).Return(nil)
+				mf.database.EXPECT().InsertAuditLog(context.Background(), gomock.Any()).Return(nil).AnyTimes()
+				// refund may be created at xendit, so the amount must stay reserved
+				mf.gateway.EXPECT().CreateRefund(context.Background(), gomock.Any()).Return(models.GatewayRefund{}, context.DeadlineExceeded)
+				mf.database.EXPECT().UpdateRefund(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantStatus: constant.RefundStatusPending,
+			wantError:  nil,
+		},
+		{
+			name: "given_valid_partial_refund_and_gateway_succeeded_then_it_should_save_partially_refunded_outbox",
+			args: args{
+				ctx: context.Background(),
+				param: models.RefundRequest{
+					OrderID:     123,
+					Amount:      "1000",
+					RequestedBy: 1,
+				},
+			},
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderIDForUpdate(context.Background(), int64(123)).Return(paidPayment, nil)
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(123)).Return(paidPayment, nil)
+				mf.database.EXPECT().GetRefundedAmountByOrderID(context.Background(), int64(123)).Return(int64(0), nil)
+				mf.gateways.EXPECT().Get(constant.GatewayProviderXendit).Return(mf.gateway, nil)
+				mf.database.EXPECT().SaveRefund(context.Background(), gomock.Any()).Return(nil)
+				mf.database.EXPECT().InsertAuditLog(context.Background(), gomock.Any()).Return(nil).AnyTimes()
+				mf.gateway.EXPECT().CreateRefund(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, param models.GatewayRefundRequest) (models.GatewayRefund, error) {
+					assert.Equal(t, "order-123", param.ExternalID)
+					assert.Equal(t, "xendit-invoice_123", param.ChargeID)
+					assert.Equal(t, models.NewMoney(1000, models.CurrencyIDR), param.Amount)
+
+					return models.GatewayRefund{
+						ID:     "rfd-123",
+						Amount: models.NewMoney(1000, models.CurrencyIDR),
+						Status: constant.RefundStatusSucceeded,
+					}, nil
+				})
+				mf.database.EXPECT().WithTransaction(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(tx repository.PaymentDatabase) error) error {
+					return fn(mf.database)
+				}).Times(2)
+				mf.database.EXPECT().UpdateRefund(context.Background(), gomock.Any(), "rfd-123", constant.RefundStatusSucceeded, "").Return(nil)
+				mf.database.EXPECT().GetSucceededRefundAmountByOrderID(context.Background(), int64(123)).Return(int64(1000), nil)
+				mf.database.EXPECT().SaveOutbox(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, param *models.Outbox) error {
+					assert.Equal(t, constant.KafkaTopicPaymentRefunded, param.EventType)
+					assert.Contains(t, param.Payload, `"status":"partially_refunded"`)
+
+					return nil
+				})
+			},
+			wantError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			log.SetupLogger()
+
+			mock := mockFields{
+				database: mocks.NewMockPaymentDatabase(ctrl),
+				gateways: mocks.NewMockPaymentGatewayResolver(ctrl),
+				gateway:  mocks.NewMockPaymentGateway(ctrl),
+			}
+
+			service := &refundService{
+				database: mock.database,
+				gateways: mock.gateways,
+			}
+
+			test.mock(mock)
+			got, gotError := service.CreateRefund(test.args.ctx, test.args.param)
+			assert.Equal(t, test.wantError, gotError)
+			if test.wantStatus != "" {
+				assert.Equal(t, test.wantStatus, got.Status)
+			}
+		})
+	}
+}
+
+func Test_SyncRefundStatus(t *testing.T) {
+	type mockFields struct {
+		database *mocks.MockPaymentDatabase
+		gateways *mocks.MockPaymentGatewayResolver
+		gateway  *mocks.MockPaymentGateway
+	}
+
+	paidPayment := &models.Payment{
+		ID:         10,
+		OrderID:    123,
+		UserID:     222,
+		ExternalID: "order-123",
+		Amount:     models.NewMoney(10000, models.CurrencyIDR),
+		Status:     "PAID",
+		Provider:   constant.GatewayProviderXendit,
+	}
+
+	// two partial refunds of 5000 each, both were pending at the gateway
+	firstRefund := models.Refund{
+		ID:              1,
+		OrderID:         123,
+		ExternalID:      "order-123",
+		GatewayRefundID: "rfd-1",
+		Amount:          models.NewMoney(5000, models.CurrencyIDR),
+		Status:          constant.RefundStatusPending,
+	}
+
+	secondRefund := firstRefund
+	secondRefund.ID = 2
+	secondRefund.GatewayRefundID = "rfd-2"
+
+	// create result of this refund was unknown, e.g. xendit timeout
+	unknownRefund := firstRefund
+	unknownRefund.ID = 3
+	unknownRefund.ReferenceID = "refund-123-abc"
+	unknownRefund.GatewayRefundID = ""
+
+	tests := []struct {
+		name      string
+		refund    models.Refund
+		mock      func(mockFields)
+		wantError error
+	}{
+		{
+			name:   "given_first_refund_succeeded_while_second_still_pending_then_it_should_not_mark_payment_refunded",
+			refund: firstRefund,
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(123)).Return(paidPayment, nil).Times(2)
+				mf.gateways.EXPECT().Get(constant.GatewayProviderXendit).Return(mf.gateway, nil)
+				mf.gateway.EXPECT().GetRefund(context.Background(), "order-123", "rfd-1").Return(models.GatewayRefund{
+					ID:     "rfd-1",
+					Amount: models.NewMoney(5000, models.CurrencyIDR),
+					Status: constant.RefundStatusSucceeded,
+				}, nil)
+				mf.database.EXPECT().WithTransaction(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(tx repository.PaymentDatabase) error) error {
+					return fn(mf.database)
+				})
+				mf.database.EXPECT().UpdateRefund(context.Background(), int64(1), "rfd-1", constant.RefundStatusSucceeded, "").Return(nil)
+				// second refund is still pending, so only the first one is succeeded
+				mf.database.EXPECT().GetSucceededRefundAmountByOrderID(context.Background(), int64(123)).Return(int64(5000), nil)
+				mf.database.EXPECT().MarkRefunded(gomock.Any(), gomock.Any()).Times(0)
+				mf.database.EXPECT().SaveOutbox(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, param *models.Outbox) error {
+					assert.Contains(t, param.Payload, `"status":"partially_refunded"`)
+
+					return nil
+				})
+				mf.database.EXPECT().InsertAuditLog(context.Background(), gomock.Any()).Return(nil).AnyTimes()
+			},
+			wantError: nil,
+		},
+		{
+			name:   "given_refund_without_gateway_refund_id_then_it_should_create_it_again_with_the_same_reference_id",
+			refund: unknownRefund,
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(123)).Return(paidPayment, nil)
+				mf.gateways.EXPECT().Get(constant.GatewayProviderXendit).Return(mf.gateway, nil)
+				mf.gateway.EXPECT().CreateRefund(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, param models.GatewayRefundRequest) (models.GatewayRefund, error) {
+					assert.Equal(t, "refund-123-abc", param.ReferenceID)
+
+					return models.GatewayRefund{ID: "rfd-3", Status: constant.RefundStatusPending}, nil
+				})
+				mf.database.EXPECT().UpdateRefund(context.Background(), int64(3), "rfd-3", constant.RefundStatusPending, "").Return(nil)
+			},
+			wantError: nil,
+		},
+		{
+			name:   "given_refund_without_gateway_refund_id_and_gateway_timeout_again_then_it_should_keep_it_pending",
+			refund: unknownRefund,
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(123)).Return(paidPayment, nil)
+				mf.gateways.EXPECT().Get(constant.GatewayProviderXendit).Return(mf.gateway, nil)
+				mf.gateway.EXPECT().CreateRefund(context.Background(), gomock.Any()).Return(models.GatewayRefund{}, context.DeadlineExceeded)
+				mf.database.EXPECT().UpdateRefund(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantError: context.DeadlineExceeded,
+		},
+		{
+			name:   "given_second_refund_succeeded_after_the_first_then_it_should_mark_payment_refunded",
+			refund: secondRefund,
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(123)).Return(paidPayment, nil).Times(2)
+				mf.gateways.EXPECT().Get(constant.GatewayProviderXendit).Return(mf.gateway, nil)
+				mf.gateway.EXPECT().GetRefund(context.Background(), "order-123", "rfd-2").Return(models.GatewayRefund{
+					ID:     "rfd-2",
+					Amount: models.NewMoney(5000, models.CurrencyIDR),
+					Status: constant.RefundStatusSucceeded,
+				}, nil)
+				mf.database.EXPECT().WithTransaction(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(tx repository.PaymentDatabase) error) error {
+					return fn(mf.database)
+				})
+				mf.database.EXPECT().UpdateRefund(context.Background(), int64(2), "rfd-2", constant.RefundStatusSucceeded, "").Return(nil)
+				mf.database.EXPECT().GetSucceededRefundAmountByOrderID(context.Background(), int64(123)).Return(int64(10000), nil)
+				mf.database.EXPECT().MarkRefunded(context.Background(), int64(123)).Return(nil)
+				mf.database.EXPECT().SaveOutbox(context.Background(), gomock.Any()).DoAndReturn(func(ctx context.Context, param *models.Outbox) error {
+					assert.Contains(t, param.Payload, `"status":"refunded"`)
+
+					return nil
+				})
+				mf.database.EXPECT().InsertAuditLog(context.Background(), gomock.Any()).Return(nil).AnyTimes()
+			},
+			wantError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			log.SetupLogger()
+
+			mock := mockFields{
+				database: mocks.NewMockPaymentDatabase(ctrl),
+				gateways: mocks.NewMockPaymentGatewayResolver(ctrl),
+				gateway:  mocks.NewMockPaymentGateway(ctrl),
+			}
+
+			service := &refundService{
+				database: mock.database,
+				gateways: mock.gateways,
+			}
+
+			test.mock(mock)
+			gotError := service.SyncRefundStatus(context.Background(), test.refund)
+			assert.Equal(t, test.wantError, gotError)
+		})
+	}
+}
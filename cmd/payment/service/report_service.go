@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"payment/cmd/payment/repository"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ReportService interface {
+	GetSettlementReport(ctx context.Context, startTime time.Time, endTime time.Time) (*models.SettlementReport, error)
+}
+
+type reportService struct {
+	database repository.PaymentDatabase
+}
+
+func NewReportService(database repository.PaymentDatabase) ReportService {
+	return &reportService{
+		database: database,
+	}
+}
+
+// GetSettlementReport group paid, refunded and expired payments in [startTime, endTime) by day, payment method
+// and currency, then add total per day, per payment method and for the whole period of each currency.
+func (s *reportService) GetSettlementReport(ctx context.Context, startTime time.Time, endTime time.Time) (*models.SettlementReport, error) {
+	summaries, err := s.database.GetSettlementSummaries(ctx, startTime, endTime)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"start_time": startTime,
+			"end_time":   endTime,
+		}).Errorf("s.database.GetSettlementSummaries() got error: %v", err)
+
+		return nil, err
+	}
+
+	type rowKey struct {
+		day           string
+		paymentMethod string
+		currency      string
+	}
+
+	// totals are keyed by the same key with day or payment method left empty
+	rows := make(map[rowKey]*models.SettlementReportRow)
+	dailyTotals := make(map[rowKey]*models.SettlementReportRow)
+	methodTotals := make(map[rowKey]*models.SettlementReportRow)
+	totals := make(map[rowKey]*models.SettlementReportRow)
+	report := &models.SettlementReport{
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
+
+	for _, summary := range summaries {
+		currency := summary.Amount.Currency
+		keys := []struct {
+			group map[rowKey]*models.SettlementReportRow
+			key   rowKey
+		}{
+			{group: rows, key: rowKey{day: summary.Day, paymentMethod: summary.PaymentMethod, currency: currency}},
+			{group: dailyTotals, key: rowKey{day: summary.Day, currency: currency}},
+			{group: methodTotals, key: rowKey{paymentMethod: summary.PaymentMethod, currency: currency}},
+			{group: totals, key: rowKey{currency: currency}},
+		}
+
+		for _, k := range keys {
+			row := k.group[k.key]
+			if row == nil {
+				row = newSettlementReportRow(k.key.day, k.key.paymentMethod, currency)
+				k.group[k.key] = row
+			}
+
+			err = addSettlementSummary(row, summary)
+			if err != nil {
+				log.Logger.WithFields(logrus.Fields{
+					"day":            summary.Day,
+					"payment_method": summary.PaymentMethod,
+					"currency":       currency,
+				}).Errorf("addSettlementSummary() got error: %v", err)
+
+				return nil, err
+			}
+		}
+	}
+
+	report.Rows = sortedSettlementRows(rows)
+	report.DailyTotals = sortedSettlementRows(dailyTotals)
+	report.MethodTotals = sortedSettlementRows(methodTotals)
+	report.Totals = sortedSettlementRows(totals)
+
+	return report, nil
+}
+
+func newSettlementReportRow(day string, paymentMethod string, currency string) *models.SettlementReportRow {
+	zero := models.NewMoney(0, currency)
+
+	return &models.SettlementReportRow{
+		Day:            day,
+		PaymentMethod:  paymentMethod,
+		Currency:       currency,
+		PaidAmount:     zero,
+		RefundedAmount: zero,
+		ExpiredAmount:  zero,
+		NetAmount:      zero,
+	}
+}
+
+// sortedSettlementRows order rows by day, payment method then currency.
+func sortedSettlementRows[K comparable](rows map[K]*models.SettlementReportRow) []models.SettlementReportRow {
+	var result []models.SettlementReportRow
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Day != result[j].Day {
+			return result[i].Day < result[j].Day
+		}
+
+		if result[i].PaymentMethod != result[j].PaymentMethod {
+			return result[i].PaymentMethod < result[j].PaymentMethod
+		}
+
+		return result[i].Currency < result[j].Currency
+	})
+
+	return result
+}
+
+// addSettlementSummary return models.ErrCurrencyMismatch when the summary is not in the currency of the row.
+func addSettlementSummary(row *models.SettlementReportRow, summary models.SettlementSummary) (err error) {
+	switch summary.Category {
+	case constant.SettlementCategoryPaid:
+		row.PaidCount += summary.Count
+		row.PaidAmount, err = row.PaidAmount.Add(summary.Amount)
+	case constant.SettlementCategoryRefunded:
+		row.RefundedCount += summary.Count
+		row.RefundedAmount, err = row.RefundedAmount.Add(summary.Amount)
+	case constant.SettlementCategoryExpired:
+		row.ExpiredCount += summary.Count
+		row.ExpiredAmount, err = row.ExpiredAmount.Add(summary.Amount)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	row.NetAmount, err = row.PaidAmount.Sub(row.RefundedAmount)
+
+	return err
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	mocks "payment/cmd/test_mock"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetSettlementReport(t *testing.T) {
+	type mockFields struct {
+		database *mocks.MockPaymentDatabase
+	}
+
+	startTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	idr := func(amount int64) models.Money {
+		return models.NewMoney(amount, models.CurrencyIDR)
+	}
+	// counts and amounts are paid, refunded and expired
+	row := func(day string, paymentMethod string, currency string, counts [3]int, amounts [3]int64) models.SettlementReportRow {
+		return models.SettlementReportRow{
+			Day:            day,
+			PaymentMethod:  paymentMethod,
+			Currency:       currency,
+			PaidCount:      counts[0],
+			PaidAmount:     models.NewMoney(amounts[0], currency),
+			RefundedCount:  counts[1],
+			RefundedAmount: models.NewMoney(amounts[1], currency),
+			ExpiredCount:   counts[2],
+			ExpiredAmount:  models.NewMoney(amounts[2], currency),
+			NetAmount:      models.NewMoney(amounts[0]-amounts[1], currency),
+		}
+	}
+	endTime := startTime.AddDate(0, 0, 2)
+
+	tests := []struct {
+		name       string
+		mock       func(mockFields)
+		wantReport *models.SettlementReport
+		wantError  error
+	}{
+		{
+			name: "given_error_GetSettlementSummaries_then_it_should_return_error",
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetSettlementSummaries(context.Background(), startTime, endTime).Return(nil, assert.AnError)
+			},
+			wantError: assert.AnError,
+		},
+		{
+			name: "given_summaries_then_it_should_group_by_day_payment_method_and_currency_with_totals",
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetSettlementSummaries(context.Background(), startTime, endTime).Return([]models.SettlementSummary{
+					{Day: "2026-01-02", PaymentMethod: "BANK_TRANSFER", Category: constant.SettlementCategoryPaid, Count: 1, Amount: idr(500)},
+					{Day: "2026-01-01", PaymentMethod: "EWALLET", Category: constant.SettlementCategoryPaid, Count: 2, Amount: idr(300)},
+					{Day: "2026-01-01", PaymentMethod: "EWALLET", Category: constant.SettlementCategoryPaid, Count: 1, Amount: models.NewMoney(12550, models.CurrencyPHP)},
+					{Day: "2026-01-01", PaymentMethod: "BANK_TRANSFER", Category: constant.SettlementCategoryPaid, Count: 3, Amount: idr(1000)},
+					{Day: "2026-01-02", PaymentMethod: "BANK_TRANSFER", Category: constant.SettlementCategoryRefunded, Count: 1, Amount: idr(200)},
+					{Day: "2026-01-02", PaymentMethod: "", Category: constant.SettlementCategoryExpired, Count: 4, Amount: idr(800)},
+				}, nil)
+			},
+			wantReport: &models.SettlementReport{
+				StartTime: startTime,
+				EndTime:   endTime,
+				Rows: []models.SettlementReportRow{
+					row("2026-01-01", "BANK_TRANSFER", "IDR", [3]int{3, 0, 0}, [3]int64{1000, 0, 0}),
+					row("2026-01-01", "EWALLET", "IDR", [3]int{2, 0, 0}, [3]int64{300, 0, 0}),
+					row("2026-01-01", "EWALLET", "PHP", [3]int{1, 0, 0}, [3]int64{12550, 0, 0}),
+					row("2026-01-02", "", "IDR", [3]int{0, 0, 4}, [3]int64{0, 0, 800}),
+					row("2026-01-02", "BANK_TRANSFER", "IDR", [3]int{1, 1, 0}, [3]int64{500, 200, 0}),
+				},
+				DailyTotals: []models.SettlementReportRow{
+					row("2026-01-01", "", "IDR", [3]int{5, 0, 0}, [3]int64{1300, 0, 0}),
+					row("2026-01-01", "", "PHP", [3]int{1, 0, 0}, [3]int64{12550, 0, 0}),
+					row("2026-01-02", "", "IDR", [3]int{1, 1, 4}, [3]int64{500, 200, 800}),
+				},
+				MethodTotals: []models.SettlementReportRow{
+					row("", "", "IDR", [3]int{0, 0, 4}, [3]int64{0, 0, 800}),
+					row("", "BANK_TRANSFER", "IDR", [3]int{4, 1, 0}, [3]int64{1500, 200, 0}),
+					row("", "EWALLET", "IDR", [3]int{2, 0, 0}, [3]int64{300, 0, 0}),
+					row("", "EWALLET", "PHP", [3]int{1, 0, 0}, [3]int64{12550, 0, 0}),
+				},
+				Totals: []models.SettlementReportRow{
+					row("", "", "IDR", [3]int{6, 1, 4}, [3]int64{1800, 200, 800}),
+					row("", "", "PHP", [3]int{1, 0, 0}, [3]int64{12550, 0, 0}),
+				},
+			},
+		},
+		{
+			name: "given_no_summary_then_it_should_return_empty_report",
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().GetSettlementSummaries(context.Background(), startTime, endTime).Return(nil, nil)
+			},
+			wantReport: &models.SettlementReport{
+				StartTime: startTime,
+				EndTime:   endTime,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			log.SetupLogger()
+
+			mf := mockFields{
+				database: mocks.NewMockPaymentDatabase(ctrl),
+			}
+			test.mock(mf)
+
+			s := NewReportService(mf.database)
+			report, err := s.GetSettlementReport(context.Background(), startTime, endTime)
+			assert.ErrorIs(t, err, test.wantError)
+			assert.Equal(t, test.wantReport, report)
+		})
+	}
+}
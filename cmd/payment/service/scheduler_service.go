@@ -2,210 +2,522 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"payment/cmd/payment/repository"
 	"payment/grpc"
+	"payment/infrastructure/breaker"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/job"
 	"payment/infrastructure/log"
 	"payment/models"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	SchedulerJobCheckPendingInvoices          = "check_pending_invoices"
+	SchedulerJobProcessPendingPaymentRequests = "process_pending_payment_requests"
+	SchedulerJobProcessFailedPaymentRequests  = "process_failed_payment_requests"
+	SchedulerJobProcessExpiredPendingPayments = "process_expired_pending_payments"
+	SchedulerJobCheckPendingRefunds           = "check_pending_refunds"
+	SchedulerJobRetryFailedEvents             = "retry_failed_events"
+	SchedulerJobRelayOutbox                   = "relay_outbox"
+	SchedulerJobReconcileXenditInvoices       = "reconcile_xendit_invoices"
 )
 
 type SchedulerService struct {
-	Database       repository.PaymentDatabase
-	Xendit         repository.XenditClient
-	Publisher      repository.PaymentEventPublisher
-	PaymentService PaymentService
-	UserClient     grpc.UserClient
+	Database           repository.PaymentDatabase
+	Gateways           repository.PaymentGatewayResolver
+	Publisher          repository.PaymentEventPublisher
+	PaymentService     PaymentService
+	RefundService      RefundService
+	FailedEventService FailedEventService
+	OutboxService      OutboxService
+	UserClient         grpc.UserClient
+
+	ReconciliationService ReconciliationService
+
+	// identify this replica as the claimer of payment requests
+	InstanceID              string
+	PaymentRequestBatchSize int
+	PaymentRequestClaimTTL  time.Duration
+}
+
+// Jobs return scheduler jobs with default schedule, schedule can be overridden by config.
+func (s *SchedulerService) Jobs() []job.Job {
+	return []job.Job{
+		{
+			Name:     SchedulerJobCheckPendingInvoices,
+			Enabled:  true,
+			Interval: 10 * time.Minute,
+			Jitter:   30 * time.Second,
+			Timeout:  5 * time.Minute,
+			Run:      s.CheckPendingInvoices,
+		},
+		{
+			Name:          SchedulerJobProcessPendingPaymentRequests,
+			Enabled:       true,
+			Interval:      5 * time.Second,
+			RetryInterval: 10 * time.Second,
+			Timeout:       5 * time.Minute,
+			Run:           s.ProcessPendingPaymentRequests,
+		},
+		{
+			Name:          SchedulerJobProcessFailedPaymentRequests,
+			Enabled:       true,
+			Interval:      time.Minute,
+			RetryInterval: 10 * time.Second,
+			Timeout:       time.Minute,
+			Run:           s.ProcessFailedPaymentRequests,
+		},
+		{
+			Name:          SchedulerJobProcessExpiredPendingPayments,
+			Enabled:       true,
+			Interval:      10 * time.Minute,
+			RetryInterval: 10 * time.Second,
+			Jitter:        30 * time.Second,
+			Timeout:       5 * time.Minute,
+			Run:           s.ProcessExpiredPendingPayments,
+		},
+		{
+			Name:     SchedulerJobCheckPendingRefunds,
+			Enabled:  true,
+			Interval: 5 * time.Minute,
+			Jitter:   30 * time.Second,
+			Timeout:  5 * time.Minute,
+			Run:      s.CheckPendingRefunds,
+		},
+		{
+			Name:          SchedulerJobRetryFailedEvents,
+			Enabled:       true,
+			Interval:      time.Minute,
+			RetryInterval: 10 * time.Second,
+			Timeout:       time.Minute,
+			Run:           s.RetryFailedEvents,
+		},
+		{
+			Name:          SchedulerJobRelayOutbox,
+			Enabled:       true,
+			Interval:      time.Second,
+			RetryInterval: 5 * time.Second,
+			Timeout:       time.Minute,
+			Run:           s.RelayOutbox,
+		},
+		{
+			// run hourly, but each day is only reconciled once
+			Name:          SchedulerJobReconcileXenditInvoices,
+			Enabled:       true,
+			Interval:      time.Hour,
+			RetryInterval: 10 * time.Minute,
+			Jitter:        time.Minute,
+			Timeout:       30 * time.Minute,
+			Run:           s.ReconcileXenditInvoices,
+		},
+	}
 }
 
-func (s *SchedulerService) StartProcessExpiredPendingPayments() {
-	ctx := context.Background()
-	go func(ctx context.Context) {
-		for {
-			log.Logger.Println("Starting to process expired pending payments...")
+func (s *SchedulerService) ProcessExpiredPendingPayments(ctx context.Context) (job.Result, error) {
+	log.Logger.Println("Starting to process expired pending payments...")
+
+	// get expired pending payments
+	expiredPayments, err := s.Database.GetExpiredPendingPayments(ctx)
+	if err != nil {
+		log.Logger.Printf("s.Database.GetExpiredPendingPayments() got error: %v", err)
+		return job.Result{}, err
+	}
+
+	var result job.Result
+	for _, expiredPayment := range expiredPayments {
+		// stop when the lease is lost or on shutdown, remaining items are handled by the next run
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		// expire the charge so it can not be paid anymore, gateway also expire it by itself so failure is only logged
+		s.expireCharge(ctx, expiredPayment)
+
+		err = s.PaymentService.ProcessPaymentExpired(ctx, expiredPayment.OrderID)
+		if err != nil {
+			log.Logger.Printf("[payment ID: %d] s.PaymentService.ProcessPaymentExpired() got error: %v", expiredPayment.ID, err)
+			result.Failed++
+			continue
+		}
+
+		result.Processed++
+	}
+
+	return result, nil
+}
+
+func (s *SchedulerService) expireCharge(ctx context.Context, payment models.Payment) {
+	gateway, err := s.Gateways.Get(payment.Provider)
+	if err != nil {
+		log.Logger.Printf("[payment ID: %d] s.Gateways.Get() got error: %v", payment.ID, err)
+		return
+	}
+
+	err = gateway.ExpireCharge(ctx, payment.ExternalID)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"payment_id":  payment.ID,
+			"external_id": payment.ExternalID,
+			"provider":    gateway.Name(),
+		}).Warnf("gateway.ExpireCharge() got error: %v", err)
+	}
+}
+
+func (s *SchedulerService) ProcessPendingPaymentRequests(ctx context.Context) (job.Result, error) {
+	batchSize := s.PaymentRequestBatchSize
+	if batchSize <= 0 {
+		batchSize = constant.DefaultPaymentRequestBatchSize
+	}
+
+	claimTTL := s.PaymentRequestClaimTTL
+	if claimTTL <= 0 {
+		claimTTL = constant.DefaultPaymentRequestClaimTTL
+	}
+
+	// claim pending payment requests, so the same request is never processed by two workers.
+	// request which is not finished, e.g. the worker dies, is claimed again after the lease expires
+	paymentRequests, err := s.Database.ClaimPaymentRequests(ctx, s.InstanceID, time.Now().Add(claimTTL), batchSize)
+	if err != nil {
+		log.Logger.Printf("s.Database.ClaimPaymentRequests() got error: %v", err)
+		return job.Result{}, err
+	}
+
+	var result job.Result
+	for i, paymentRequest := range paymentRequests {
+		// stop when the lease is lost or on shutdown, remaining requests are released for the next leader
+		if ctx.Err() != nil {
+			s.releasePaymentRequests(context.WithoutCancel(ctx), paymentRequests[i:], ctx.Err())
+			return result, ctx.Err()
+		}
+
+		// process each payment request
+		externalID := fmt.Sprintf("order-%d", paymentRequest.OrderID)
+		log.Logger.Printf("[DEBUG] Processing payment request ID: %d", paymentRequest.ID)
+
+		// check if invoice has been created, e.g. the previous run died after saving the payment
+		paymentInfo, err := s.Database.GetPaymentInfoByOrderID(ctx, paymentRequest.OrderID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Logger.Printf("[req id: %d] got error: %v", paymentRequest.ID, err)
+			result.Failed++
+			continue
+		}
 
-			// get expired pending payments
-			expiredPayments, err := s.Database.GetExpiredPendingPayments(ctx)
+		if err == nil && paymentInfo.ID != 0 {
+			err = s.Database.UpdateSuccessPaymentRequest(ctx, paymentRequest.ID)
 			if err != nil {
-				log.Logger.Printf("s.Database.GetExpiredPendingPayments() got error: %v", err)
-				time.Sleep(10 * time.Second) // give time gap before next iteration
+				log.Logger.Printf("[req id: %d] s.Database.UpdateSuccessPaymentRequest() got error: %v", paymentRequest.ID, err)
+				result.Failed++
 				continue
 			}
 
-			for _, expiredPayment := range expiredPayments {
-				err = s.Database.MarkExpired(ctx, expiredPayment.OrderID)
-				if err != nil {
-					log.Logger.Printf("[payment ID: %d] s.Database.MarkExpired() got error: %v", expiredPayment.ID, err)
-					continue
-				}
+			result.Processed++
+			continue
+		}
+
+		// get user info by grpc
+		userInfo, err := s.UserClient.GetUserInfoByUserId(ctx, paymentRequest.UserID)
+		if errors.Is(err, breaker.ErrOpen) {
+			s.releasePaymentRequests(ctx, paymentRequests[i:], err)
+			return result, nil
+		}
+
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"user_id":  paymentRequest.UserID,
+				"order_id": paymentRequest.OrderID,
+			}).WithError(err).Errorf("[req id: %d] s.UserClient.GetUserInfoByUserId() got error: %v", paymentRequest.ID, err)
+			result.Failed++
+			continue
+		}
+
+		gateway, err := s.Gateways.ForPaymentMethod(paymentRequest.PaymentMethod)
+		if err != nil {
+			log.Logger.Printf("[req id: %d] s.Gateways.ForPaymentMethod() got error: %v", paymentRequest.ID, err)
+
+			errSaveFailedPaymentRequest := s.Database.UpdateFailedPaymentRequest(ctx, paymentRequest.ID, err.Error())
+			if errSaveFailedPaymentRequest != nil {
+				log.Logger.Printf("[req id: %d] s.Database.UpdateFailedPaymentRequest() got error: %v", paymentRequest.ID, errSaveFailedPaymentRequest)
 			}
 
-			time.Sleep(10 * time.Minute) // give time gap before next iteration
+			result.Failed++
+			continue
+		}
+
+		payerName := paymentRequest.CustomerName
+		if payerName == "" {
+			payerName = userInfo.Name
+		}
+
+		chargeReq := models.ChargeRequest{
+			ExternalID:    externalID,
+			Amount:        paymentRequest.Amount,
+			Description:   fmt.Sprintf("Payment for Order ID %d", paymentRequest.OrderID),
+			PayerEmail:    userInfo.Email,
+			PayerName:     payerName,
+			PayerPhone:    paymentRequest.CustomerPhone,
+			PaymentMethod: paymentRequest.PaymentMethod,
+			Items:         paymentRequest.Items,
+		}
+
+		charge, err := gateway.CreateCharge(ctx, chargeReq)
+		if errors.Is(err, breaker.ErrOpen) {
+			s.releasePaymentRequests(ctx, paymentRequests[i:], err)
+			return result, nil
+		}
+
+		errLogAudit := s.Database.InsertAuditLog(ctx, models.PaymentAuditLog{
+			OrderID:    paymentRequest.OrderID,
+			UserID:     paymentRequest.UserID,
+			ExternalID: externalID,
+			Event:      "CreateInvoice",
+			Actor:      "scheduler_service_process_pending_payment_requests",
+			CreateTime: time.Now(),
+		})
+		if errLogAudit != nil {
+			log.Logger.Printf("[req id: %d] s.Database.InsertAuditLog() got error: %v", paymentRequest.ID, errLogAudit)
+		}
+
+		if err != nil {
+			log.Logger.Printf("[req id: %d] gateway.CreateCharge() got error: %v", paymentRequest.ID, err.Error())
+
+			errSaveFailedPaymentRequest := s.Database.UpdateFailedPaymentRequest(ctx, paymentRequest.ID, err.Error())
+			if errSaveFailedPaymentRequest != nil {
+				log.Logger.Printf("[req id: %d] s.Database.UpdateFailedPaymentRequest() got error: %v", paymentRequest.ID, errSaveFailedPaymentRequest)
+			}
+
+			result.Failed++
+			continue
 		}
-	}(ctx)
-}
 
-func (s *SchedulerService) StartProcessPendingPaymentRequests() {
-	ctx := context.Background()
-	go func(ctx context.Context) {
-		for {
-			var paymentRequests []models.PaymentRequests
-			// get pending payment requests
-			err := s.Database.GetPendingPaymentRequests(ctx, &paymentRequests)
+		// save the payment and mark the request as SUCCESS together, request whose payment is not saved is
+		// claimed again after the lease expires
+		err = s.Database.WithTransaction(ctx, func(tx repository.PaymentDatabase) error {
+			err := tx.SavePayment(ctx, models.Payment{
+				OrderID:         paymentRequest.OrderID,
+				UserID:          paymentRequest.UserID,
+				Amount:          paymentRequest.Amount,
+				ExternalID:      externalID,
+				Status:          models.PaymentStatusPending,
+				Provider:        gateway.Name(),
+				GatewayChargeID: charge.ID,
+				InvoiceURL:      charge.PaymentURL,
+				ExpiredTime:     charge.ExpiryTime,
+				CreateTime:      time.Now(),
+			})
 			if err != nil {
-				log.Logger.Printf("s.Database.GetPendingPaymentRequests() got error: %v", err)
-				// give time gap to avoid tight loop
-				time.Sleep(10 * time.Second)
-				continue
+				log.Logger.Printf("[req id: %d] tx.SavePayment() got error: %v", paymentRequest.ID, err)
+				return err
 			}
 
-			for _, paymentRequest := range paymentRequests {
-				// process each payment request
-				externalID := fmt.Sprintf("order-%d", paymentRequest.OrderID)
-				log.Logger.Printf("[DEBUG] Processing payment request ID: %d", paymentRequest.ID)
-
-				// check if invoice has been created
-				paymentInfo, err := s.Database.GetPaymentInfoByOrderID(ctx, paymentRequest.OrderID)
-				if err != nil {
-					log.Logger.Printf("[req id: %d] got error: %v", paymentRequest.ID, err)
-					continue
-				}
-
-				// get user info by grpc
-				userInfo, err := s.UserClient.GetUserInfoByUserId(ctx, paymentInfo.UserID)
-				if err != nil {
-					log.Logger.WithFields(logrus.Fields{
-						"user_id":    paymentInfo.UserID,
-						"payment_id": paymentInfo.ID,
-					}).WithError(err).Errorf("[req id: %d] s.UserClient.GetUserInfoByUserId() got error: %v", paymentRequest.ID, err)
-					continue
-				}
-
-				if paymentInfo.ID != 0 {
-					xenditInvoiceReq := models.XenditInvoiceRequest{
-						ExternalID:  externalID,
-						Amount:      paymentRequest.Amount,
-						Description: fmt.Sprintf("Payment for Order ID %d", paymentRequest.OrderID),
-						PayerEmail:  userInfo.Email, // to do use real email
-					}
-
-					xenditInvoiceRes, err := s.Xendit.CreateInvoice(ctx, xenditInvoiceReq)
-					errLogAudit := s.Database.InsertAuditLog(ctx, models.PaymentAuditLog{
-						OrderID:    paymentInfo.OrderID,
-						UserID:     paymentInfo.UserID,
-						PaymentID:  paymentInfo.ID,
-						ExternalID: paymentInfo.ExternalID,
-						Event:      "CreateInvoice",
-						Actor:      "scheduler_service_process_pending_payment_requests",
-						CreateTime: time.Now(),
-					})
-					if errLogAudit != nil {
-						log.Logger.Printf("[req id: %d] s.Database.InsertAuditLog() got error: %v", paymentRequest.ID, errLogAudit)
-					}
-
-					if err != nil {
-						log.Logger.Printf("[req id: %d] s.Xendit.CreateInvoice() got error: %v", paymentRequest.ID, err.Error())
-
-						errSaveFailedPaymentRequest := s.Database.UpdateFailedPaymentRequest(ctx, paymentRequest.ID, err.Error())
-						if errSaveFailedPaymentRequest != nil {
-							log.Logger.Printf("[req id: %d] s.Database.UpdateFailedPaymentRequest() got error: %v", paymentRequest.ID, errSaveFailedPaymentRequest)
-						}
-
-						continue
-					}
-
-					// update status payment request to SUCCESS
-					err = s.Database.UpdateSuccessPaymentRequest(ctx, paymentRequest.ID)
-					if err != nil {
-						log.Logger.Printf("[req id: %d] s.Database.UpdateSuccessPaymentRequest() got error: %v", paymentRequest.ID, err)
-						continue
-					}
-
-					// save data to table payment
-					err = s.Database.SavePayment(ctx, models.Payment{
-						OrderID:     paymentRequest.OrderID,
-						UserID:      paymentRequest.UserID,
-						Amount:      paymentRequest.Amount,
-						ExternalID:  externalID,
-						Status:      "PENDING",
-						ExpiredTime: xenditInvoiceRes.ExpiryDate,
-						CreateTime:  time.Now(),
-					})
-					if err != nil {
-						log.Logger.Printf("[req id: %d] s.Database.SavePayment() got error: %v", paymentRequest.ID, err)
-						continue
-					}
-				}
+			err = tx.UpdateSuccessPaymentRequest(ctx, paymentRequest.ID)
+			if err != nil {
+				log.Logger.Printf("[req id: %d] tx.UpdateSuccessPaymentRequest() got error: %v", paymentRequest.ID, err)
+				return err
 			}
 
-			time.Sleep(5 * time.Second) // give time gap before next iteration
+			return nil
+		})
+		if err != nil {
+			result.Failed++
+			continue
 		}
-	}(ctx)
+
+		result.Processed++
+	}
+
+	return result, nil
 }
 
-func (s *SchedulerService) StartProcessFailedPaymentRequests() {
-	go func(ctx context.Context) {
-		for {
-			// get list of failed payment requests
-			var paymentRequests []models.PaymentRequests
-			err := s.Database.GetFailedPaymentRequests(ctx, &paymentRequests)
-			if err != nil {
-				log.Logger.Printf("s.Database.GetFailedPaymentRequests() got error: %v", err)
-				time.Sleep(10 * time.Second) // give time gap before next iteration
-				continue
-			}
+// releasePaymentRequests put claimed requests back to pending without calling the dependency whose breaker is
+// open, they are claimed again by the next run.
+func (s *SchedulerService) releasePaymentRequests(ctx context.Context, paymentRequests []models.PaymentRequests, cause error) {
+	log.Logger.WithFields(logrus.Fields{
+		"payment_requests": len(paymentRequests),
+	}).Warnf("ProcessPendingPaymentRequests => payment requests are released: %v", cause)
+
+	for _, paymentRequest := range paymentRequests {
+		err := s.Database.UpdatePendingPaymentRequest(ctx, paymentRequest.ID)
+		if err != nil {
+			log.Logger.Printf("[req id: %d] s.Database.UpdatePendingPaymentRequest() got error: %v", paymentRequest.ID, err)
+		}
+	}
+}
 
-			// update status to PENDING
-			for _, paymentRequest := range paymentRequests {
-				err = s.Database.UpdatePendingPaymentRequest(ctx, paymentRequest.ID)
-				if err != nil {
-					log.Logger.Printf("s.Database.UpdatePendingPaymentRequest() got error: %v", err)
+func (s *SchedulerService) ProcessFailedPaymentRequests(ctx context.Context) (job.Result, error) {
+	// get list of failed payment requests
+	var paymentRequests []models.PaymentRequests
+	err := s.Database.GetFailedPaymentRequests(ctx, &paymentRequests)
+	if err != nil {
+		log.Logger.Printf("s.Database.GetFailedPaymentRequests() got error: %v", err)
+		return job.Result{}, err
+	}
 
-					// another retry process
-					errUpdateStatus := s.Database.UpdateFailedPaymentRequest(ctx, paymentRequest.ID, err.Error())
-					if errUpdateStatus != nil {
-						log.Logger.Printf("s.Database.UpdateFailedPaymentRequest() got error: %v", errUpdateStatus.Error())
-					}
+	// update status to PENDING
+	var result job.Result
+	for _, paymentRequest := range paymentRequests {
+		// stop when the lease is lost or on shutdown, remaining items are handled by the next run
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
 
-					continue
-				}
+		err = s.Database.UpdatePendingPaymentRequest(ctx, paymentRequest.ID)
+		if err != nil {
+			log.Logger.Printf("s.Database.UpdatePendingPaymentRequest() got error: %v", err)
+
+			// another retry process
+			errUpdateStatus := s.Database.UpdateFailedPaymentRequest(ctx, paymentRequest.ID, err.Error())
+			if errUpdateStatus != nil {
+				log.Logger.Printf("s.Database.UpdateFailedPaymentRequest() got error: %v", errUpdateStatus.Error())
 			}
 
-			time.Sleep(1 * time.Minute) // give time gap before next iteration
+			result.Failed++
+			continue
 		}
-	}(context.Background())
+
+		result.Processed++
+	}
+
+	return result, nil
 }
 
-func (s *SchedulerService) StartCheckPendingInvoices() {
-	ticker := time.NewTicker(10 * time.Minute)
+func (s *SchedulerService) CheckPendingInvoices(ctx context.Context) (job.Result, error) {
+	// query pending invoices
+	listPendingInvoices, err := s.Database.GetPendingInvoices(ctx)
+	if err != nil {
+		log.Logger.Printf("s.Database.GetPendingInvoices() got error: %v", err)
+		return job.Result{}, err
+	}
+
+	var result job.Result
+	for _, pendingInvoice := range listPendingInvoices {
+		// stop when the lease is lost or on shutdown, remaining items are handled by the next run
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		gateway, err := s.Gateways.Get(pendingInvoice.Provider)
+		if err != nil {
+			log.Logger.Printf("s.Gateways.Get() got error: %v", err)
+			result.Failed++
+			continue
+		}
+
+		charge, err := gateway.GetCharge(ctx, pendingInvoice.ExternalID)
+		if errors.Is(err, repository.ErrChargeNotFound) {
+			// e.g. midtrans only create the transaction after customer choose the payment method
+			result.Processed++
+			continue
+		}
+
+		if err != nil {
+			log.Logger.Printf("gateway.GetCharge() got error: %v", err)
+			result.Failed++
+			continue
+		}
 
-	go func() {
-		for range ticker.C {
-			// query pending invoices
-			ctx := context.Background()
-			listPendingInvoices, err := s.Database.GetPendingInvoices(ctx)
+		if charge.Status == constant.GatewayStatusPaid {
+			err = s.PaymentService.ProcessPaymentSuccess(ctx, pendingInvoice.OrderID, models.PaymentPaidDetail{
+				PaymentMethod: charge.PaymentMethod,
+				PaidAt:        charge.PaidTime,
+			})
 			if err != nil {
-				log.Logger.Printf("s.Database.GetPendingInvoices() got error: %v", err)
+				log.Logger.Printf("s.PaymentService.ProcessPaymentSuccess() got error: %v", err)
+				result.Failed++
 				continue
 			}
+		}
 
-			for _, pendingInvoice := range listPendingInvoices {
-				invoiceStatus, err := s.Xendit.CheckInvoiceStatus(ctx, pendingInvoice.ExternalID)
-				if err != nil {
-					log.Logger.Printf("s.Xendit.CheckInvoiceStatus() got error: %v", err)
-					continue
-				}
-
-				if invoiceStatus == "PAID" {
-					err = s.PaymentService.ProcessPaymentSuccess(ctx, pendingInvoice.OrderID)
-					if err != nil {
-						log.Logger.Printf("s.PaymentService.ProcessPaymentSuccess() got error: %v", err)
-						continue
-					}
-				}
-			}
+		result.Processed++
+	}
+
+	return result, nil
+}
+
+func (s *SchedulerService) CheckPendingRefunds(ctx context.Context) (job.Result, error) {
+	// query refunds which still processed by xendit
+	pendingRefunds, err := s.Database.GetPendingRefunds(ctx)
+	if err != nil {
+		log.Logger.Printf("s.Database.GetPendingRefunds() got error: %v", err)
+		return job.Result{}, err
+	}
+
+	var result job.Result
+	for _, pendingRefund := range pendingRefunds {
+		// stop when the lease is lost or on shutdown, remaining items are handled by the next run
+		if ctx.Err() != nil {
+			return result, ctx.Err()
 		}
-	}()
+
+		err = s.RefundService.SyncRefundStatus(ctx, pendingRefund)
+		if err != nil {
+			log.Logger.Printf("[refund id: %d] s.RefundService.SyncRefundStatus() got error: %v", pendingRefund.ID, err)
+			result.Failed++
+			continue
+		}
+
+		result.Processed++
+	}
+
+	return result, nil
+}
+
+func (s *SchedulerService) RetryFailedEvents(ctx context.Context) (job.Result, error) {
+	// re-publish failed events marked as retry
+	retried, err := s.FailedEventService.RetryFailedEvents(ctx)
+	if err != nil {
+		log.Logger.Printf("s.FailedEventService.RetryFailedEvents() got error: %v", err)
+		return job.Result{}, err
+	}
+
+	return job.Result{Processed: retried}, nil
+}
+
+func (s *SchedulerService) RelayOutbox(ctx context.Context) (job.Result, error) {
+	// publish payment events stored in outbox
+	published, err := s.OutboxService.RelayOutbox(ctx)
+	if err != nil {
+		log.Logger.Printf("s.OutboxService.RelayOutbox() got error: %v", err)
+		return job.Result{}, err
+	}
+
+	// full batch means there may be more pending outbox
+	return job.Result{
+		Processed: published,
+		HasMore:   published == RelayOutboxBatchSize,
+	}, nil
+}
+
+// ReconcileXenditInvoices reconcile the previous day when it is not reconciled yet.
+func (s *SchedulerService) ReconcileXenditInvoices(ctx context.Context) (job.Result, error) {
+	now := time.Now()
+	periodEnd := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if now.Before(periodEnd.Add(constant.ReconciliationDelay)) {
+		return job.Result{}, nil
+	}
+
+	periodStart := periodEnd.AddDate(0, 0, -1)
+
+	_, err := s.Database.GetReconciliationRunByPeriod(ctx, periodStart, periodEnd)
+	if err == nil {
+		return job.Result{}, nil
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Logger.Printf("s.Database.GetReconciliationRunByPeriod() got error: %v", err)
+		return job.Result{}, err
+	}
+
+	run, err := s.ReconciliationService.Reconcile(ctx, periodStart, periodEnd, constant.ReconciliationActorScheduler)
+	if err != nil {
+		log.Logger.Printf("s.ReconciliationService.Reconcile() got error: %v", err)
+		return job.Result{}, err
+	}
+
+	return job.Result{Processed: run.XenditInvoiceCount}, nil
 }
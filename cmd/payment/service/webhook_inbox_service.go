@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment/cmd/payment/repository"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	RetryWebhookInboxBatchSize = 20
+
+	// received inbox older than this is considered missed by the worker channel
+	WebhookInboxPickupDelay = 30 * time.Second
+
+	// processing inbox older than this is considered stuck, e.g. app restarted while processing
+	WebhookInboxProcessingTimeout = 5 * time.Minute
+)
+
+type WebhookInboxService interface {
+	ReserveWebhook(ctx context.Context, source string, callbackID string, status string) (bool, error)
+	ReleaseWebhook(ctx context.Context, source string, callbackID string, status string) error
+	SaveWebhookInbox(ctx context.Context, inbox *models.WebhookInbox) error
+	GetWebhookInboxByID(ctx context.Context, id int64) (*models.WebhookInbox, error)
+	GetWebhookInboxes(ctx context.Context, filter models.WebhookInboxFilter) ([]models.WebhookInbox, error)
+	GetRetryableWebhookInboxes(ctx context.Context) ([]models.WebhookInbox, error)
+	ClaimWebhookInbox(ctx context.Context, id int64) (bool, error)
+	FinishWebhookInbox(ctx context.Context, id int64, processErr error) error
+}
+
+type webhookInboxService struct {
+	database repository.PaymentDatabase
+	redis    repository.PaymentRedis
+}
+
+func NewWebhookInboxService(database repository.PaymentDatabase, redis repository.PaymentRedis) WebhookInboxService {
+	return &webhookInboxService{
+		database: database,
+		redis:    redis,
+	}
+}
+
+// ReserveWebhook return false when the same webhook callback is already received.
+func (s *webhookInboxService) ReserveWebhook(ctx context.Context, source string, callbackID string, status string) (bool, error) {
+	key := fmt.Sprintf(constant.RedisKeyWebhookDedup, source, callbackID, status)
+	reserved, err := s.redis.SetIfNotExists(ctx, key, constant.WebhookDedupTTL)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"key": key,
+		}).Errorf("s.redis.SetIfNotExists() got error: %v", err)
+
+		return false, err
+	}
+
+	return reserved, nil
+}
+
+// ReleaseWebhook allow the webhook callback to be received again, e.g. when it is failed to be stored.
+func (s *webhookInboxService) ReleaseWebhook(ctx context.Context, source string, callbackID string, status string) error {
+	key := fmt.Sprintf(constant.RedisKeyWebhookDedup, source, callbackID, status)
+	err := s.redis.Delete(ctx, key)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"key": key,
+		}).Errorf("s.redis.Delete() got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *webhookInboxService) SaveWebhookInbox(ctx context.Context, inbox *models.WebhookInbox) error {
+	inbox.Status = constant.WebhookInboxStatusReceived
+	inbox.ReceivedTime = time.Now()
+	inbox.UpdateTime = inbox.ReceivedTime
+
+	err := s.database.SaveWebhookInbox(ctx, inbox)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"source":      inbox.Source,
+			"external_id": inbox.ExternalID,
+		}).Errorf("s.database.SaveWebhookInbox() got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *webhookInboxService) GetWebhookInboxByID(ctx context.Context, id int64) (*models.WebhookInbox, error) {
+	inbox, err := s.database.GetWebhookInboxByID(ctx, id)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id": id,
+		}).Errorf("s.database.GetWebhookInboxByID() got error: %v", err)
+
+		return nil, err
+	}
+
+	return inbox, nil
+}
+
+func (s *webhookInboxService) GetWebhookInboxes(ctx context.Context, filter models.WebhookInboxFilter) ([]models.WebhookInbox, error) {
+	inboxes, err := s.database.GetWebhookInboxes(ctx, filter)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("s.database.GetWebhookInboxes() got error: %v", err)
+
+		return nil, err
+	}
+
+	return inboxes, nil
+}
+
+// GetRetryableWebhookInboxes return inbox which should be picked up again by worker.
+func (s *webhookInboxService) GetRetryableWebhookInboxes(ctx context.Context) ([]models.WebhookInbox, error) {
+	now := time.Now()
+	inboxes, err := s.database.GetRetryableWebhookInboxes(ctx, now.Add(-WebhookInboxPickupDelay), now.Add(-WebhookInboxProcessingTimeout), constant.MaxWebhookInboxAttempts, RetryWebhookInboxBatchSize)
+	if err != nil {
+		log.Logger.Printf("s.database.GetRetryableWebhookInboxes() got error: %v", err)
+		return nil, err
+	}
+
+	return inboxes, nil
+}
+
+// ClaimWebhookInbox return false when inbox is already processed or being processed by other worker.
+func (s *webhookInboxService) ClaimWebhookInbox(ctx context.Context, id int64) (bool, error) {
+	claimed, err := s.database.ClaimWebhookInbox(ctx, id, time.Now().Add(-WebhookInboxProcessingTimeout))
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id": id,
+		}).Errorf("s.database.ClaimWebhookInbox() got error: %v", err)
+
+		return false, err
+	}
+
+	return claimed, nil
+}
+
+// FinishWebhookInbox store processing result of the inbox.
+func (s *webhookInboxService) FinishWebhookInbox(ctx context.Context, id int64, processErr error) error {
+	status := constant.WebhookInboxStatusProcessed
+	result := "OK"
+	switch {
+	case errors.Is(processErr, ErrPaymentAnomaly):
+		// anomaly is checked manually, retrying the webhook only record the same anomaly again
+		result = processErr.Error()
+	case processErr != nil:
+		status = constant.WebhookInboxStatusFailed
+		result = processErr.Error()
+	}
+
+	err := s.database.UpdateWebhookInboxResult(ctx, id, status, result)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id":     id,
+			"status": status,
+		}).Errorf("s.database.UpdateWebhookInboxResult() got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	mocks "payment/cmd/test_mock"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FinishWebhookInbox(t *testing.T) {
+	type mockFields struct {
+		database *mocks.MockPaymentDatabase
+	}
+
+	tests := []struct {
+		name       string
+		processErr error
+		mock       func(mockFields)
+		wantError  error
+	}{
+		{
+			name:       "given_no_process_error_then_it_should_mark_processed",
+			processErr: nil,
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().UpdateWebhookInboxResult(context.Background(), int64(1), constant.WebhookInboxStatusProcessed, "OK").Return(nil)
+			},
+			wantError: nil,
+		},
+		{
+			name:       "given_process_error_then_it_should_mark_failed_with_error_result",
+			processErr: assert.AnError,
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().UpdateWebhookInboxResult(context.Background(), int64(1), constant.WebhookInboxStatusFailed, assert.AnError.Error()).Return(nil)
+			},
+			wantError: nil,
+		},
+		{
+			name:       "given_payment_anomaly_error_then_it_should_mark_processed_so_it_is_not_retried",
+			processErr: fmt.Errorf("%w: webhook amount mismatch", ErrPaymentAnomaly),
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().UpdateWebhookInboxResult(context.Background(), int64(1), constant.WebhookInboxStatusProcessed, "payment anomaly is recorded: webhook amount mismatch").Return(nil)
+			},
+			wantError: nil,
+		},
+		{
+			name:       "given_error_UpdateWebhookInboxResult_then_it_should_return_error",
+			processErr: nil,
+			mock: func(mf mockFields) {
+				mf.database.EXPECT().UpdateWebhookInboxResult(context.Background(), int64(1), constant.WebhookInboxStatusProcessed, "OK").Return(assert.AnError)
+			},
+			wantError: assert.AnError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			log.SetupLogger()
+
+			mock := mockFields{
+				database: mocks.NewMockPaymentDatabase(ctrl),
+			}
+
+			service := &webhookInboxService{
+				database: mock.database,
+			}
+
+			test.mock(mock)
+			gotError := service.FinishWebhookInbox(context.Background(), 1, test.processErr)
+			assert.Equal(t, test.wantError, gotError)
+		})
+	}
+}
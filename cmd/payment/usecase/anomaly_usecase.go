@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"payment/cmd/payment/service"
+	"payment/infrastructure/log"
+	"payment/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultAnomalyListLimit = 50
+	MaxAnomalyListLimit     = 200
+)
+
+var ErrPaymentAnomalyNotFound = errors.New("payment anomaly not found")
+
+type AnomalyUsecase interface {
+	GetPaymentAnomalies(ctx context.Context, filter models.PaymentAnomalyFilter) ([]models.PaymentAnomaly, error)
+	GetPaymentAnomalyDetail(ctx context.Context, anomalyID int64) (*models.PaymentAnomalyDetail, error)
+	ResolvePaymentAnomaly(ctx context.Context, anomalyID int64, param models.ResolvePaymentAnomalyRequest) error
+}
+
+type anomalyUsecase struct {
+	anomalyService service.AnomalyService
+}
+
+func NewAnomalyUsecase(anomalyService service.AnomalyService) AnomalyUsecase {
+	return &anomalyUsecase{
+		anomalyService: anomalyService,
+	}
+}
+
+func (uc *anomalyUsecase) GetPaymentAnomalies(ctx context.Context, filter models.PaymentAnomalyFilter) ([]models.PaymentAnomaly, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAnomalyListLimit
+	}
+
+	if filter.Limit > MaxAnomalyListLimit {
+		filter.Limit = MaxAnomalyListLimit
+	}
+
+	anomalies, err := uc.anomalyService.GetPaymentAnomalies(ctx, filter)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("GetPaymentAnomalies => uc.anomalyService.GetPaymentAnomalies got error: %v", err)
+
+		return nil, err
+	}
+
+	return anomalies, nil
+}
+
+func (uc *anomalyUsecase) GetPaymentAnomalyDetail(ctx context.Context, anomalyID int64) (*models.PaymentAnomalyDetail, error) {
+	detail, err := uc.anomalyService.GetPaymentAnomalyDetail(ctx, anomalyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentAnomalyNotFound
+		}
+
+		log.Logger.WithFields(logrus.Fields{
+			"id": anomalyID,
+		}).Errorf("GetPaymentAnomalyDetail => uc.anomalyService.GetPaymentAnomalyDetail got error: %v", err)
+
+		return nil, err
+	}
+
+	return detail, nil
+}
+
+func (uc *anomalyUsecase) ResolvePaymentAnomaly(ctx context.Context, anomalyID int64, param models.ResolvePaymentAnomalyRequest) error {
+	err := uc.anomalyService.ResolvePaymentAnomaly(ctx, anomalyID, param)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPaymentAnomalyNotFound
+		}
+
+		log.Logger.WithFields(logrus.Fields{
+			"id":    anomalyID,
+			"param": param,
+		}).Errorf("ResolvePaymentAnomaly => uc.anomalyService.ResolvePaymentAnomaly got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
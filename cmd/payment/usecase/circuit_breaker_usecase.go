@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+	"payment/infrastructure/breaker"
+)
+
+type CircuitBreakerUsecase interface {
+	GetCircuitBreakers(ctx context.Context) []breaker.Status
+}
+
+type circuitBreakerUsecase struct {
+	breakers []*breaker.Breaker
+}
+
+func NewCircuitBreakerUsecase(breakers ...*breaker.Breaker) CircuitBreakerUsecase {
+	return &circuitBreakerUsecase{
+		breakers: breakers,
+	}
+}
+
+// GetCircuitBreakers return breakers of the replica serving the request, breaker state is not shared by replicas.
+func (uc *circuitBreakerUsecase) GetCircuitBreakers(ctx context.Context) []breaker.Status {
+	statuses := make([]breaker.Status, 0, len(uc.breakers))
+	for _, cb := range uc.breakers {
+		statuses = append(statuses, cb.Status())
+	}
+
+	return statuses
+}
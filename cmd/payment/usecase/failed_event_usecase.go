@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"payment/cmd/payment/service"
+	"payment/infrastructure/log"
+	"payment/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultFailedEventListLimit = 50
+	MaxFailedEventListLimit     = 200
+	MaxFailedEventBulkSize      = 100
+)
+
+var ErrInvalidFailedEventIDs = errors.New("ids is required and must not exceed 100 items")
+
+type FailedEventUsecase interface {
+	GetFailedEvents(ctx context.Context, filter models.FailedEventFilter) ([]models.FailedEvents, error)
+	ReplayFailedEvents(ctx context.Context, ids []int64, actor string) ([]models.FailedEventReplayResult, error)
+	CloseFailedEvents(ctx context.Context, ids []int64, actor string, notes string) (int64, error)
+}
+
+type failedEventUsecase struct {
+	failedEventService service.FailedEventService
+}
+
+func NewFailedEventUsecase(failedEventService service.FailedEventService) FailedEventUsecase {
+	return &failedEventUsecase{
+		failedEventService: failedEventService,
+	}
+}
+
+func (uc *failedEventUsecase) GetFailedEvents(ctx context.Context, filter models.FailedEventFilter) ([]models.FailedEvents, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultFailedEventListLimit
+	}
+
+	if filter.Limit > MaxFailedEventListLimit {
+		filter.Limit = MaxFailedEventListLimit
+	}
+
+	failedEvents, err := uc.failedEventService.GetFailedEvents(ctx, filter)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("GetFailedEvents => uc.failedEventService.GetFailedEvents got error: %v", err)
+
+		return nil, err
+	}
+
+	return failedEvents, nil
+}
+
+func (uc *failedEventUsecase) ReplayFailedEvents(ctx context.Context, ids []int64, actor string) ([]models.FailedEventReplayResult, error) {
+	if len(ids) == 0 || len(ids) > MaxFailedEventBulkSize {
+		return nil, ErrInvalidFailedEventIDs
+	}
+
+	results, err := uc.failedEventService.ReplayFailedEvents(ctx, ids, actor)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"ids":   ids,
+			"actor": actor,
+		}).Errorf("ReplayFailedEvents => uc.failedEventService.ReplayFailedEvents got error: %v", err)
+
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (uc *failedEventUsecase) CloseFailedEvents(ctx context.Context, ids []int64, actor string, notes string) (int64, error) {
+	if len(ids) == 0 || len(ids) > MaxFailedEventBulkSize {
+		return 0, ErrInvalidFailedEventIDs
+	}
+
+	closed, err := uc.failedEventService.CloseFailedEvents(ctx, ids, actor, notes)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"ids":   ids,
+			"actor": actor,
+		}).Errorf("CloseFailedEvents => uc.failedEventService.CloseFailedEvents got error: %v", err)
+
+		return 0, err
+	}
+
+	return closed, nil
+}
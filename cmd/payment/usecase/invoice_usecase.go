@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+	"payment/cmd/payment/service"
+	"payment/infrastructure/log"
+	"payment/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+type InvoiceUsecase interface {
+	CreateInvoice(ctx context.Context, param models.OrderCreatedEvent) error
+}
+
+type invoiceUsecase struct {
+	invoiceService service.InvoiceService
+}
+
+func NewInvoiceUsecase(invoiceService service.InvoiceService) InvoiceUsecase {
+	return &invoiceUsecase{
+		invoiceService: invoiceService,
+	}
+}
+
+func (uc *invoiceUsecase) CreateInvoice(ctx context.Context, param models.OrderCreatedEvent) error {
+	err := uc.invoiceService.CreateInvoice(ctx, param)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"param": param,
+		}).Errorf("CreateInvoice => uc.invoiceService.CreateInvoice got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"payment/infrastructure/job"
+	"payment/infrastructure/log"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	JobActionTrigger = "trigger"
+	JobActionPause   = "pause"
+	JobActionResume  = "resume"
+)
+
+var ErrInvalidJobAction = errors.New("action must be one of trigger, pause, resume")
+
+type JobUsecase interface {
+	GetJobs(ctx context.Context) ([]job.Status, error)
+	ControlJob(ctx context.Context, name string, action string, actor string) error
+}
+
+type jobUsecase struct {
+	jobManager job.Manager
+}
+
+func NewJobUsecase(jobManager job.Manager) JobUsecase {
+	return &jobUsecase{
+		jobManager: jobManager,
+	}
+}
+
+func (uc *jobUsecase) GetJobs(ctx context.Context) ([]job.Status, error) {
+	statuses, err := uc.jobManager.Statuses(ctx)
+	if err != nil {
+		log.Logger.Errorf("GetJobs => uc.jobManager.Statuses got error: %v", err)
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+// ControlJob trigger, pause or resume the job. It apply to every replica, not only the one serving the request.
+func (uc *jobUsecase) ControlJob(ctx context.Context, name string, action string, actor string) error {
+	var err error
+	switch action {
+	case JobActionTrigger:
+		err = uc.jobManager.Trigger(ctx, name)
+	case JobActionPause:
+		err = uc.jobManager.Pause(ctx, name)
+	case JobActionResume:
+		err = uc.jobManager.Resume(ctx, name)
+	default:
+		return ErrInvalidJobAction
+	}
+
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"job":    name,
+			"action": action,
+			"actor":  actor,
+		}).Errorf("ControlJob => uc.jobManager got error: %v", err)
+
+		return err
+	}
+
+	log.Logger.WithFields(logrus.Fields{
+		"job":    name,
+		"action": action,
+		"actor":  actor,
+	}).Info("ControlJob => job is controlled by admin")
+
+	return nil
+}
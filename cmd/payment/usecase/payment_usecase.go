@@ -14,14 +14,18 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 type PaymentUsecase interface {
-	ProcessPaymentWebhook(ctx context.Context, payload models.XenditWebhookPayload) error
+	ProcessPaymentWebhook(ctx context.Context, payload models.GatewayWebhook) error
 	ProcessPaymentRequest(ctx context.Context, payload models.OrderCreatedEvent) error
 	DownloadPDFInvoice(ctx context.Context, orderID int64) (string, error)
+	GetPaymentInfo(ctx context.Context, orderID int64, userID int64) (*models.Payment, error)
 }
 
+var ErrPaymentNotFound = errors.New("payment not found")
+
 type paymentUsecase struct {
 	Service service.PaymentService
 }
@@ -33,12 +37,25 @@ func NewPaymentUsecase(svc service.PaymentService) PaymentUsecase {
 }
 
 func (uc *paymentUsecase) ProcessPaymentRequest(ctx context.Context, payload models.OrderCreatedEvent) error {
-	err := uc.Service.SavePaymentRequest(ctx, models.PaymentRequests{
-		OrderID:    payload.OrderID,
-		Amount:     payload.TotalAmount,
-		UserID:     payload.UserID,
-		Status:     "PENDING",
-		CreateTime: time.Now(),
+	amount, err := payload.Total()
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"payload": payload,
+		}).Errorf("payload.Total() got error: %v", err)
+
+		return err
+	}
+
+	err = uc.Service.SavePaymentRequest(ctx, models.PaymentRequests{
+		OrderID:       payload.OrderID,
+		Amount:        amount,
+		UserID:        payload.UserID,
+		PaymentMethod: payload.PaymentMethod,
+		CustomerName:  payload.CustomerName,
+		CustomerPhone: payload.CustomerPhone,
+		Items:         payload.Items,
+		Status:        constant.PaymentRequestStatusPending,
+		CreateTime:    time.Now(),
 	})
 	if err != nil {
 		log.Logger.WithFields(logrus.Fields{
@@ -51,6 +68,35 @@ func (uc *paymentUsecase) ProcessPaymentRequest(ctx context.Context, payload mod
 	return nil
 }
 
+func (uc *paymentUsecase) GetPaymentInfo(ctx context.Context, orderID int64, userID int64) (*models.Payment, error) {
+	paymentInfo, err := uc.Service.GetPaymentInfoByOrderID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+
+		log.Logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+			"user_id":  userID,
+		}).Errorf("uc.svc.GetPaymentInfoByOrderID() got error: %v", err)
+
+		return nil, err
+	}
+
+	// do not leak other user's payment, treat it as not found
+	if paymentInfo.UserID != userID {
+		log.Logger.WithFields(logrus.Fields{
+			"order_id":         orderID,
+			"user_id":          userID,
+			"payment_owner_id": paymentInfo.UserID,
+		}).Warn("GetPaymentInfo => user is not the owner of the payment")
+
+		return nil, ErrPaymentNotFound
+	}
+
+	return paymentInfo, nil
+}
+
 func (uc *paymentUsecase) DownloadPDFInvoice(ctx context.Context, orderID int64) (string, error) {
 	paymentDetail, err := uc.Service.GetPaymentInfoByOrderID(ctx, orderID)
 	if err != nil {
@@ -76,9 +122,10 @@ func (uc *paymentUsecase) DownloadPDFInvoice(ctx context.Context, orderID int64)
 	return filePath, nil
 }
 
-func (uc *paymentUsecase) ProcessPaymentWebhook(ctx context.Context, payload models.XenditWebhookPayload) error {
+// ProcessPaymentWebhook apply the webhook status which is already mapped by the gateway.
+func (uc *paymentUsecase) ProcessPaymentWebhook(ctx context.Context, payload models.GatewayWebhook) error {
 	switch payload.Status {
-	case "PAID":
+	case constant.GatewayStatusPaid:
 		orderID := extractExternalIDToOrderId(payload.ExternalID)
 
 		// validate webhook amount before process payment success
@@ -94,13 +141,19 @@ func (uc *paymentUsecase) ProcessPaymentWebhook(ctx context.Context, payload mod
 			return err
 		}
 
-		if amount != payload.Amount {
-			// insert into payment anomaly table
-			errorInvalidAmount := fmt.Sprintf("Webhook amount mismatch: expected %.2f, got %.2f", amount, payload.Amount)
+		if !amount.Equal(payload.Amount) {
+			// insert into payment anomaly table, paid in another currency is not the same as a wrong amount
+			anomalyType := constant.AnomalyTypeInvalidAmount
+			errorInvalidAmount := fmt.Sprintf("Webhook amount mismatch: expected %s, got %s", amount, payload.Amount)
+			if amount.Currency != payload.Amount.Currency {
+				anomalyType = constant.AnomalyTypeCurrencyMismatch
+				errorInvalidAmount = fmt.Sprintf("Webhook currency mismatch: expected %s, got %s", amount, payload.Amount)
+			}
+
 			paymentAnomaly := models.PaymentAnomaly{
 				OrderID:     orderID,
 				ExternalID:  payload.ExternalID,
-				AnomalyType: constant.AnomalyTypeInvalidAmount,
+				AnomalyType: anomalyType,
 				Notes:       errorInvalidAmount,
 				Status:      constant.PaymentAnomalyStatusNeedToCheck,
 				CreateTime:  time.Now(),
@@ -119,12 +172,14 @@ func (uc *paymentUsecase) ProcessPaymentWebhook(ctx context.Context, payload mod
 			log.Logger.WithFields(logrus.Fields{
 				"payload": payload,
 			}).Error(errorInvalidAmount)
-			err = errors.New(errorInvalidAmount)
 
-			return err
+			return fmt.Errorf("%w: %s", service.ErrPaymentAnomaly, errorInvalidAmount)
 		}
 
-		err = uc.Service.ProcessPaymentSuccess(ctx, orderID)
+		err = uc.Service.ProcessPaymentSuccess(ctx, orderID, models.PaymentPaidDetail{
+			PaymentMethod: payload.PaymentMethod,
+			PaidAt:        payload.PaidAt,
+		})
 		if err != nil {
 			log.Logger.WithFields(logrus.Fields{
 				"status":      payload.Status,
@@ -133,15 +188,68 @@ func (uc *paymentUsecase) ProcessPaymentWebhook(ctx context.Context, payload mod
 
 			return err
 		}
-	case "FAILED":
-	case "PENDING":
+	case constant.GatewayStatusExpired:
+		orderID := extractExternalIDToOrderId(payload.ExternalID)
+
+		err := uc.Service.ProcessPaymentExpired(ctx, orderID)
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"status":      payload.Status,
+				"external_id": payload.ExternalID,
+			}).Errorf("uc.svc.ProcessPaymentExpired() got error: %v", err)
+
+			return err
+		}
+	case constant.GatewayStatusFailed:
+		orderID := extractExternalIDToOrderId(payload.ExternalID)
+
+		err := uc.Service.ProcessPaymentFailed(ctx, orderID)
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"status":      payload.Status,
+				"external_id": payload.ExternalID,
+			}).Errorf("uc.svc.ProcessPaymentFailed() got error: %v", err)
+
+			return err
+		}
+	case constant.GatewayStatusRefunded:
+		// refund is created and synced by refund service, notification of it is only logged
+		log.Logger.WithFields(logrus.Fields{
+			"provider":    payload.Provider,
+			"status":      payload.Status,
+			"external_id": payload.ExternalID,
+		}).Info("Payment webhook status refunded.")
+	case constant.GatewayStatusPending:
+		// nothing to do, payment is created as pending
+		log.Logger.WithFields(logrus.Fields{
+			"status":      payload.Status,
+			"external_id": payload.ExternalID,
+		}).Info("Payment webhook status still pending.")
 	default:
 		log.Logger.WithFields(logrus.Fields{
 			"status":      payload.Status,
 			"external_id": payload.ExternalID,
 		}).Infof("[%s] Anomaly Payment Webhook Status not found: %s", payload.ExternalID, payload.Status)
 
-		// maybe store to payment_anomaly table, so we can proceed manually later.
+		// store to payment_anomaly table, so we can proceed manually later.
+		paymentAnomaly := models.PaymentAnomaly{
+			OrderID:     extractExternalIDToOrderId(payload.ExternalID),
+			ExternalID:  payload.ExternalID,
+			AnomalyType: constant.AnomalyTypeUnknownWebhookStatus,
+			Notes:       fmt.Sprintf("Unknown webhook status: %s", payload.Status),
+			Status:      constant.PaymentAnomalyStatusNeedToCheck,
+			CreateTime:  time.Now(),
+		}
+
+		err := uc.Service.SavePaymentAnomaly(ctx, paymentAnomaly)
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"payload":        payload,
+				"paymentAnomaly": paymentAnomaly,
+			}).Errorf("uc.svc.SavePaymentAnomaly() got error: %v", err)
+
+			return err
+		}
 	}
 
 	return nil
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	mocks "payment/cmd/test_mock"
+	"payment/infrastructure/log"
+	"payment/models"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_GetPaymentInfo(t *testing.T) {
+	type mockFields struct {
+		service *mocks.MockPaymentService
+	}
+
+	type args struct {
+		ctx     context.Context
+		orderID int64
+		userID  int64
+	}
+
+	payment := &models.Payment{
+		ID:         10,
+		OrderID:    123,
+		UserID:     222,
+		ExternalID: "order-123",
+		Amount:     models.NewMoney(10000, models.CurrencyIDR),
+		Status:     models.PaymentStatusPaid,
+	}
+
+	tests := []struct {
+		name      string
+		args      args
+		mock      func(mockFields)
+		want      *models.Payment
+		wantError error
+	}{
+		{
+			name: "given_owner_of_the_payment_then_it_should_return_payment",
+			args: args{
+				ctx:     context.Background(),
+				orderID: 123,
+				userID:  222,
+			},
+			mock: func(mf mockFields) {
+				mf.service.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(123)).Return(payment, nil)
+			},
+			want:      payment,
+			wantError: nil,
+		},
+		{
+			name: "given_other_user_then_it_should_return_error_payment_not_found",
+			args: args{
+				ctx:     context.Background(),
+				orderID: 123,
+				userID:  333,
+			},
+			mock: func(mf mockFields) {
+				mf.service.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(123)).Return(payment, nil)
+			},
+			want:      nil,
+			wantError: ErrPaymentNotFound,
+		},
+		{
+			name: "given_payment_does_not_exist_then_it_should_return_error_payment_not_found",
+			args: args{
+				ctx:     context.Background(),
+				orderID: 456,
+				userID:  222,
+			},
+			mock: func(mf mockFields) {
+				mf.service.EXPECT().GetPaymentInfoByOrderID(context.Background(), int64(456)).Return(&models.Payment{}, gorm.ErrRecordNotFound)
+			},
+			want:      nil,
+			wantError: ErrPaymentNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			log.SetupLogger()
+
+			mock := mockFields{
+				service: mocks.NewMockPaymentService(ctrl),
+			}
+
+			uc := &paymentUsecase{
+				Service: mock.service,
+			}
+
+			test.mock(mock)
+			got, gotError := uc.GetPaymentInfo(test.args.ctx, test.args.orderID, test.args.userID)
+			assert.Equal(t, test.want, got)
+			assert.Equal(t, test.wantError, gotError)
+		})
+	}
+}
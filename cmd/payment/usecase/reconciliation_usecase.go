@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"payment/cmd/payment/service"
+	"payment/infrastructure/log"
+	"payment/models"
+	"payment/report"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultReconciliationRunListLimit = 50
+	MaxReconciliationRunListLimit     = 200
+)
+
+type ReconciliationUsecase interface {
+	GetReconciliationRuns(ctx context.Context, filter models.ReconciliationRunFilter) ([]models.ReconciliationRun, error)
+	GetReconciliationDiscrepancies(ctx context.Context, runID int64) ([]models.ReconciliationDiscrepancy, error)
+	ExportReconciliationDiscrepancies(ctx context.Context, runID int64, w io.Writer) error
+}
+
+type reconciliationUsecase struct {
+	reconciliationService service.ReconciliationService
+}
+
+func NewReconciliationUsecase(reconciliationService service.ReconciliationService) ReconciliationUsecase {
+	return &reconciliationUsecase{
+		reconciliationService: reconciliationService,
+	}
+}
+
+func (uc *reconciliationUsecase) GetReconciliationRuns(ctx context.Context, filter models.ReconciliationRunFilter) ([]models.ReconciliationRun, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultReconciliationRunListLimit
+	}
+
+	if filter.Limit > MaxReconciliationRunListLimit {
+		filter.Limit = MaxReconciliationRunListLimit
+	}
+
+	runs, err := uc.reconciliationService.GetReconciliationRuns(ctx, filter)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("GetReconciliationRuns => uc.reconciliationService.GetReconciliationRuns got error: %v", err)
+
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func (uc *reconciliationUsecase) GetReconciliationDiscrepancies(ctx context.Context, runID int64) ([]models.ReconciliationDiscrepancy, error) {
+	discrepancies, err := uc.reconciliationService.GetReconciliationDiscrepancies(ctx, runID)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"run_id": runID,
+		}).Errorf("GetReconciliationDiscrepancies => uc.reconciliationService.GetReconciliationDiscrepancies got error: %v", err)
+
+		return nil, err
+	}
+
+	return discrepancies, nil
+}
+
+// ExportReconciliationDiscrepancies write discrepancies of the run to w as csv.
+func (uc *reconciliationUsecase) ExportReconciliationDiscrepancies(ctx context.Context, runID int64, w io.Writer) error {
+	discrepancies, err := uc.GetReconciliationDiscrepancies(ctx, runID)
+	if err != nil {
+		return err
+	}
+
+	err = report.WriteReconciliationDiscrepanciesCSV(w, discrepancies)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"run_id": runID,
+		}).Errorf("ExportReconciliationDiscrepancies => report.WriteReconciliationDiscrepanciesCSV got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"payment/cmd/payment/service"
+	"payment/infrastructure/log"
+	"payment/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type RefundUsecase interface {
+	CreateRefund(ctx context.Context, param models.RefundRequest) (*models.Refund, error)
+}
+
+type refundUsecase struct {
+	refundService service.RefundService
+}
+
+func NewRefundUsecase(refundService service.RefundService) RefundUsecase {
+	return &refundUsecase{
+		refundService: refundService,
+	}
+}
+
+func (uc *refundUsecase) CreateRefund(ctx context.Context, param models.RefundRequest) (*models.Refund, error) {
+	refund, err := uc.refundService.CreateRefund(ctx, param)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+
+		log.Logger.WithFields(logrus.Fields{
+			"param": param,
+		}).Errorf("CreateRefund => uc.refundService.CreateRefund got error: %v", err)
+
+		return nil, err
+	}
+
+	return refund, nil
+}
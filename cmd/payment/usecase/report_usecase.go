@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"payment/cmd/payment/service"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/report"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrInvalidReportFormat = errors.New("invalid report format")
+	ErrInvalidReportPeriod = errors.New("invalid report period")
+)
+
+type ReportUsecase interface {
+	ExportSettlementReport(ctx context.Context, startTime time.Time, endTime time.Time, format string, w io.Writer) error
+}
+
+type reportUsecase struct {
+	reportService service.ReportService
+}
+
+func NewReportUsecase(reportService service.ReportService) ReportUsecase {
+	return &reportUsecase{
+		reportService: reportService,
+	}
+}
+
+// ExportSettlementReport write settlement report of [startTime, endTime) to w as csv or xlsx.
+func (uc *reportUsecase) ExportSettlementReport(ctx context.Context, startTime time.Time, endTime time.Time, format string, w io.Writer) error {
+	if format != constant.ReportFormatCSV && format != constant.ReportFormatXLSX {
+		return fmt.Errorf("%w: %s", ErrInvalidReportFormat, format)
+	}
+
+	if startTime.IsZero() || endTime.IsZero() || !startTime.Before(endTime) {
+		return fmt.Errorf("%w: from and to are required", ErrInvalidReportPeriod)
+	}
+
+	if endTime.After(startTime.AddDate(0, 0, constant.MaxSettlementReportDays)) {
+		return fmt.Errorf("%w: period is longer than %d days", ErrInvalidReportPeriod, constant.MaxSettlementReportDays)
+	}
+
+	settlementReport, err := uc.reportService.GetSettlementReport(ctx, startTime, endTime)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"start_time": startTime,
+			"end_time":   endTime,
+		}).Errorf("ExportSettlementReport => uc.reportService.GetSettlementReport got error: %v", err)
+
+		return err
+	}
+
+	if format == constant.ReportFormatXLSX {
+		err = report.WriteSettlementReportXLSX(w, settlementReport)
+	} else {
+		err = report.WriteSettlementReportCSV(w, settlementReport)
+	}
+
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"start_time": startTime,
+			"end_time":   endTime,
+			"format":     format,
+		}).Errorf("ExportSettlementReport => write report got error: %v", err)
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,311 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"payment/cmd/payment/repository"
+	"payment/cmd/payment/service"
+	"payment/infrastructure/log"
+	"payment/models"
+	"payment/utils"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	WebhookInboxQueueSize       = 100
+	WebhookInboxProcessTimeout  = 30 * time.Second
+	WebhookInboxRecoverInterval = 30 * time.Second
+	MaxWebhookInboxReplaySize   = 1000
+)
+
+var (
+	ErrInvalidWebhookPayload   = errors.New("invalid webhook payload")
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	ErrUnknownWebhookSource    = errors.New("unknown webhook source")
+	ErrDuplicateWebhook        = errors.New("duplicate webhook")
+)
+
+// header which must not be stored in inbox
+var webhookInboxSecretHeaders = map[string]bool{
+	"x-callback-token": true,
+	"authorization":    true,
+}
+
+type WebhookInboxUsecase interface {
+	ReceiveWebhook(ctx context.Context, provider string, headers http.Header, rawBody []byte) (*models.WebhookInbox, error)
+	ReplayWebhookInbox(ctx context.Context, id int64) error
+	ReplayWebhookInboxes(ctx context.Context, filter models.WebhookInboxFilter) ([]models.WebhookInboxReplayResult, error)
+	StartWorker(ctx context.Context)
+	Shutdown(ctx context.Context) error
+}
+
+type webhookInboxUsecase struct {
+	webhookInboxService service.WebhookInboxService
+	paymentUsecase      PaymentUsecase
+	gateways            repository.PaymentGatewayResolver
+	queue               chan int64
+
+	// guard queue from being written after it is closed on shutdown
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func NewWebhookInboxUsecase(webhookInboxService service.WebhookInboxService, paymentUsecase PaymentUsecase, gateways repository.PaymentGatewayResolver) WebhookInboxUsecase {
+	return &webhookInboxUsecase{
+		webhookInboxService: webhookInboxService,
+		paymentUsecase:      paymentUsecase,
+		gateways:            gateways,
+		queue:               make(chan int64, WebhookInboxQueueSize),
+	}
+}
+
+// ReceiveWebhook verify the webhook by the gateway of the provider, then store it verbatim and queue it
+// to be processed asynchronously.
+func (uc *webhookInboxUsecase) ReceiveWebhook(ctx context.Context, provider string, headers http.Header, rawBody []byte) (*models.WebhookInbox, error) {
+	gateway, err := uc.gateways.Get(provider)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownWebhookSource, err)
+	}
+
+	if err := gateway.VerifyWebhook(headers, rawBody); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhookSignature, err)
+	}
+
+	payload, err := gateway.ParseWebhook(rawBody)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhookPayload, err)
+	}
+
+	storedHeaders := make(map[string]string, len(headers))
+	for key := range headers {
+		if webhookInboxSecretHeaders[strings.ToLower(key)] {
+			continue
+		}
+
+		storedHeaders[key] = headers.Get(key)
+	}
+
+	headersJSON, _ := json.Marshal(storedHeaders)
+
+	// redis is only used to drop duplicate delivery early, payment success is still guarded by order lock
+	// so webhook is still stored when redis is not available.
+	reserved, err := uc.webhookInboxService.ReserveWebhook(ctx, gateway.Name(), payload.CallbackID, payload.Status)
+	if err == nil && !reserved {
+		log.Logger.WithFields(logrus.Fields{
+			"provider":    gateway.Name(),
+			"callback_id": payload.CallbackID,
+			"external_id": payload.ExternalID,
+			"status":      payload.Status,
+		}).Info("ReceiveWebhook => duplicate webhook is ignored")
+
+		return nil, ErrDuplicateWebhook
+	}
+
+	inbox := &models.WebhookInbox{
+		Source:     gateway.Name(),
+		ExternalID: payload.ExternalID,
+		RequestID:  utils.RequestIDFromContext(ctx),
+		Headers:    string(headersJSON),
+		RawBody:    string(rawBody),
+	}
+
+	err = uc.webhookInboxService.SaveWebhookInbox(ctx, inbox)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"provider":    gateway.Name(),
+			"external_id": payload.ExternalID,
+			"status":      payload.Status,
+		}).Errorf("ReceiveWebhook => uc.webhookInboxService.SaveWebhookInbox got error: %v", err)
+
+		// let the provider retry delivery to be received again
+		if reserved {
+			_ = uc.webhookInboxService.ReleaseWebhook(context.Background(), gateway.Name(), payload.CallbackID, payload.Status)
+		}
+
+		return nil, err
+	}
+
+	uc.enqueue(inbox.ID)
+
+	return inbox, nil
+}
+
+func (uc *webhookInboxUsecase) enqueue(id int64) {
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+
+	// inbox is already stored, it is picked up by recover loop after restart
+	if uc.closed {
+		return
+	}
+
+	// do not block the webhook when queue is full, recover loop will pick it up later
+	select {
+	case uc.queue <- id:
+	default:
+		log.Logger.WithFields(logrus.Fields{
+			"id": id,
+		}).Warn("ReceiveWebhook => webhook inbox queue is full")
+	}
+}
+
+// StartWorker process queued inbox and periodically recover inbox which is missed, failed or stuck.
+// Recover loop is stopped when ctx is done, queued inbox is processed until Shutdown.
+func (uc *webhookInboxUsecase) StartWorker(ctx context.Context) {
+	uc.wg.Add(2)
+
+	go func() {
+		defer uc.wg.Done()
+
+		for id := range uc.queue {
+			uc.processQueuedInbox(id)
+		}
+	}()
+
+	go func() {
+		defer uc.wg.Done()
+
+		ticker := time.NewTicker(WebhookInboxRecoverInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			inboxes, err := uc.webhookInboxService.GetRetryableWebhookInboxes(context.Background())
+			if err != nil {
+				continue
+			}
+
+			for _, inbox := range inboxes {
+				if ctx.Err() != nil {
+					break
+				}
+
+				uc.processQueuedInbox(inbox.ID)
+			}
+		}
+	}()
+}
+
+// Shutdown stop queueing new inbox and wait until queued inbox is processed.
+// Inbox which is not processed before ctx is done stays in inbox and is recovered after restart.
+func (uc *webhookInboxUsecase) Shutdown(ctx context.Context) error {
+	uc.mu.Lock()
+	if !uc.closed {
+		uc.closed = true
+		close(uc.queue)
+	}
+	uc.mu.Unlock()
+
+	return utils.WaitContext(ctx, &uc.wg)
+}
+
+func (uc *webhookInboxUsecase) processQueuedInbox(id int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), WebhookInboxProcessTimeout)
+	defer cancel()
+
+	claimed, err := uc.webhookInboxService.ClaimWebhookInbox(ctx, id)
+	if err != nil || !claimed {
+		return
+	}
+
+	inbox, err := uc.webhookInboxService.GetWebhookInboxByID(ctx, id)
+	if err != nil {
+		return
+	}
+
+	_ = uc.process(ctx, *inbox)
+}
+
+// ReplayWebhookInbox process the inbox again regardless of its current status.
+func (uc *webhookInboxUsecase) ReplayWebhookInbox(ctx context.Context, id int64) error {
+	inbox, err := uc.webhookInboxService.GetWebhookInboxByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return uc.process(ctx, *inbox)
+}
+
+// ReplayWebhookInboxes replay every inbox received in the filter time range, ordered by received time.
+func (uc *webhookInboxUsecase) ReplayWebhookInboxes(ctx context.Context, filter models.WebhookInboxFilter) ([]models.WebhookInboxReplayResult, error) {
+	if filter.Limit <= 0 || filter.Limit > MaxWebhookInboxReplaySize {
+		filter.Limit = MaxWebhookInboxReplaySize
+	}
+
+	inboxes, err := uc.webhookInboxService.GetWebhookInboxes(ctx, filter)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"filter": filter,
+		}).Errorf("ReplayWebhookInboxes => uc.webhookInboxService.GetWebhookInboxes got error: %v", err)
+
+		return nil, err
+	}
+
+	results := make([]models.WebhookInboxReplayResult, 0, len(inboxes))
+	for _, inbox := range inboxes {
+		err = uc.process(ctx, inbox)
+		if err != nil {
+			results = append(results, models.WebhookInboxReplayResult{
+				ID:      inbox.ID,
+				Message: err.Error(),
+			})
+			continue
+		}
+
+		results = append(results, models.WebhookInboxReplayResult{
+			ID:      inbox.ID,
+			Success: true,
+		})
+	}
+
+	return results, nil
+}
+
+func (uc *webhookInboxUsecase) process(ctx context.Context, inbox models.WebhookInbox) error {
+	// events published from this webhook keep the request id of the webhook as correlation id
+	if inbox.RequestID != "" {
+		ctx = utils.WithRequestID(ctx, inbox.RequestID)
+	}
+
+	// inbox source is the provider which sent the webhook
+	var payload models.GatewayWebhook
+	gateway, err := uc.gateways.Get(inbox.Source)
+	if err == nil {
+		payload, err = gateway.ParseWebhook([]byte(inbox.RawBody))
+		if err != nil {
+			err = fmt.Errorf("%w: %v", ErrInvalidWebhookPayload, err)
+		}
+	}
+
+	if err == nil {
+		err = uc.paymentUsecase.ProcessPaymentWebhook(ctx, payload)
+	}
+
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"id":          inbox.ID,
+			"external_id": inbox.ExternalID,
+			"attempts":    inbox.Attempts + 1,
+		}).Errorf("process webhook inbox got error: %v", err)
+	}
+
+	errFinish := uc.webhookInboxService.FinishWebhookInbox(ctx, inbox.ID, err)
+	if errFinish != nil && err == nil {
+		return errFinish
+	}
+
+	return err
+}
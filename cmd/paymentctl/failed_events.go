@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"payment/infrastructure/constant"
+	"payment/models"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const cliActor = "paymentctl"
+
+func (c *cli) failedEvents(ctx context.Context, action string, args []string) error {
+	fs := newFlagSet("failed-events " + action)
+	status := fs.Int("status", constant.FailedPublishEventStatusNeedToCheck, "filter by status, 0 for all")
+	limit := fs.Int("limit", 50, "max rows")
+	offset := fs.Int("offset", 0, "rows offset")
+	ids := fs.String("ids", "", "comma separated failed event ids")
+	notes := fs.String("notes", "", "closing notes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		failedEvents, err := c.failedEventService.GetFailedEvents(ctx, models.FailedEventFilter{
+			Status: *status,
+			Limit:  *limit,
+			Offset: *offset,
+		})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tORDER_ID\tEVENT_TYPE\tSTATUS\tRETRY\tCREATED\tNOTES")
+		for _, e := range failedEvents {
+			fmt.Fprintf(w, "%d\t%d\t%s\t%d\t%d\t%s\t%s\n", e.ID, e.OrderID, e.EventType, e.Status, e.RetryCount, e.CreateTime.Format(time.DateTime), e.Notes)
+		}
+
+		return w.Flush()
+	case "replay":
+		parsedIDs, err := parseIDs(*ids)
+		if err != nil {
+			return err
+		}
+
+		results, err := c.failedEventService.ReplayFailedEvents(ctx, parsedIDs, cliActor)
+		if err != nil {
+			return err
+		}
+
+		for _, result := range results {
+			if result.Success {
+				fmt.Printf("%d\treplayed\n", result.ID)
+				continue
+			}
+
+			fmt.Printf("%d\tfailed: %s\n", result.ID, result.Message)
+		}
+
+		return nil
+	case "close":
+		parsedIDs, err := parseIDs(*ids)
+		if err != nil {
+			return err
+		}
+
+		if *notes == "" {
+			return errors.New("-notes is required")
+		}
+
+		closed, err := c.failedEventService.CloseFailedEvents(ctx, parsedIDs, cliActor, *notes)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("closed %d of %d failed events\n", closed, len(parsedIDs))
+
+		return nil
+	default:
+		return fmt.Errorf("unknown failed-events action: %s", action)
+	}
+}
+
+func parseIDs(value string) ([]int64, error) {
+	if value == "" {
+		return nil, errors.New("-ids is required")
+	}
+
+	var ids []int64
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q", part)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
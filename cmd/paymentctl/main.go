@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"payment/cmd/payment/repository"
+	"payment/cmd/payment/resource"
+	"payment/cmd/payment/service"
+	"payment/cmd/payment/usecase"
+	"payment/config"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+)
+
+// paymentctl is an operational CLI, run it from the repository root so config can be loaded.
+//
+//	go run ./cmd/paymentctl failed-events list -status=99
+//	go run ./cmd/paymentctl failed-events replay -ids=1,2,3
+//	go run ./cmd/paymentctl failed-events close -ids=1,2,3 -notes="published manually"
+//	go run ./cmd/paymentctl webhook-inbox replay -id=10
+//	go run ./cmd/paymentctl webhook-inbox replay -from="2026-01-02 10:00:00" -to="2026-01-02 11:00:00"
+//	go run ./cmd/paymentctl reconciliation run -from=2026-01-01 -to=2026-01-31
+//	go run ./cmd/paymentctl reconciliation export -id=5 -out=reconciliation.csv
+//	go run ./cmd/paymentctl report settlement -from=2026-01-01 -to=2026-01-31 -format=xlsx -out=settlement.xlsx
+func main() {
+	if len(os.Args) < 3 {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := run(os.Args[1], os.Args[2], os.Args[3:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		usage()
+		os.Exit(1)
+	}
+}
+
+func run(command string, action string, args []string) error {
+	// init config
+	cfg := config.LoadConfig()
+
+	// setup logger
+	log.SetupLogger()
+
+	// init connection
+	db := resource.InitDb(&cfg)
+	redisClient := resource.InitRedis(&cfg)
+
+	databaseRepository := repository.NewPaymentDatabase(db)
+	redisRepository := repository.NewPaymentRedis(redisClient)
+	xenditRepository := repository.NewXenditClient(cfg.Xendit)
+
+	paymentService := service.NewPaymentService(databaseRepository, redisRepository)
+	paymentUsecase := usecase.NewPaymentUsecase(paymentService)
+
+	app := &cli{
+		failedEventService:  service.NewFailedEventService(databaseRepository),
+		webhookInboxService: service.NewWebhookInboxService(databaseRepository, redisRepository),
+
+		reconciliationService: service.NewReconciliationService(databaseRepository, xenditRepository),
+		reportUsecase:         usecase.NewReportUsecase(service.NewReportService(databaseRepository)),
+	}
+	midtransBreaker := resource.InitCircuitBreaker(&cfg, constant.CircuitBreakerMidtrans, repository.IsMidtransFailure)
+	app.webhookInboxUsecase = usecase.NewWebhookInboxUsecase(app.webhookInboxService, paymentUsecase, resource.InitPaymentGateways(&cfg, xenditRepository, midtransBreaker))
+
+	ctx := context.Background()
+	switch command {
+	case "failed-events":
+		return app.failedEvents(ctx, action, args)
+	case "webhook-inbox":
+		return app.webhookInbox(ctx, action, args)
+	case "reconciliation":
+		return app.reconciliation(ctx, action, args)
+	case "report":
+		return app.report(ctx, action, args)
+	default:
+		return fmt.Errorf("unknown command: %s", command)
+	}
+}
+
+type cli struct {
+	failedEventService  service.FailedEventService
+	webhookInboxService service.WebhookInboxService
+	webhookInboxUsecase usecase.WebhookInboxUsecase
+
+	reconciliationService service.ReconciliationService
+	reportUsecase         usecase.ReportUsecase
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: paymentctl <command> <action> [flags]
+
+commands:
+  failed-events list    [-status=99] [-limit=50] [-offset=0]
+  failed-events replay  -ids=1,2,3
+  failed-events close   -ids=1,2,3 -notes="reason"
+  webhook-inbox list    [-status=FAILED] [-from="YYYY-MM-DD HH:MM:SS"] [-to="YYYY-MM-DD HH:MM:SS"] [-limit=50]
+  webhook-inbox replay  -id=10
+  webhook-inbox replay  -from="YYYY-MM-DD HH:MM:SS" -to="YYYY-MM-DD HH:MM:SS" [-status=FAILED]
+  reconciliation run    -from=YYYY-MM-DD [-to=YYYY-MM-DD]
+  reconciliation list   [-limit=50]
+  reconciliation export -id=5 [-out=file.csv]
+  report settlement     -from=YYYY-MM-DD -to=YYYY-MM-DD [-format=csv|xlsx] [-out=file]`)
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
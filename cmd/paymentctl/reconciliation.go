@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"payment/models"
+	"payment/report"
+	"text/tabwriter"
+	"time"
+)
+
+func (c *cli) reconciliation(ctx context.Context, action string, args []string) error {
+	fs := newFlagSet("reconciliation " + action)
+	id := fs.Int64("id", 0, "reconciliation run id")
+	from := fs.String("from", "", "first day (inclusive), local time YYYY-MM-DD")
+	to := fs.String("to", "", "last day (inclusive), local time YYYY-MM-DD, default to -from")
+	limit := fs.Int("limit", 50, "max rows")
+	out := fs.String("out", "", "csv file path, default to stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch action {
+	case "run":
+		if *from == "" {
+			return errors.New("-from is required")
+		}
+
+		if *to == "" {
+			*to = *from
+		}
+
+		periodStart, err := time.ParseInLocation(time.DateOnly, *from, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid -from: %w", err)
+		}
+
+		periodEnd, err := time.ParseInLocation(time.DateOnly, *to, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+
+		periodEnd = periodEnd.AddDate(0, 0, 1)
+		if !periodStart.Before(periodEnd) {
+			return errors.New("-from must not be after -to")
+		}
+
+		run, err := c.reconciliationService.Reconcile(ctx, periodStart, periodEnd, cliActor)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("run %d: %d xendit invoices, %d payments, %d discrepancies\n", run.ID, run.XenditInvoiceCount, run.LocalPaymentCount, run.DiscrepancyCount)
+
+		return nil
+	case "list":
+		runs, err := c.reconciliationService.GetReconciliationRuns(ctx, models.ReconciliationRunFilter{
+			Limit: *limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tPERIOD_START\tPERIOD_END\tINVOICES\tPAYMENTS\tDISCREPANCIES\tTRIGGERED_BY\tCREATED")
+		for _, run := range runs {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%d\t%s\t%s\n", run.ID, run.PeriodStart.Format(time.DateTime), run.PeriodEnd.Format(time.DateTime),
+				run.XenditInvoiceCount, run.LocalPaymentCount, run.DiscrepancyCount, run.TriggeredBy, run.CreateTime.Format(time.DateTime))
+		}
+
+		return w.Flush()
+	case "export":
+		if *id == 0 {
+			return errors.New("-id is required")
+		}
+
+		discrepancies, err := c.reconciliationService.GetReconciliationDiscrepancies(ctx, *id)
+		if err != nil {
+			return err
+		}
+
+		if *out == "" {
+			return report.WriteReconciliationDiscrepanciesCSV(os.Stdout, discrepancies)
+		}
+
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if err = report.WriteReconciliationDiscrepanciesCSV(file, discrepancies); err != nil {
+			return err
+		}
+
+		fmt.Printf("exported %d discrepancies to %s\n", len(discrepancies), *out)
+
+		return file.Close()
+	default:
+		return fmt.Errorf("unknown reconciliation action: %s", action)
+	}
+}
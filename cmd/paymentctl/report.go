@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"payment/infrastructure/constant"
+	"time"
+)
+
+func (c *cli) report(ctx context.Context, action string, args []string) error {
+	fs := newFlagSet("report " + action)
+	from := fs.String("from", "", "first day (inclusive), local time YYYY-MM-DD")
+	to := fs.String("to", "", "last day (inclusive), local time YYYY-MM-DD")
+	format := fs.String("format", constant.ReportFormatCSV, "csv or xlsx")
+	out := fs.String("out", "", "output file path, default to stdout for csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch action {
+	case "settlement":
+		if *from == "" || *to == "" {
+			return errors.New("-from and -to are required")
+		}
+
+		startTime, err := time.ParseInLocation(time.DateOnly, *from, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid -from: %w", err)
+		}
+
+		endTime, err := time.ParseInLocation(time.DateOnly, *to, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+
+		// xlsx is binary, do not write it to terminal
+		if *out == "" && *format != constant.ReportFormatCSV {
+			return errors.New("-out is required for " + *format)
+		}
+
+		var w io.Writer = os.Stdout
+		if *out != "" {
+			file, err := os.Create(*out)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			w = file
+		}
+
+		err = c.reportUsecase.ExportSettlementReport(ctx, startTime, endTime.AddDate(0, 0, 1), *format, w)
+		if err != nil {
+			return err
+		}
+
+		if *out != "" {
+			fmt.Printf("exported settlement report to %s\n", *out)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown report action: %s", action)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"payment/models"
+	"text/tabwriter"
+	"time"
+)
+
+func (c *cli) webhookInbox(ctx context.Context, action string, args []string) error {
+	fs := newFlagSet("webhook-inbox " + action)
+	id := fs.Int64("id", 0, "webhook inbox id")
+	status := fs.String("status", "", "filter by status, empty for all")
+	from := fs.String("from", "", "received time from (inclusive), local time YYYY-MM-DD HH:MM:SS")
+	to := fs.String("to", "", "received time to (exclusive), local time YYYY-MM-DD HH:MM:SS")
+	limit := fs.Int("limit", 50, "max rows")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filter := models.WebhookInboxFilter{
+		Status: *status,
+		Limit:  *limit,
+	}
+
+	var err error
+	if filter.StartTime, err = parseTime(*from); err != nil {
+		return err
+	}
+
+	if filter.EndTime, err = parseTime(*to); err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		inboxes, err := c.webhookInboxService.GetWebhookInboxes(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tEXTERNAL_ID\tSTATUS\tATTEMPTS\tRECEIVED\tRESULT")
+		for _, inbox := range inboxes {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\n", inbox.ID, inbox.ExternalID, inbox.Status, inbox.Attempts, inbox.ReceivedTime.Format(time.DateTime), inbox.Result)
+		}
+
+		return w.Flush()
+	case "replay":
+		if *id != 0 {
+			err = c.webhookInboxUsecase.ReplayWebhookInbox(ctx, *id)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%d\treplayed\n", *id)
+
+			return nil
+		}
+
+		if filter.StartTime.IsZero() || filter.EndTime.IsZero() {
+			return errors.New("-id or both -from and -to are required")
+		}
+
+		// replay the whole range, not only the listed page
+		filter.Limit = 0
+		results, err := c.webhookInboxUsecase.ReplayWebhookInboxes(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		for _, result := range results {
+			if result.Success {
+				fmt.Printf("%d\treplayed\n", result.ID)
+				continue
+			}
+
+			fmt.Printf("%d\tfailed: %s\n", result.ID, result.Message)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown webhook-inbox action: %s", action)
+	}
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	parsed, err := time.ParseInLocation(time.DateTime, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected YYYY-MM-DD HH:MM:SS", value)
+	}
+
+	return parsed, nil
+}
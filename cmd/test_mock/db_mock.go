@@ -6,8 +6,10 @@ package mocks
 
 import (
 	context "context"
+	repository "payment/cmd/payment/repository"
 	models "payment/models"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -36,10 +38,10 @@ func (m *MockPaymentDatabase) EXPECT() *MockPaymentDatabaseMockRecorder {
 }
 
 // CheckPaymentAmountByOrderID mocks base method.
-func (m *MockPaymentDatabase) CheckPaymentAmountByOrderID(ctx context.Context, orderID int64) (float64, error) {
+func (m *MockPaymentDatabase) CheckPaymentAmountByOrderID(ctx context.Context, orderID int64) (models.Money, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CheckPaymentAmountByOrderID", ctx, orderID)
-	ret0, _ := ret[0].(float64)
+	ret0, _ := ret[0].(models.Money)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -50,6 +52,51 @@ func (mr *MockPaymentDatabaseMockRecorder) CheckPaymentAmountByOrderID(ctx, orde
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPaymentAmountByOrderID", reflect.TypeOf((*MockPaymentDatabase)(nil).CheckPaymentAmountByOrderID), ctx, orderID)
 }
 
+// ClaimPaymentRequests mocks base method.
+func (m *MockPaymentDatabase) ClaimPaymentRequests(ctx context.Context, claimedBy string, leaseExpireTime time.Time, limit int) ([]models.PaymentRequests, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimPaymentRequests", ctx, claimedBy, leaseExpireTime, limit)
+	ret0, _ := ret[0].([]models.PaymentRequests)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimPaymentRequests indicates an expected call of ClaimPaymentRequests.
+func (mr *MockPaymentDatabaseMockRecorder) ClaimPaymentRequests(ctx, claimedBy, leaseExpireTime, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPaymentRequests", reflect.TypeOf((*MockPaymentDatabase)(nil).ClaimPaymentRequests), ctx, claimedBy, leaseExpireTime, limit)
+}
+
+// ClaimWebhookInbox mocks base method.
+func (m *MockPaymentDatabase) ClaimWebhookInbox(ctx context.Context, id int64, staleBefore time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookInbox", ctx, id, staleBefore)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookInbox indicates an expected call of ClaimWebhookInbox.
+func (mr *MockPaymentDatabaseMockRecorder) ClaimWebhookInbox(ctx, id, staleBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookInbox", reflect.TypeOf((*MockPaymentDatabase)(nil).ClaimWebhookInbox), ctx, id, staleBefore)
+}
+
+// GetAuditLogsByOrderID mocks base method.
+func (m *MockPaymentDatabase) GetAuditLogsByOrderID(ctx context.Context, orderID int64) ([]models.PaymentAuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditLogsByOrderID", ctx, orderID)
+	ret0, _ := ret[0].([]models.PaymentAuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditLogsByOrderID indicates an expected call of GetAuditLogsByOrderID.
+func (mr *MockPaymentDatabaseMockRecorder) GetAuditLogsByOrderID(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLogsByOrderID", reflect.TypeOf((*MockPaymentDatabase)(nil).GetAuditLogsByOrderID), ctx, orderID)
+}
+
 // GetExpiredPendingPayments mocks base method.
 func (m *MockPaymentDatabase) GetExpiredPendingPayments(ctx context.Context) ([]models.Payment, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +112,36 @@ func (mr *MockPaymentDatabaseMockRecorder) GetExpiredPendingPayments(ctx interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredPendingPayments", reflect.TypeOf((*MockPaymentDatabase)(nil).GetExpiredPendingPayments), ctx)
 }
 
+// GetFailedEvents mocks base method.
+func (m *MockPaymentDatabase) GetFailedEvents(ctx context.Context, filter models.FailedEventFilter) ([]models.FailedEvents, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailedEvents", ctx, filter)
+	ret0, _ := ret[0].([]models.FailedEvents)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailedEvents indicates an expected call of GetFailedEvents.
+func (mr *MockPaymentDatabaseMockRecorder) GetFailedEvents(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailedEvents", reflect.TypeOf((*MockPaymentDatabase)(nil).GetFailedEvents), ctx, filter)
+}
+
+// GetFailedEventsByIDs mocks base method.
+func (m *MockPaymentDatabase) GetFailedEventsByIDs(ctx context.Context, ids []int64) ([]models.FailedEvents, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailedEventsByIDs", ctx, ids)
+	ret0, _ := ret[0].([]models.FailedEvents)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailedEventsByIDs indicates an expected call of GetFailedEventsByIDs.
+func (mr *MockPaymentDatabaseMockRecorder) GetFailedEventsByIDs(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailedEventsByIDs", reflect.TypeOf((*MockPaymentDatabase)(nil).GetFailedEventsByIDs), ctx, ids)
+}
+
 // GetFailedPaymentRequests mocks base method.
 func (m *MockPaymentDatabase) GetFailedPaymentRequests(ctx context.Context, paymentRequests *[]models.PaymentRequests) error {
 	m.ctrl.T.Helper()
@@ -79,6 +156,36 @@ func (mr *MockPaymentDatabaseMockRecorder) GetFailedPaymentRequests(ctx, payment
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailedPaymentRequests", reflect.TypeOf((*MockPaymentDatabase)(nil).GetFailedPaymentRequests), ctx, paymentRequests)
 }
 
+// GetPaymentAnomalies mocks base method.
+func (m *MockPaymentDatabase) GetPaymentAnomalies(ctx context.Context, filter models.PaymentAnomalyFilter) ([]models.PaymentAnomaly, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentAnomalies", ctx, filter)
+	ret0, _ := ret[0].([]models.PaymentAnomaly)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentAnomalies indicates an expected call of GetPaymentAnomalies.
+func (mr *MockPaymentDatabaseMockRecorder) GetPaymentAnomalies(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentAnomalies", reflect.TypeOf((*MockPaymentDatabase)(nil).GetPaymentAnomalies), ctx, filter)
+}
+
+// GetPaymentAnomalyByID mocks base method.
+func (m *MockPaymentDatabase) GetPaymentAnomalyByID(ctx context.Context, anomalyID int64) (*models.PaymentAnomaly, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentAnomalyByID", ctx, anomalyID)
+	ret0, _ := ret[0].(*models.PaymentAnomaly)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentAnomalyByID indicates an expected call of GetPaymentAnomalyByID.
+func (mr *MockPaymentDatabaseMockRecorder) GetPaymentAnomalyByID(ctx, anomalyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentAnomalyByID", reflect.TypeOf((*MockPaymentDatabase)(nil).GetPaymentAnomalyByID), ctx, anomalyID)
+}
+
 // GetPaymentInfoByOrderID mocks base method.
 func (m *MockPaymentDatabase) GetPaymentInfoByOrderID(ctx context.Context, orderID int64) (*models.Payment, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +201,51 @@ func (mr *MockPaymentDatabaseMockRecorder) GetPaymentInfoByOrderID(ctx, orderID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentInfoByOrderID", reflect.TypeOf((*MockPaymentDatabase)(nil).GetPaymentInfoByOrderID), ctx, orderID)
 }
 
+// GetPaymentInfoByOrderIDForUpdate mocks base method.
+func (m *MockPaymentDatabase) GetPaymentInfoByOrderIDForUpdate(ctx context.Context, orderID int64) (*models.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentInfoByOrderIDForUpdate", ctx, orderID)
+	ret0, _ := ret[0].(*models.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentInfoByOrderIDForUpdate indicates an expected call of GetPaymentInfoByOrderIDForUpdate.
+func (mr *MockPaymentDatabaseMockRecorder) GetPaymentInfoByOrderIDForUpdate(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentInfoByOrderIDForUpdate", reflect.TypeOf((*MockPaymentDatabase)(nil).GetPaymentInfoByOrderIDForUpdate), ctx, orderID)
+}
+
+// GetPaymentsByCreateTime mocks base method.
+func (m *MockPaymentDatabase) GetPaymentsByCreateTime(ctx context.Context, startTime, endTime time.Time) ([]models.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentsByCreateTime", ctx, startTime, endTime)
+	ret0, _ := ret[0].([]models.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentsByCreateTime indicates an expected call of GetPaymentsByCreateTime.
+func (mr *MockPaymentDatabaseMockRecorder) GetPaymentsByCreateTime(ctx, startTime, endTime interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentsByCreateTime", reflect.TypeOf((*MockPaymentDatabase)(nil).GetPaymentsByCreateTime), ctx, startTime, endTime)
+}
+
+// GetPaymentsByExternalIDs mocks base method.
+func (m *MockPaymentDatabase) GetPaymentsByExternalIDs(ctx context.Context, externalIDs []string) ([]models.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentsByExternalIDs", ctx, externalIDs)
+	ret0, _ := ret[0].([]models.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentsByExternalIDs indicates an expected call of GetPaymentsByExternalIDs.
+func (mr *MockPaymentDatabaseMockRecorder) GetPaymentsByExternalIDs(ctx, externalIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentsByExternalIDs", reflect.TypeOf((*MockPaymentDatabase)(nil).GetPaymentsByExternalIDs), ctx, externalIDs)
+}
+
 // GetPendingInvoices mocks base method.
 func (m *MockPaymentDatabase) GetPendingInvoices(ctx context.Context) ([]models.Payment, error) {
 	m.ctrl.T.Helper()
@@ -109,18 +261,169 @@ func (mr *MockPaymentDatabaseMockRecorder) GetPendingInvoices(ctx interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingInvoices", reflect.TypeOf((*MockPaymentDatabase)(nil).GetPendingInvoices), ctx)
 }
 
-// GetPendingPaymentRequests mocks base method.
-func (m *MockPaymentDatabase) GetPendingPaymentRequests(ctx context.Context, paymentRequests *[]models.PaymentRequests) error {
+// GetPendingOutbox mocks base method.
+func (m *MockPaymentDatabase) GetPendingOutbox(ctx context.Context, limit int) ([]models.Outbox, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPendingPaymentRequests", ctx, paymentRequests)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetPendingOutbox", ctx, limit)
+	ret0, _ := ret[0].([]models.Outbox)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingOutbox indicates an expected call of GetPendingOutbox.
+func (mr *MockPaymentDatabaseMockRecorder) GetPendingOutbox(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingOutbox", reflect.TypeOf((*MockPaymentDatabase)(nil).GetPendingOutbox), ctx, limit)
+}
+
+// GetPendingRefunds mocks base method.
+func (m *MockPaymentDatabase) GetPendingRefunds(ctx context.Context) ([]models.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingRefunds", ctx)
+	ret0, _ := ret[0].([]models.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingRefunds indicates an expected call of GetPendingRefunds.
+func (mr *MockPaymentDatabaseMockRecorder) GetPendingRefunds(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingRefunds", reflect.TypeOf((*MockPaymentDatabase)(nil).GetPendingRefunds), ctx)
+}
+
+// GetReconciliationDiscrepancies mocks base method.
+func (m *MockPaymentDatabase) GetReconciliationDiscrepancies(ctx context.Context, runID int64) ([]models.ReconciliationDiscrepancy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReconciliationDiscrepancies", ctx, runID)
+	ret0, _ := ret[0].([]models.ReconciliationDiscrepancy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReconciliationDiscrepancies indicates an expected call of GetReconciliationDiscrepancies.
+func (mr *MockPaymentDatabaseMockRecorder) GetReconciliationDiscrepancies(ctx, runID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReconciliationDiscrepancies", reflect.TypeOf((*MockPaymentDatabase)(nil).GetReconciliationDiscrepancies), ctx, runID)
+}
+
+// GetReconciliationRunByPeriod mocks base method.
+func (m *MockPaymentDatabase) GetReconciliationRunByPeriod(ctx context.Context, periodStart, periodEnd time.Time) (*models.ReconciliationRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReconciliationRunByPeriod", ctx, periodStart, periodEnd)
+	ret0, _ := ret[0].(*models.ReconciliationRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReconciliationRunByPeriod indicates an expected call of GetReconciliationRunByPeriod.
+func (mr *MockPaymentDatabaseMockRecorder) GetReconciliationRunByPeriod(ctx, periodStart, periodEnd interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReconciliationRunByPeriod", reflect.TypeOf((*MockPaymentDatabase)(nil).GetReconciliationRunByPeriod), ctx, periodStart, periodEnd)
+}
+
+// GetReconciliationRuns mocks base method.
+func (m *MockPaymentDatabase) GetReconciliationRuns(ctx context.Context, filter models.ReconciliationRunFilter) ([]models.ReconciliationRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReconciliationRuns", ctx, filter)
+	ret0, _ := ret[0].([]models.ReconciliationRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReconciliationRuns indicates an expected call of GetReconciliationRuns.
+func (mr *MockPaymentDatabaseMockRecorder) GetReconciliationRuns(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReconciliationRuns", reflect.TypeOf((*MockPaymentDatabase)(nil).GetReconciliationRuns), ctx, filter)
+}
+
+// GetRefundedAmountByOrderID mocks base method.
+func (m *MockPaymentDatabase) GetRefundedAmountByOrderID(ctx context.Context, orderID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefundedAmountByOrderID", ctx, orderID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefundedAmountByOrderID indicates an expected call of GetRefundedAmountByOrderID.
+func (mr *MockPaymentDatabaseMockRecorder) GetRefundedAmountByOrderID(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefundedAmountByOrderID", reflect.TypeOf((*MockPaymentDatabase)(nil).GetRefundedAmountByOrderID), ctx, orderID)
+}
+
+// GetRetryableWebhookInboxes mocks base method.
+func (m *MockPaymentDatabase) GetRetryableWebhookInboxes(ctx context.Context, receivedBefore, staleBefore time.Time, maxAttempts, limit int) ([]models.WebhookInbox, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRetryableWebhookInboxes", ctx, receivedBefore, staleBefore, maxAttempts, limit)
+	ret0, _ := ret[0].([]models.WebhookInbox)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRetryableWebhookInboxes indicates an expected call of GetRetryableWebhookInboxes.
+func (mr *MockPaymentDatabaseMockRecorder) GetRetryableWebhookInboxes(ctx, receivedBefore, staleBefore, maxAttempts, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRetryableWebhookInboxes", reflect.TypeOf((*MockPaymentDatabase)(nil).GetRetryableWebhookInboxes), ctx, receivedBefore, staleBefore, maxAttempts, limit)
+}
+
+// GetSettlementSummaries mocks base method.
+func (m *MockPaymentDatabase) GetSettlementSummaries(ctx context.Context, startTime, endTime time.Time) ([]models.SettlementSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSettlementSummaries", ctx, startTime, endTime)
+	ret0, _ := ret[0].([]models.SettlementSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSettlementSummaries indicates an expected call of GetSettlementSummaries.
+func (mr *MockPaymentDatabaseMockRecorder) GetSettlementSummaries(ctx, startTime, endTime interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSettlementSummaries", reflect.TypeOf((*MockPaymentDatabase)(nil).GetSettlementSummaries), ctx, startTime, endTime)
+}
+
+// GetSucceededRefundAmountByOrderID mocks base method.
+func (m *MockPaymentDatabase) GetSucceededRefundAmountByOrderID(ctx context.Context, orderID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSucceededRefundAmountByOrderID", ctx, orderID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// GetPendingPaymentRequests indicates an expected call of GetPendingPaymentRequests.
-func (mr *MockPaymentDatabaseMockRecorder) GetPendingPaymentRequests(ctx, paymentRequests interface{}) *gomock.Call {
+// GetSucceededRefundAmountByOrderID indicates an expected call of GetSucceededRefundAmountByOrderID.
+func (mr *MockPaymentDatabaseMockRecorder) GetSucceededRefundAmountByOrderID(ctx, orderID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingPaymentRequests", reflect.TypeOf((*MockPaymentDatabase)(nil).GetPendingPaymentRequests), ctx, paymentRequests)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSucceededRefundAmountByOrderID", reflect.TypeOf((*MockPaymentDatabase)(nil).GetSucceededRefundAmountByOrderID), ctx, orderID)
+}
+
+// GetWebhookInboxByID mocks base method.
+func (m *MockPaymentDatabase) GetWebhookInboxByID(ctx context.Context, id int64) (*models.WebhookInbox, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookInboxByID", ctx, id)
+	ret0, _ := ret[0].(*models.WebhookInbox)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookInboxByID indicates an expected call of GetWebhookInboxByID.
+func (mr *MockPaymentDatabaseMockRecorder) GetWebhookInboxByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookInboxByID", reflect.TypeOf((*MockPaymentDatabase)(nil).GetWebhookInboxByID), ctx, id)
+}
+
+// GetWebhookInboxes mocks base method.
+func (m *MockPaymentDatabase) GetWebhookInboxes(ctx context.Context, filter models.WebhookInboxFilter) ([]models.WebhookInbox, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookInboxes", ctx, filter)
+	ret0, _ := ret[0].([]models.WebhookInbox)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookInboxes indicates an expected call of GetWebhookInboxes.
+func (mr *MockPaymentDatabaseMockRecorder) GetWebhookInboxes(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookInboxes", reflect.TypeOf((*MockPaymentDatabase)(nil).GetWebhookInboxes), ctx, filter)
 }
 
 // InsertAuditLog mocks base method.
@@ -137,47 +440,89 @@ func (mr *MockPaymentDatabaseMockRecorder) InsertAuditLog(ctx, param interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertAuditLog", reflect.TypeOf((*MockPaymentDatabase)(nil).InsertAuditLog), ctx, param)
 }
 
-// IsAlreadyPaid mocks base method.
-func (m *MockPaymentDatabase) IsAlreadyPaid(ctx context.Context, orderID int64) (bool, error) {
+// MarkExpired mocks base method.
+func (m *MockPaymentDatabase) MarkExpired(ctx context.Context, orderID int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsAlreadyPaid", ctx, orderID)
-	ret0, _ := ret[0].(bool)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "MarkExpired", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// IsAlreadyPaid indicates an expected call of IsAlreadyPaid.
-func (mr *MockPaymentDatabaseMockRecorder) IsAlreadyPaid(ctx, orderID interface{}) *gomock.Call {
+// MarkExpired indicates an expected call of MarkExpired.
+func (mr *MockPaymentDatabaseMockRecorder) MarkExpired(ctx, orderID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAlreadyPaid", reflect.TypeOf((*MockPaymentDatabase)(nil).IsAlreadyPaid), ctx, orderID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkExpired", reflect.TypeOf((*MockPaymentDatabase)(nil).MarkExpired), ctx, orderID)
 }
 
-// MarkExpired mocks base method.
-func (m *MockPaymentDatabase) MarkExpired(ctx context.Context, paymentID int64) error {
+// MarkFailed mocks base method.
+func (m *MockPaymentDatabase) MarkFailed(ctx context.Context, orderID int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MarkExpired", ctx, paymentID)
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, orderID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// MarkExpired indicates an expected call of MarkExpired.
-func (mr *MockPaymentDatabaseMockRecorder) MarkExpired(ctx, paymentID interface{}) *gomock.Call {
+// MarkFailed indicates an expected call of MarkFailed.
+func (mr *MockPaymentDatabaseMockRecorder) MarkFailed(ctx, orderID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkExpired", reflect.TypeOf((*MockPaymentDatabase)(nil).MarkExpired), ctx, paymentID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockPaymentDatabase)(nil).MarkFailed), ctx, orderID)
+}
+
+// MarkOutboxPublished mocks base method.
+func (m *MockPaymentDatabase) MarkOutboxPublished(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxPublished", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxPublished indicates an expected call of MarkOutboxPublished.
+func (mr *MockPaymentDatabaseMockRecorder) MarkOutboxPublished(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxPublished", reflect.TypeOf((*MockPaymentDatabase)(nil).MarkOutboxPublished), ctx, id)
 }
 
 // MarkPaid mocks base method.
-func (m *MockPaymentDatabase) MarkPaid(ctx context.Context, orderID int64) error {
+func (m *MockPaymentDatabase) MarkPaid(ctx context.Context, orderID int64, detail models.PaymentPaidDetail) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MarkPaid", ctx, orderID)
+	ret := m.ctrl.Call(m, "MarkPaid", ctx, orderID, detail)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // MarkPaid indicates an expected call of MarkPaid.
-func (mr *MockPaymentDatabaseMockRecorder) MarkPaid(ctx, orderID interface{}) *gomock.Call {
+func (mr *MockPaymentDatabaseMockRecorder) MarkPaid(ctx, orderID, detail interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPaid", reflect.TypeOf((*MockPaymentDatabase)(nil).MarkPaid), ctx, orderID, detail)
+}
+
+// MarkRefunded mocks base method.
+func (m *MockPaymentDatabase) MarkRefunded(ctx context.Context, orderID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRefunded", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRefunded indicates an expected call of MarkRefunded.
+func (mr *MockPaymentDatabaseMockRecorder) MarkRefunded(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRefunded", reflect.TypeOf((*MockPaymentDatabase)(nil).MarkRefunded), ctx, orderID)
+}
+
+// ResolvePaymentAnomaly mocks base method.
+func (m *MockPaymentDatabase) ResolvePaymentAnomaly(ctx context.Context, anomalyID int64, status int, resolvedBy int64, notes string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolvePaymentAnomaly", ctx, anomalyID, status, resolvedBy, notes)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolvePaymentAnomaly indicates an expected call of ResolvePaymentAnomaly.
+func (mr *MockPaymentDatabaseMockRecorder) ResolvePaymentAnomaly(ctx, anomalyID, status, resolvedBy, notes interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPaid", reflect.TypeOf((*MockPaymentDatabase)(nil).MarkPaid), ctx, orderID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolvePaymentAnomaly", reflect.TypeOf((*MockPaymentDatabase)(nil).ResolvePaymentAnomaly), ctx, anomalyID, status, resolvedBy, notes)
 }
 
 // SaveFailedPublishEvent mocks base method.
@@ -194,6 +539,20 @@ func (mr *MockPaymentDatabaseMockRecorder) SaveFailedPublishEvent(ctx, param int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFailedPublishEvent", reflect.TypeOf((*MockPaymentDatabase)(nil).SaveFailedPublishEvent), ctx, param)
 }
 
+// SaveOutbox mocks base method.
+func (m *MockPaymentDatabase) SaveOutbox(ctx context.Context, param *models.Outbox) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveOutbox", ctx, param)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveOutbox indicates an expected call of SaveOutbox.
+func (mr *MockPaymentDatabaseMockRecorder) SaveOutbox(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOutbox", reflect.TypeOf((*MockPaymentDatabase)(nil).SaveOutbox), ctx, param)
+}
+
 // SavePayment mocks base method.
 func (m *MockPaymentDatabase) SavePayment(ctx context.Context, param models.Payment) error {
 	m.ctrl.T.Helper()
@@ -236,6 +595,91 @@ func (mr *MockPaymentDatabaseMockRecorder) SavePaymentRequest(ctx, param interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePaymentRequest", reflect.TypeOf((*MockPaymentDatabase)(nil).SavePaymentRequest), ctx, param)
 }
 
+// SaveReconciliationDiscrepancies mocks base method.
+func (m *MockPaymentDatabase) SaveReconciliationDiscrepancies(ctx context.Context, discrepancies []models.ReconciliationDiscrepancy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveReconciliationDiscrepancies", ctx, discrepancies)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveReconciliationDiscrepancies indicates an expected call of SaveReconciliationDiscrepancies.
+func (mr *MockPaymentDatabaseMockRecorder) SaveReconciliationDiscrepancies(ctx, discrepancies interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveReconciliationDiscrepancies", reflect.TypeOf((*MockPaymentDatabase)(nil).SaveReconciliationDiscrepancies), ctx, discrepancies)
+}
+
+// SaveReconciliationRun mocks base method.
+func (m *MockPaymentDatabase) SaveReconciliationRun(ctx context.Context, param *models.ReconciliationRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveReconciliationRun", ctx, param)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveReconciliationRun indicates an expected call of SaveReconciliationRun.
+func (mr *MockPaymentDatabaseMockRecorder) SaveReconciliationRun(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveReconciliationRun", reflect.TypeOf((*MockPaymentDatabase)(nil).SaveReconciliationRun), ctx, param)
+}
+
+// SaveRefund mocks base method.
+func (m *MockPaymentDatabase) SaveRefund(ctx context.Context, param *models.Refund) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRefund", ctx, param)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRefund indicates an expected call of SaveRefund.
+func (mr *MockPaymentDatabaseMockRecorder) SaveRefund(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRefund", reflect.TypeOf((*MockPaymentDatabase)(nil).SaveRefund), ctx, param)
+}
+
+// SaveWebhookInbox mocks base method.
+func (m *MockPaymentDatabase) SaveWebhookInbox(ctx context.Context, param *models.WebhookInbox) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWebhookInbox", ctx, param)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveWebhookInbox indicates an expected call of SaveWebhookInbox.
+func (mr *MockPaymentDatabaseMockRecorder) SaveWebhookInbox(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWebhookInbox", reflect.TypeOf((*MockPaymentDatabase)(nil).SaveWebhookInbox), ctx, param)
+}
+
+// UpdateFailedEventRetry mocks base method.
+func (m *MockPaymentDatabase) UpdateFailedEventRetry(ctx context.Context, id int64, status int, notes string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFailedEventRetry", ctx, id, status, notes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateFailedEventRetry indicates an expected call of UpdateFailedEventRetry.
+func (mr *MockPaymentDatabaseMockRecorder) UpdateFailedEventRetry(ctx, id, status, notes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFailedEventRetry", reflect.TypeOf((*MockPaymentDatabase)(nil).UpdateFailedEventRetry), ctx, id, status, notes)
+}
+
+// UpdateFailedEventsStatus mocks base method.
+func (m *MockPaymentDatabase) UpdateFailedEventsStatus(ctx context.Context, ids []int64, fromStatuses []int, status int, notes string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFailedEventsStatus", ctx, ids, fromStatuses, status, notes)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateFailedEventsStatus indicates an expected call of UpdateFailedEventsStatus.
+func (mr *MockPaymentDatabaseMockRecorder) UpdateFailedEventsStatus(ctx, ids, fromStatuses, status, notes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFailedEventsStatus", reflect.TypeOf((*MockPaymentDatabase)(nil).UpdateFailedEventsStatus), ctx, ids, fromStatuses, status, notes)
+}
+
 // UpdateFailedPaymentRequest mocks base method.
 func (m *MockPaymentDatabase) UpdateFailedPaymentRequest(ctx context.Context, paymentRequestID int64, notes string) error {
 	m.ctrl.T.Helper()
@@ -250,6 +694,20 @@ func (mr *MockPaymentDatabaseMockRecorder) UpdateFailedPaymentRequest(ctx, payme
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFailedPaymentRequest", reflect.TypeOf((*MockPaymentDatabase)(nil).UpdateFailedPaymentRequest), ctx, paymentRequestID, notes)
 }
 
+// UpdateOutboxFailed mocks base method.
+func (m *MockPaymentDatabase) UpdateOutboxFailed(ctx context.Context, id int64, lastError string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOutboxFailed", ctx, id, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOutboxFailed indicates an expected call of UpdateOutboxFailed.
+func (mr *MockPaymentDatabaseMockRecorder) UpdateOutboxFailed(ctx, id, lastError interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOutboxFailed", reflect.TypeOf((*MockPaymentDatabase)(nil).UpdateOutboxFailed), ctx, id, lastError)
+}
+
 // UpdatePendingPaymentRequest mocks base method.
 func (m *MockPaymentDatabase) UpdatePendingPaymentRequest(ctx context.Context, paymentRequestID int64) error {
 	m.ctrl.T.Helper()
@@ -264,6 +722,20 @@ func (mr *MockPaymentDatabaseMockRecorder) UpdatePendingPaymentRequest(ctx, paym
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePendingPaymentRequest", reflect.TypeOf((*MockPaymentDatabase)(nil).UpdatePendingPaymentRequest), ctx, paymentRequestID)
 }
 
+// UpdateRefund mocks base method.
+func (m *MockPaymentDatabase) UpdateRefund(ctx context.Context, refundID int64, gatewayRefundID, status, notes string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRefund", ctx, refundID, gatewayRefundID, status, notes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRefund indicates an expected call of UpdateRefund.
+func (mr *MockPaymentDatabaseMockRecorder) UpdateRefund(ctx, refundID, gatewayRefundID, status, notes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRefund", reflect.TypeOf((*MockPaymentDatabase)(nil).UpdateRefund), ctx, refundID, gatewayRefundID, status, notes)
+}
+
 // UpdateSuccessPaymentRequest mocks base method.
 func (m *MockPaymentDatabase) UpdateSuccessPaymentRequest(ctx context.Context, paymentRequestID int64) error {
 	m.ctrl.T.Helper()
@@ -277,3 +749,31 @@ func (mr *MockPaymentDatabaseMockRecorder) UpdateSuccessPaymentRequest(ctx, paym
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSuccessPaymentRequest", reflect.TypeOf((*MockPaymentDatabase)(nil).UpdateSuccessPaymentRequest), ctx, paymentRequestID)
 }
+
+// UpdateWebhookInboxResult mocks base method.
+func (m *MockPaymentDatabase) UpdateWebhookInboxResult(ctx context.Context, id int64, status, result string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookInboxResult", ctx, id, status, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookInboxResult indicates an expected call of UpdateWebhookInboxResult.
+func (mr *MockPaymentDatabaseMockRecorder) UpdateWebhookInboxResult(ctx, id, status, result interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookInboxResult", reflect.TypeOf((*MockPaymentDatabase)(nil).UpdateWebhookInboxResult), ctx, id, status, result)
+}
+
+// WithTransaction mocks base method.
+func (m *MockPaymentDatabase) WithTransaction(ctx context.Context, fn func(repository.PaymentDatabase) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTransaction indicates an expected call of WithTransaction.
+func (mr *MockPaymentDatabaseMockRecorder) WithTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTransaction", reflect.TypeOf((*MockPaymentDatabase)(nil).WithTransaction), ctx, fn)
+}
@@ -0,0 +1,208 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: cmd/payment/repository/gateway.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	http "net/http"
+	repository "payment/cmd/payment/repository"
+	models "payment/models"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPaymentGateway is a mock of PaymentGateway interface.
+type MockPaymentGateway struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentGatewayMockRecorder
+}
+
+// MockPaymentGatewayMockRecorder is the mock recorder for MockPaymentGateway.
+type MockPaymentGatewayMockRecorder struct {
+	mock *MockPaymentGateway
+}
+
+// NewMockPaymentGateway creates a new mock instance.
+func NewMockPaymentGateway(ctrl *gomock.Controller) *MockPaymentGateway {
+	mock := &MockPaymentGateway{ctrl: ctrl}
+	mock.recorder = &MockPaymentGatewayMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentGateway) EXPECT() *MockPaymentGatewayMockRecorder {
+	return m.recorder
+}
+
+// CreateCharge mocks base method.
+func (m *MockPaymentGateway) CreateCharge(ctx context.Context, param models.ChargeRequest) (models.Charge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCharge", ctx, param)
+	ret0, _ := ret[0].(models.Charge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCharge indicates an expected call of CreateCharge.
+func (mr *MockPaymentGatewayMockRecorder) CreateCharge(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCharge", reflect.TypeOf((*MockPaymentGateway)(nil).CreateCharge), ctx, param)
+}
+
+// CreateRefund mocks base method.
+func (m *MockPaymentGateway) CreateRefund(ctx context.Context, param models.GatewayRefundRequest) (models.GatewayRefund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefund", ctx, param)
+	ret0, _ := ret[0].(models.GatewayRefund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRefund indicates an expected call of CreateRefund.
+func (mr *MockPaymentGatewayMockRecorder) CreateRefund(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefund", reflect.TypeOf((*MockPaymentGateway)(nil).CreateRefund), ctx, param)
+}
+
+// ExpireCharge mocks base method.
+func (m *MockPaymentGateway) ExpireCharge(ctx context.Context, externalID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireCharge", ctx, externalID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExpireCharge indicates an expected call of ExpireCharge.
+func (mr *MockPaymentGatewayMockRecorder) ExpireCharge(ctx, externalID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireCharge", reflect.TypeOf((*MockPaymentGateway)(nil).ExpireCharge), ctx, externalID)
+}
+
+// GetCharge mocks base method.
+func (m *MockPaymentGateway) GetCharge(ctx context.Context, externalID string) (models.Charge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCharge", ctx, externalID)
+	ret0, _ := ret[0].(models.Charge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCharge indicates an expected call of GetCharge.
+func (mr *MockPaymentGatewayMockRecorder) GetCharge(ctx, externalID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCharge", reflect.TypeOf((*MockPaymentGateway)(nil).GetCharge), ctx, externalID)
+}
+
+// GetRefund mocks base method.
+func (m *MockPaymentGateway) GetRefund(ctx context.Context, externalID, refundID string) (models.GatewayRefund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefund", ctx, externalID, refundID)
+	ret0, _ := ret[0].(models.GatewayRefund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefund indicates an expected call of GetRefund.
+func (mr *MockPaymentGatewayMockRecorder) GetRefund(ctx, externalID, refundID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefund", reflect.TypeOf((*MockPaymentGateway)(nil).GetRefund), ctx, externalID, refundID)
+}
+
+// Name mocks base method.
+func (m *MockPaymentGateway) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockPaymentGatewayMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockPaymentGateway)(nil).Name))
+}
+
+// ParseWebhook mocks base method.
+func (m *MockPaymentGateway) ParseWebhook(rawBody []byte) (models.GatewayWebhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseWebhook", rawBody)
+	ret0, _ := ret[0].(models.GatewayWebhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseWebhook indicates an expected call of ParseWebhook.
+func (mr *MockPaymentGatewayMockRecorder) ParseWebhook(rawBody interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseWebhook", reflect.TypeOf((*MockPaymentGateway)(nil).ParseWebhook), rawBody)
+}
+
+// VerifyWebhook mocks base method.
+func (m *MockPaymentGateway) VerifyWebhook(headers http.Header, rawBody []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyWebhook", headers, rawBody)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyWebhook indicates an expected call of VerifyWebhook.
+func (mr *MockPaymentGatewayMockRecorder) VerifyWebhook(headers, rawBody interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyWebhook", reflect.TypeOf((*MockPaymentGateway)(nil).VerifyWebhook), headers, rawBody)
+}
+
+// MockPaymentGatewayResolver is a mock of PaymentGatewayResolver interface.
+type MockPaymentGatewayResolver struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentGatewayResolverMockRecorder
+}
+
+// MockPaymentGatewayResolverMockRecorder is the mock recorder for MockPaymentGatewayResolver.
+type MockPaymentGatewayResolverMockRecorder struct {
+	mock *MockPaymentGatewayResolver
+}
+
+// NewMockPaymentGatewayResolver creates a new mock instance.
+func NewMockPaymentGatewayResolver(ctrl *gomock.Controller) *MockPaymentGatewayResolver {
+	mock := &MockPaymentGatewayResolver{ctrl: ctrl}
+	mock.recorder = &MockPaymentGatewayResolverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentGatewayResolver) EXPECT() *MockPaymentGatewayResolverMockRecorder {
+	return m.recorder
+}
+
+// ForPaymentMethod mocks base method.
+func (m *MockPaymentGatewayResolver) ForPaymentMethod(paymentMethod string) (repository.PaymentGateway, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForPaymentMethod", paymentMethod)
+	ret0, _ := ret[0].(repository.PaymentGateway)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForPaymentMethod indicates an expected call of ForPaymentMethod.
+func (mr *MockPaymentGatewayResolverMockRecorder) ForPaymentMethod(paymentMethod interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForPaymentMethod", reflect.TypeOf((*MockPaymentGatewayResolver)(nil).ForPaymentMethod), paymentMethod)
+}
+
+// Get mocks base method.
+func (m *MockPaymentGatewayResolver) Get(provider string) (repository.PaymentGateway, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", provider)
+	ret0, _ := ret[0].(repository.PaymentGateway)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockPaymentGatewayResolverMockRecorder) Get(provider interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPaymentGatewayResolver)(nil).Get), provider)
+}
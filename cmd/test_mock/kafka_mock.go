@@ -6,6 +6,7 @@ package mocks
 
 import (
 	context "context"
+	models "payment/models"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,16 +35,16 @@ func (m *MockPaymentEventPublisher) EXPECT() *MockPaymentEventPublisherMockRecor
 	return m.recorder
 }
 
-// PublishPaymentSuccess mocks base method.
-func (m *MockPaymentEventPublisher) PublishPaymentSuccess(ctx context.Context, orderID int64) error {
+// PublishOutbox mocks base method.
+func (m *MockPaymentEventPublisher) PublishOutbox(ctx context.Context, outbox models.Outbox) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PublishPaymentSuccess", ctx, orderID)
+	ret := m.ctrl.Call(m, "PublishOutbox", ctx, outbox)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// PublishPaymentSuccess indicates an expected call of PublishPaymentSuccess.
-func (mr *MockPaymentEventPublisherMockRecorder) PublishPaymentSuccess(ctx, orderID interface{}) *gomock.Call {
+// PublishOutbox indicates an expected call of PublishOutbox.
+func (mr *MockPaymentEventPublisherMockRecorder) PublishOutbox(ctx, outbox interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishPaymentSuccess", reflect.TypeOf((*MockPaymentEventPublisher)(nil).PublishPaymentSuccess), ctx, orderID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishOutbox", reflect.TypeOf((*MockPaymentEventPublisher)(nil).PublishOutbox), ctx, outbox)
 }
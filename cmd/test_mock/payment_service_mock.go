@@ -36,10 +36,10 @@ func (m *MockPaymentService) EXPECT() *MockPaymentServiceMockRecorder {
 }
 
 // CheckPaymentAmountByOrderID mocks base method.
-func (m *MockPaymentService) CheckPaymentAmountByOrderID(ctx context.Context, orderID int64) (float64, error) {
+func (m *MockPaymentService) CheckPaymentAmountByOrderID(ctx context.Context, orderID int64) (models.Money, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CheckPaymentAmountByOrderID", ctx, orderID)
-	ret0, _ := ret[0].(float64)
+	ret0, _ := ret[0].(models.Money)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -65,18 +65,46 @@ func (mr *MockPaymentServiceMockRecorder) GetPaymentInfoByOrderID(ctx, orderID i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentInfoByOrderID", reflect.TypeOf((*MockPaymentService)(nil).GetPaymentInfoByOrderID), ctx, orderID)
 }
 
+// ProcessPaymentExpired mocks base method.
+func (m *MockPaymentService) ProcessPaymentExpired(ctx context.Context, orderID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessPaymentExpired", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProcessPaymentExpired indicates an expected call of ProcessPaymentExpired.
+func (mr *MockPaymentServiceMockRecorder) ProcessPaymentExpired(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessPaymentExpired", reflect.TypeOf((*MockPaymentService)(nil).ProcessPaymentExpired), ctx, orderID)
+}
+
+// ProcessPaymentFailed mocks base method.
+func (m *MockPaymentService) ProcessPaymentFailed(ctx context.Context, orderID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessPaymentFailed", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProcessPaymentFailed indicates an expected call of ProcessPaymentFailed.
+func (mr *MockPaymentServiceMockRecorder) ProcessPaymentFailed(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessPaymentFailed", reflect.TypeOf((*MockPaymentService)(nil).ProcessPaymentFailed), ctx, orderID)
+}
+
 // ProcessPaymentSuccess mocks base method.
-func (m *MockPaymentService) ProcessPaymentSuccess(ctx context.Context, orderID int64) error {
+func (m *MockPaymentService) ProcessPaymentSuccess(ctx context.Context, orderID int64, detail models.PaymentPaidDetail) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ProcessPaymentSuccess", ctx, orderID)
+	ret := m.ctrl.Call(m, "ProcessPaymentSuccess", ctx, orderID, detail)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ProcessPaymentSuccess indicates an expected call of ProcessPaymentSuccess.
-func (mr *MockPaymentServiceMockRecorder) ProcessPaymentSuccess(ctx, orderID interface{}) *gomock.Call {
+func (mr *MockPaymentServiceMockRecorder) ProcessPaymentSuccess(ctx, orderID, detail interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessPaymentSuccess", reflect.TypeOf((*MockPaymentService)(nil).ProcessPaymentSuccess), ctx, orderID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessPaymentSuccess", reflect.TypeOf((*MockPaymentService)(nil).ProcessPaymentSuccess), ctx, orderID, detail)
 }
 
 // SavePaymentAnomaly mocks base method.
@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: cmd/payment/repository/redis.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPaymentRedis is a mock of PaymentRedis interface.
+type MockPaymentRedis struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentRedisMockRecorder
+}
+
+// MockPaymentRedisMockRecorder is the mock recorder for MockPaymentRedis.
+type MockPaymentRedisMockRecorder struct {
+	mock *MockPaymentRedis
+}
+
+// NewMockPaymentRedis creates a new mock instance.
+func NewMockPaymentRedis(ctrl *gomock.Controller) *MockPaymentRedis {
+	mock := &MockPaymentRedis{ctrl: ctrl}
+	mock.recorder = &MockPaymentRedisMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentRedis) EXPECT() *MockPaymentRedisMockRecorder {
+	return m.recorder
+}
+
+// AcquireLock mocks base method.
+func (m *MockPaymentRedis) AcquireLock(ctx context.Context, key string, ttl time.Duration) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireLock", ctx, key, ttl)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AcquireLock indicates an expected call of AcquireLock.
+func (mr *MockPaymentRedisMockRecorder) AcquireLock(ctx, key, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireLock", reflect.TypeOf((*MockPaymentRedis)(nil).AcquireLock), ctx, key, ttl)
+}
+
+// Delete mocks base method.
+func (m *MockPaymentRedis) Delete(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockPaymentRedisMockRecorder) Delete(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPaymentRedis)(nil).Delete), ctx, key)
+}
+
+// ReleaseLock mocks base method.
+func (m *MockPaymentRedis) ReleaseLock(ctx context.Context, key, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseLock", ctx, key, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseLock indicates an expected call of ReleaseLock.
+func (mr *MockPaymentRedisMockRecorder) ReleaseLock(ctx, key, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseLock", reflect.TypeOf((*MockPaymentRedis)(nil).ReleaseLock), ctx, key, token)
+}
+
+// RenewLock mocks base method.
+func (m *MockPaymentRedis) RenewLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewLock", ctx, key, token, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenewLock indicates an expected call of RenewLock.
+func (mr *MockPaymentRedisMockRecorder) RenewLock(ctx, key, token, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewLock", reflect.TypeOf((*MockPaymentRedis)(nil).RenewLock), ctx, key, token, ttl)
+}
+
+// SetIfNotExists mocks base method.
+func (m *MockPaymentRedis) SetIfNotExists(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetIfNotExists", ctx, key, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetIfNotExists indicates an expected call of SetIfNotExists.
+func (mr *MockPaymentRedisMockRecorder) SetIfNotExists(ctx, key, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIfNotExists", reflect.TypeOf((*MockPaymentRedis)(nil).SetIfNotExists), ctx, key, ttl)
+}
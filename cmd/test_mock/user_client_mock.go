@@ -1,7 +1,7 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: grpc/user_client.go
 
-// Package mock_grpc is a generated GoMock package.
+// Package mocks is a generated GoMock package.
 package mocks
 
 import (
@@ -35,6 +35,20 @@ func (m *MockUserClient) EXPECT() *MockUserClientMockRecorder {
 	return m.recorder
 }
 
+// Close mocks base method.
+func (m *MockUserClient) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockUserClientMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockUserClient)(nil).Close))
+}
+
 // GetUserInfoByUserId mocks base method.
 func (m *MockUserClient) GetUserInfoByUserId(ctx context.Context, userID int64) (*userpb.GetUserInfoResult, error) {
 	m.ctrl.T.Helper()
@@ -64,3 +64,78 @@ func (mr *MockXenditClientMockRecorder) CreateInvoice(ctx, param interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvoice", reflect.TypeOf((*MockXenditClient)(nil).CreateInvoice), ctx, param)
 }
+
+// CreateRefund mocks base method.
+func (m *MockXenditClient) CreateRefund(ctx context.Context, param models.XenditRefundRequest) (models.XenditRefundResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefund", ctx, param)
+	ret0, _ := ret[0].(models.XenditRefundResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRefund indicates an expected call of CreateRefund.
+func (mr *MockXenditClientMockRecorder) CreateRefund(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefund", reflect.TypeOf((*MockXenditClient)(nil).CreateRefund), ctx, param)
+}
+
+// ExpireInvoice mocks base method.
+func (m *MockXenditClient) ExpireInvoice(ctx context.Context, invoiceID string) (models.XenditInvoiceResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireInvoice", ctx, invoiceID)
+	ret0, _ := ret[0].(models.XenditInvoiceResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireInvoice indicates an expected call of ExpireInvoice.
+func (mr *MockXenditClientMockRecorder) ExpireInvoice(ctx, invoiceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireInvoice", reflect.TypeOf((*MockXenditClient)(nil).ExpireInvoice), ctx, invoiceID)
+}
+
+// GetInvoiceByExternalID mocks base method.
+func (m *MockXenditClient) GetInvoiceByExternalID(ctx context.Context, externalID string) (models.XenditInvoiceResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvoiceByExternalID", ctx, externalID)
+	ret0, _ := ret[0].(models.XenditInvoiceResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvoiceByExternalID indicates an expected call of GetInvoiceByExternalID.
+func (mr *MockXenditClientMockRecorder) GetInvoiceByExternalID(ctx, externalID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvoiceByExternalID", reflect.TypeOf((*MockXenditClient)(nil).GetInvoiceByExternalID), ctx, externalID)
+}
+
+// GetRefund mocks base method.
+func (m *MockXenditClient) GetRefund(ctx context.Context, refundID string) (models.XenditRefundResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefund", ctx, refundID)
+	ret0, _ := ret[0].(models.XenditRefundResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefund indicates an expected call of GetRefund.
+func (mr *MockXenditClientMockRecorder) GetRefund(ctx, refundID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefund", reflect.TypeOf((*MockXenditClient)(nil).GetRefund), ctx, refundID)
+}
+
+// ListInvoices mocks base method.
+func (m *MockXenditClient) ListInvoices(ctx context.Context, param models.XenditListInvoicesRequest) ([]models.XenditInvoiceResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvoices", ctx, param)
+	ret0, _ := ret[0].([]models.XenditInvoiceResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvoices indicates an expected call of ListInvoices.
+func (mr *MockXenditClientMockRecorder) ListInvoices(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvoices", reflect.TypeOf((*MockXenditClient)(nil).ListInvoices), ctx, param)
+}
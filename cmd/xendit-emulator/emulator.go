@@ -0,0 +1,720 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	invoiceStatusPending = "PENDING"
+	invoiceStatusPaid    = "PAID"
+	invoiceStatusSettled = "SETTLED"
+	invoiceStatusExpired = "EXPIRED"
+
+	refundStatusPending   = "PENDING"
+	refundStatusSucceeded = "SUCCEEDED"
+	refundStatusFailed    = "FAILED"
+
+	defaultListLimit     = 10
+	maxListLimit         = 100
+	defaultPaymentMethod = "BANK_TRANSFER"
+	defaultCurrency      = "IDR"
+	webhookTimeout       = 10 * time.Second
+)
+
+type emulatorConfig struct {
+	// empty secret key accept every api key
+	SecretKey    string
+	WebhookURL   string
+	WebhookToken string
+	// used to build invoice_url
+	PublicURL       string
+	InvoiceDuration time.Duration
+	// status of a new refund, PENDING refund is finished by the control api
+	RefundStatus string
+}
+
+type invoice struct {
+	ID          string    `json:"id"`
+	ExternalID  string    `json:"external_id"`
+	Status      string    `json:"status"`
+	Amount      float64   `json:"amount"`
+	Currency    string    `json:"currency"`
+	Description string    `json:"description,omitempty"`
+	PayerEmail  string    `json:"payer_email,omitempty"`
+	Customer    *customer `json:"customer,omitempty"`
+	Items       []item    `json:"items,omitempty"`
+	// channels allowed by the request, the emulator does not restrict how the invoice is paid
+	PaymentMethods     []string   `json:"payment_methods,omitempty"`
+	SuccessRedirectURL string     `json:"success_redirect_url,omitempty"`
+	FailureRedirectURL string     `json:"failure_redirect_url,omitempty"`
+	InvoiceURL         string     `json:"invoice_url"`
+	ExpiryDate         time.Time  `json:"expiry_date"`
+	PaymentMethod      string     `json:"payment_method,omitempty"`
+	PaidAt             *time.Time `json:"paid_at,omitempty"`
+	Created            time.Time  `json:"created"`
+	Updated            time.Time  `json:"updated"`
+}
+
+type customer struct {
+	GivenNames   string `json:"given_names,omitempty"`
+	Email        string `json:"email,omitempty"`
+	MobileNumber string `json:"mobile_number,omitempty"`
+}
+
+type item struct {
+	Name     string  `json:"name"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+	Category string  `json:"category,omitempty"`
+}
+
+type refund struct {
+	ID          string    `json:"id"`
+	InvoiceID   string    `json:"invoice_id"`
+	ReferenceID string    `json:"reference_id"`
+	Amount      float64   `json:"amount"`
+	Status      string    `json:"status"`
+	Reason      string    `json:"reason"`
+	FailureCode string    `json:"failure_code,omitempty"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+}
+
+// webhookResult tell the control api caller whether the payment service accepted the webhook.
+type webhookResult struct {
+	Sent       bool   `json:"sent"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Body       string `json:"body,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// emulator keep every invoice and refund in memory, state is lost on restart.
+type emulator struct {
+	cfg        emulatorConfig
+	httpClient *http.Client
+
+	mu sync.Mutex
+	// in creation order
+	invoices             []*invoice
+	invoiceByIdempotency map[string]string
+	refunds              map[string]*refund
+	refundByIdempotency  map[string]string
+	refundByReferenceKey map[string]string
+	// next api calls fail with this status, to exercise client retries
+	faultStatus int
+	faultCount  int
+}
+
+func newEmulator(cfg emulatorConfig) *emulator {
+	if cfg.InvoiceDuration <= 0 {
+		cfg.InvoiceDuration = 24 * time.Hour
+	}
+
+	if cfg.RefundStatus == "" {
+		cfg.RefundStatus = refundStatusSucceeded
+	}
+
+	e := &emulator{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: webhookTimeout},
+	}
+	e.reset()
+
+	return e
+}
+
+func (e *emulator) reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.invoices = nil
+	e.invoiceByIdempotency = make(map[string]string)
+	e.faultStatus = 0
+	e.faultCount = 0
+	e.refunds = make(map[string]*refund)
+	e.refundByIdempotency = make(map[string]string)
+	e.refundByReferenceKey = make(map[string]string)
+}
+
+// register add the xendit api, which is used by the payment service, and the control api, which drive invoices in tests.
+func (e *emulator) register(router *gin.Engine) {
+	api := router.Group("", e.authenticate, e.injectFault)
+	api.POST("/v2/invoices", e.handleCreateInvoice)
+	api.GET("/v2/invoices", e.handleListInvoices)
+	api.GET("/v2/invoices/:id", e.handleGetInvoice)
+	api.POST("/invoices/:id/expire!", e.handleExpireInvoice)
+	api.POST("/refunds", e.handleCreateRefund)
+	api.GET("/refunds/:id", e.handleGetRefund)
+
+	// checkout page is not emulated, invoice url show the invoice instead
+	router.GET("/web/invoices/:id", e.handleGetInvoice)
+
+	// invoice can be referred by id or external id
+	control := router.Group("/_control")
+	control.GET("/invoices", e.handleControlListInvoices)
+	control.POST("/invoices/:id/pay", e.handleControlPayInvoice)
+	control.POST("/invoices/:id/expire", e.handleControlExpireInvoice)
+	control.POST("/invoices/:id/webhook", e.handleControlResendWebhook)
+	control.GET("/refunds", e.handleControlListRefunds)
+	control.POST("/refunds/:id/:action", e.handleControlFinishRefund)
+	control.POST("/faults", e.handleControlFaults)
+	control.POST("/reset", e.handleControlReset)
+}
+
+func (e *emulator) authenticate(c *gin.Context) {
+	if e.cfg.SecretKey == "" {
+		return
+	}
+
+	key, _, ok := c.Request.BasicAuth()
+	if !ok || key != e.cfg.SecretKey {
+		abortError(c, http.StatusUnauthorized, "INVALID_API_KEY", "API key is invalid")
+		return
+	}
+}
+
+func (e *emulator) injectFault(c *gin.Context) {
+	e.mu.Lock()
+	status := e.faultStatus
+	if e.faultCount > 0 {
+		e.faultCount--
+	} else {
+		status = 0
+	}
+	e.mu.Unlock()
+
+	if status == 0 {
+		return
+	}
+
+	if status == http.StatusTooManyRequests {
+		c.Header("Retry-After", "1")
+	}
+
+	abortError(c, status, "EMULATED_FAULT", "fault injected by the emulator")
+}
+
+func (e *emulator) handleCreateInvoice(c *gin.Context) {
+	var req struct {
+		ExternalID  string  `json:"external_id"`
+		Amount      float64 `json:"amount"`
+		Description string  `json:"description"`
+		PayerEmail  string  `json:"payer_email"`
+		Currency    string  `json:"currency"`
+		// seconds
+		InvoiceDuration    int64     `json:"invoice_duration"`
+		PaymentMethods     []string  `json:"payment_methods"`
+		SuccessRedirectURL string    `json:"success_redirect_url"`
+		FailureRedirectURL string    `json:"failure_redirect_url"`
+		Customer           *customer `json:"customer"`
+		Items              []item    `json:"items"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortError(c, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	if req.ExternalID == "" || req.Amount <= 0 {
+		abortError(c, http.StatusBadRequest, "API_VALIDATION_ERROR", "external_id and positive amount are required")
+		return
+	}
+
+	for _, reqItem := range req.Items {
+		if reqItem.Name == "" || reqItem.Quantity <= 0 {
+			abortError(c, http.StatusBadRequest, "API_VALIDATION_ERROR", "item name and positive quantity are required")
+			return
+		}
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	duration := e.cfg.InvoiceDuration
+	if req.InvoiceDuration > 0 {
+		duration = time.Duration(req.InvoiceDuration) * time.Second
+	}
+
+	idempotencyKey := c.GetHeader("X-IDEMPOTENCY-KEY")
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// retried request with the same key get the invoice created by the first one
+	if id, ok := e.invoiceByIdempotency[idempotencyKey]; ok && idempotencyKey != "" {
+		if inv := e.findInvoice(id, false); inv != nil {
+			c.JSON(http.StatusOK, *inv)
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	id := strings.ReplaceAll(uuid.New().String(), "-", "")[:24]
+	inv := &invoice{
+		ID:                 id,
+		ExternalID:         req.ExternalID,
+		Status:             invoiceStatusPending,
+		Amount:             req.Amount,
+		Currency:           currency,
+		Description:        req.Description,
+		PayerEmail:         req.PayerEmail,
+		Customer:           req.Customer,
+		Items:              req.Items,
+		PaymentMethods:     req.PaymentMethods,
+		SuccessRedirectURL: req.SuccessRedirectURL,
+		FailureRedirectURL: req.FailureRedirectURL,
+		InvoiceURL:         strings.TrimSuffix(e.cfg.PublicURL, "/") + "/web/invoices/" + id,
+		ExpiryDate:         now.Add(duration),
+		Created:            now,
+		Updated:            now,
+	}
+
+	e.invoices = append(e.invoices, inv)
+	if idempotencyKey != "" {
+		e.invoiceByIdempotency[idempotencyKey] = id
+	}
+
+	c.JSON(http.StatusOK, *inv)
+}
+
+// handleListInvoices list newest invoice first like xendit, last_invoice_id is the cursor of the next page.
+func (e *emulator) handleListInvoices(c *gin.Context) {
+	limit := defaultListLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxListLimit {
+			abortError(c, http.StatusBadRequest, "API_VALIDATION_ERROR", fmt.Sprintf("limit must be between 1 and %d", maxListLimit))
+			return
+		}
+
+		limit = parsed
+	}
+
+	var createdAfter, createdBefore time.Time
+	for key, target := range map[string]*time.Time{"created_after": &createdAfter, "created_before": &createdBefore} {
+		value := c.Query(key)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			abortError(c, http.StatusBadRequest, "API_VALIDATION_ERROR", fmt.Sprintf("invalid %s: %v", key, err))
+			return
+		}
+
+		*target = parsed
+	}
+
+	externalID := c.Query("external_id")
+	lastInvoiceID := c.Query("last_invoice_id")
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result := make([]invoice, 0, limit)
+	afterCursor := lastInvoiceID == ""
+	for i := len(e.invoices) - 1; i >= 0 && len(result) < limit; i-- {
+		inv := e.invoices[i]
+		if !afterCursor {
+			afterCursor = inv.ID == lastInvoiceID
+			continue
+		}
+
+		// both bound are inclusive at xendit
+		if externalID != "" && inv.ExternalID != externalID ||
+			!createdAfter.IsZero() && inv.Created.Before(createdAfter) ||
+			!createdBefore.IsZero() && inv.Created.After(createdBefore) {
+			continue
+		}
+
+		result = append(result, *inv)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (e *emulator) handleGetInvoice(c *gin.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	inv := e.findInvoice(c.Param("id"), false)
+	if inv == nil {
+		abortError(c, http.StatusNotFound, "INVOICE_NOT_FOUND_ERROR", "Invoice not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, *inv)
+}
+
+func (e *emulator) handleExpireInvoice(c *gin.Context) {
+	inv, err := e.expireInvoice(c.Param("id"), false)
+	if err != nil {
+		abortError(c, err.status, err.code, err.message)
+		return
+	}
+
+	// xendit notify the expiry asynchronously
+	go e.sendWebhook(context.Background(), inv)
+
+	c.JSON(http.StatusOK, inv)
+}
+
+func (e *emulator) handleCreateRefund(c *gin.Context) {
+	var req struct {
+		InvoiceID   string  `json:"invoice_id"`
+		ReferenceID string  `json:"reference_id"`
+		Amount      float64 `json:"amount"`
+		Reason      string  `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortError(c, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	idempotencyKey := c.GetHeader("Idempotency-key")
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// retried request with the same key return the refund which is already created
+	if id, ok := e.refundByIdempotency[idempotencyKey]; ok && idempotencyKey != "" {
+		c.JSON(http.StatusOK, *e.refunds[id])
+		return
+	}
+
+	if req.ReferenceID != "" {
+		if _, ok := e.refundByReferenceKey[req.ReferenceID]; ok {
+			abortError(c, http.StatusConflict, "DUPLICATE_ERROR", "reference_id is already used")
+			return
+		}
+	}
+
+	inv := e.findInvoice(req.InvoiceID, false)
+	if inv == nil {
+		abortError(c, http.StatusNotFound, "DATA_NOT_FOUND", "Invoice not found")
+		return
+	}
+
+	if inv.Status != invoiceStatusPaid && inv.Status != invoiceStatusSettled {
+		abortError(c, http.StatusBadRequest, "INELIGIBLE_TRANSACTION", "Invoice is not paid")
+		return
+	}
+
+	// failed refund does not use the paid amount
+	var refunded float64
+	for _, rfd := range e.refunds {
+		if rfd.InvoiceID == inv.ID && rfd.Status != refundStatusFailed {
+			refunded += rfd.Amount
+		}
+	}
+
+	amount := req.Amount
+	if amount == 0 {
+		amount = inv.Amount - refunded
+	}
+
+	if amount <= 0 || amount > inv.Amount-refunded {
+		abortError(c, http.StatusBadRequest, "REFUND_AMOUNT_EXCEEDED", "Refund amount exceeds remaining paid amount")
+		return
+	}
+
+	now := time.Now().UTC()
+	rfd := &refund{
+		ID:          "rfd-" + uuid.New().String(),
+		InvoiceID:   inv.ID,
+		ReferenceID: req.ReferenceID,
+		Amount:      amount,
+		Status:      e.cfg.RefundStatus,
+		Reason:      req.Reason,
+		Created:     now,
+		Updated:     now,
+	}
+
+	e.refunds[rfd.ID] = rfd
+	if idempotencyKey != "" {
+		e.refundByIdempotency[idempotencyKey] = rfd.ID
+	}
+
+	if req.ReferenceID != "" {
+		e.refundByReferenceKey[req.ReferenceID] = rfd.ID
+	}
+
+	c.JSON(http.StatusOK, *rfd)
+}
+
+func (e *emulator) handleGetRefund(c *gin.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rfd, ok := e.refunds[c.Param("id")]
+	if !ok {
+		abortError(c, http.StatusNotFound, "DATA_NOT_FOUND", "Refund not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, *rfd)
+}
+
+func (e *emulator) handleControlListInvoices(c *gin.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result := make([]invoice, 0, len(e.invoices))
+	for _, inv := range e.invoices {
+		result = append(result, *inv)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleControlPayInvoice mark the invoice as paid by the customer, then send the PAID webhook.
+func (e *emulator) handleControlPayInvoice(c *gin.Context) {
+	var req struct {
+		PaymentMethod string `json:"payment_method"`
+		// pay a different amount to test amount mismatch
+		Amount float64 `json:"amount"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abortError(c, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+			return
+		}
+	}
+
+	if req.PaymentMethod == "" {
+		req.PaymentMethod = defaultPaymentMethod
+	}
+
+	e.mu.Lock()
+	inv := e.findInvoice(c.Param("id"), true)
+	if inv == nil {
+		e.mu.Unlock()
+		abortError(c, http.StatusNotFound, "INVOICE_NOT_FOUND_ERROR", "Invoice not found")
+		return
+	}
+
+	if inv.Status != invoiceStatusPending {
+		e.mu.Unlock()
+		abortError(c, http.StatusBadRequest, "INVALID_INVOICE_STATUS", fmt.Sprintf("Invoice is %s", inv.Status))
+		return
+	}
+
+	now := time.Now().UTC()
+	inv.Status = invoiceStatusPaid
+	inv.PaymentMethod = req.PaymentMethod
+	inv.PaidAt = &now
+	inv.Updated = now
+	if req.Amount > 0 {
+		inv.Amount = req.Amount
+	}
+
+	result := *inv
+	e.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"invoice": result,
+		"webhook": e.sendWebhook(c.Request.Context(), result),
+	})
+}
+
+func (e *emulator) handleControlExpireInvoice(c *gin.Context) {
+	inv, err := e.expireInvoice(c.Param("id"), true)
+	if err != nil {
+		abortError(c, err.status, err.code, err.message)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invoice": inv,
+		"webhook": e.sendWebhook(c.Request.Context(), inv),
+	})
+}
+
+// handleControlResendWebhook send the webhook of the current status again, e.g. to test duplicate delivery.
+func (e *emulator) handleControlResendWebhook(c *gin.Context) {
+	e.mu.Lock()
+	inv := e.findInvoice(c.Param("id"), true)
+	if inv == nil {
+		e.mu.Unlock()
+		abortError(c, http.StatusNotFound, "INVOICE_NOT_FOUND_ERROR", "Invoice not found")
+		return
+	}
+
+	result := *inv
+	e.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"invoice": result,
+		"webhook": e.sendWebhook(c.Request.Context(), result),
+	})
+}
+
+func (e *emulator) handleControlListRefunds(c *gin.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result := make([]refund, 0, len(e.refunds))
+	for _, rfd := range e.refunds {
+		result = append(result, *rfd)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Created.Before(result[j].Created)
+	})
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleControlFinishRefund finish a pending refund, action is succeed or fail.
+func (e *emulator) handleControlFinishRefund(c *gin.Context) {
+	status := map[string]string{
+		"succeed": refundStatusSucceeded,
+		"fail":    refundStatusFailed,
+	}[c.Param("action")]
+	if status == "" {
+		abortError(c, http.StatusBadRequest, "API_VALIDATION_ERROR", "action must be succeed or fail")
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rfd, ok := e.refunds[c.Param("id")]
+	if !ok {
+		abortError(c, http.StatusNotFound, "DATA_NOT_FOUND", "Refund not found")
+		return
+	}
+
+	if rfd.Status != refundStatusPending {
+		abortError(c, http.StatusBadRequest, "INVALID_REFUND_STATUS", fmt.Sprintf("Refund is %s", rfd.Status))
+		return
+	}
+
+	rfd.Status = status
+	rfd.Updated = time.Now().UTC()
+	if status == refundStatusFailed {
+		rfd.FailureCode = "REFUND_FAILED"
+	}
+
+	c.JSON(http.StatusOK, *rfd)
+}
+
+// handleControlFaults make the next count api calls fail with status, e.g. {"status":503,"count":2}.
+func (e *emulator) handleControlFaults(c *gin.Context) {
+	var req struct {
+		Status int `json:"status" binding:"required,min=400,max=599"`
+		Count  int `json:"count" binding:"min=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortError(c, http.StatusBadRequest, "API_VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	e.mu.Lock()
+	e.faultStatus = req.Status
+	e.faultCount = req.Count
+	e.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"status": req.Status, "count": req.Count})
+}
+
+func (e *emulator) handleControlReset(c *gin.Context) {
+	e.reset()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Success."})
+}
+
+type apiError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *emulator) expireInvoice(id string, byExternalID bool) (invoice, *apiError) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	inv := e.findInvoice(id, byExternalID)
+	if inv == nil {
+		return invoice{}, &apiError{http.StatusNotFound, "INVOICE_NOT_FOUND_ERROR", "Invoice not found"}
+	}
+
+	if inv.Status != invoiceStatusPending {
+		return invoice{}, &apiError{http.StatusBadRequest, "INVALID_INVOICE_STATUS", fmt.Sprintf("Invoice is %s", inv.Status)}
+	}
+
+	now := time.Now().UTC()
+	inv.Status = invoiceStatusExpired
+	inv.ExpiryDate = now
+	inv.Updated = now
+
+	return *inv, nil
+}
+
+// findInvoice must be called with mu held, the latest invoice of the external id is returned.
+func (e *emulator) findInvoice(id string, byExternalID bool) *invoice {
+	for i := len(e.invoices) - 1; i >= 0; i-- {
+		inv := e.invoices[i]
+		if inv.ID == id || byExternalID && inv.ExternalID == id {
+			return inv
+		}
+	}
+
+	return nil
+}
+
+// sendWebhook post the invoice callback with x-callback-token like xendit, it is not retried.
+func (e *emulator) sendWebhook(ctx context.Context, inv invoice) webhookResult {
+	if e.cfg.WebhookURL == "" {
+		return webhookResult{Error: "webhook url is not configured"}
+	}
+
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		return webhookResult{Error: err.Error()}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return webhookResult{Error: err.Error()}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-callback-token", e.cfg.WebhookToken)
+
+	res, err := e.httpClient.Do(req)
+	if err != nil {
+		return webhookResult{Error: err.Error()}
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+
+	return webhookResult{
+		Sent:       true,
+		StatusCode: res.StatusCode,
+		Body:       string(body),
+	}
+}
+
+func abortError(c *gin.Context, status int, code string, message string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"error_code": code,
+		"message":    message,
+	})
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"payment/cmd/payment/repository"
+	"payment/config"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedWebhook struct {
+	headers http.Header
+	body    []byte
+}
+
+func setupEmulator(t *testing.T, refundStatus string) (repository.XenditClient, repository.PaymentGateway, string, chan receivedWebhook) {
+	gin.SetMode(gin.TestMode)
+
+	webhooks := make(chan receivedWebhook, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		webhooks <- receivedWebhook{headers: r.Header, body: body}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(receiver.Close)
+
+	router := gin.New()
+	newEmulator(emulatorConfig{
+		SecretKey:    "secret",
+		WebhookURL:   receiver.URL,
+		WebhookToken: "token",
+		RefundStatus: refundStatus,
+	}).register(router)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	client := repository.NewXenditClient(config.XenditConfig{SecretApiKey: "secret", BaseURL: server.URL})
+
+	return client, repository.NewXenditGateway(client, "token", config.XenditInvoiceConfig{}), server.URL, webhooks
+}
+
+func control(t *testing.T, baseURL string, path string) {
+	res, err := http.Post(baseURL+"/_control"+path, "application/json", nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func Test_Emulator_PayInvoice(t *testing.T) {
+	client, gateway, baseURL, webhooks := setupEmulator(t, refundStatusSucceeded)
+	ctx := context.Background()
+
+	created, err := client.CreateInvoice(ctx, models.XenditInvoiceRequest{ExternalID: "order-1", Amount: "10000", Description: "Order 1"})
+	require.NoError(t, err)
+	assert.Equal(t, constant.XenditInvoiceStatusPending, created.Status)
+	assert.NotEmpty(t, created.InvoiceURL)
+
+	control(t, baseURL, "/invoices/order-1/pay")
+
+	webhook := <-webhooks
+	require.NoError(t, gateway.VerifyWebhook(webhook.headers, webhook.body))
+
+	payload, err := gateway.ParseWebhook(webhook.body)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, payload.CallbackID)
+	assert.Equal(t, "order-1", payload.ExternalID)
+	assert.Equal(t, constant.GatewayStatusPaid, payload.Status)
+	assert.Equal(t, models.NewMoney(10000, models.CurrencyIDR), payload.Amount)
+	assert.False(t, payload.PaidAt.IsZero())
+
+	charge, err := gateway.GetCharge(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, constant.GatewayStatusPaid, charge.Status)
+
+	refund, err := gateway.CreateRefund(ctx, models.GatewayRefundRequest{ExternalID: "order-1", ReferenceID: "refund-1", Amount: models.NewMoney(4000, models.CurrencyIDR)})
+	require.NoError(t, err)
+	assert.Equal(t, constant.RefundStatusSucceeded, refund.Status)
+
+	_, err = client.CreateRefund(ctx, models.XenditRefundRequest{InvoiceID: created.ID, ReferenceID: "refund-2", Amount: "7000"})
+	assert.Error(t, err, "refund more than remaining paid amount")
+
+	// retried with the same reference id is idempotent
+	retried, err := gateway.CreateRefund(ctx, models.GatewayRefundRequest{ExternalID: "order-1", ReferenceID: "refund-1", Amount: models.NewMoney(4000, models.CurrencyIDR)})
+	require.NoError(t, err)
+	assert.Equal(t, refund.ID, retried.ID)
+}
+
+func Test_Emulator_ExpireInvoice(t *testing.T) {
+	client, gateway, baseURL, webhooks := setupEmulator(t, refundStatusSucceeded)
+	ctx := context.Background()
+
+	_, err := client.CreateInvoice(ctx, models.XenditInvoiceRequest{ExternalID: "order-2", Amount: "5000"})
+	require.NoError(t, err)
+
+	_, err = client.CreateInvoice(ctx, models.XenditInvoiceRequest{ExternalID: "order-3", Amount: "5000"})
+	require.NoError(t, err)
+
+	require.NoError(t, gateway.ExpireCharge(ctx, "order-2"))
+
+	webhook := <-webhooks
+	payload, err := gateway.ParseWebhook(webhook.body)
+	require.NoError(t, err)
+	assert.Equal(t, "order-2", payload.ExternalID)
+	assert.Equal(t, constant.GatewayStatusExpired, payload.Status)
+
+	control(t, baseURL, "/invoices/order-3/expire")
+	webhook = <-webhooks
+	payload, err = gateway.ParseWebhook(webhook.body)
+	require.NoError(t, err)
+	assert.Equal(t, constant.GatewayStatusExpired, payload.Status)
+
+	// expired invoice can not be expired again
+	assert.Error(t, gateway.ExpireCharge(ctx, "order-2"))
+
+	_, err = gateway.GetCharge(ctx, "order-404")
+	assert.True(t, errors.Is(err, repository.ErrChargeNotFound))
+}
+
+func Test_Emulator_ListInvoices(t *testing.T) {
+	client, _, baseURL, _ := setupEmulator(t, refundStatusSucceeded)
+	ctx := context.Background()
+
+	start := time.Now()
+	for _, externalID := range []string{"order-1", "order-2", "order-3"} {
+		_, err := client.CreateInvoice(ctx, models.XenditInvoiceRequest{ExternalID: externalID, Amount: "1000"})
+		require.NoError(t, err)
+	}
+
+	param := models.XenditListInvoicesRequest{
+		CreatedAfter:  start.Add(-time.Second),
+		CreatedBefore: time.Now().Add(time.Second),
+		Limit:         2,
+	}
+
+	page, err := client.ListInvoices(ctx, param)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+
+	param.LastInvoiceID = page[len(page)-1].ID
+	next, err := client.ListInvoices(ctx, param)
+	require.NoError(t, err)
+	require.Len(t, next, 1)
+
+	externalIDs := map[string]bool{}
+	for _, invoice := range append(page, next...) {
+		externalIDs[invoice.ExternalID] = true
+	}
+	assert.Len(t, externalIDs, 3)
+
+	_, err = repository.NewXenditClient(config.XenditConfig{SecretApiKey: "wrong", BaseURL: baseURL}).ListInvoices(ctx, param)
+	assert.Error(t, err)
+}
+
+func Test_Emulator_PendingRefund(t *testing.T) {
+	client, gateway, baseURL, webhooks := setupEmulator(t, refundStatusPending)
+	ctx := context.Background()
+
+	_, err := client.CreateInvoice(ctx, models.XenditInvoiceRequest{ExternalID: "order-4", Amount: "2000"})
+	require.NoError(t, err)
+
+	control(t, baseURL, "/invoices/order-4/pay")
+	<-webhooks
+
+	refund, err := gateway.CreateRefund(ctx, models.GatewayRefundRequest{ExternalID: "order-4", ReferenceID: "refund-4"})
+	require.NoError(t, err)
+	assert.Equal(t, constant.RefundStatusPending, refund.Status)
+	assert.Equal(t, models.NewMoney(2000, models.CurrencyIDR), refund.Amount)
+
+	control(t, baseURL, "/refunds/"+refund.ID+"/succeed")
+
+	refund, err = gateway.GetRefund(ctx, "order-4", refund.ID)
+	require.NoError(t, err)
+	assert.Equal(t, constant.RefundStatusSucceeded, refund.Status)
+}
+
+func Test_Emulator_RetryFault(t *testing.T) {
+	log.SetupLogger()
+
+	client, _, baseURL, _ := setupEmulator(t, refundStatusSucceeded)
+	ctx := context.Background()
+
+	res, err := http.Post(baseURL+"/_control/faults", "application/json", strings.NewReader(`{"status":503,"count":2}`))
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	created, err := client.CreateInvoice(ctx, models.XenditInvoiceRequest{ExternalID: "order-5", Amount: "1000"})
+	require.NoError(t, err)
+
+	// same external id is the idempotency key, so no second invoice is created
+	again, err := client.CreateInvoice(ctx, models.XenditInvoiceRequest{ExternalID: "order-5", Amount: "1000"})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, again.ID)
+}
+
+func Test_Emulator_RichInvoice(t *testing.T) {
+	client, _, baseURL, _ := setupEmulator(t, refundStatusSucceeded)
+	ctx := context.Background()
+
+	gateway := repository.NewXenditGateway(client, "token", config.XenditInvoiceConfig{
+		InvoiceDuration:    time.Hour,
+		PaymentMethods:     []string{"BCA", "OVO"},
+		SuccessRedirectURL: "https://shop.test/payment/success?ref={external_id}",
+		FailureRedirectURL: "https://shop.test/payment/failure",
+	})
+
+	start := time.Now()
+	charge, err := gateway.CreateCharge(ctx, models.ChargeRequest{
+		ExternalID: "order-6",
+		Amount:     models.NewMoney(3000, models.CurrencyIDR),
+		PayerEmail: "deni@example.com",
+		PayerName:  "Deni",
+		PayerPhone: "+6281234567890",
+		Items: []models.OrderItem{
+			{Name: "Kopi", Quantity: 2, Price: "1500", Category: "beverage"},
+		},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, charge.ID)
+	assert.NotEmpty(t, charge.PaymentURL)
+	assert.WithinDuration(t, start.Add(time.Hour), charge.ExpiryTime, time.Minute)
+
+	req, err := http.NewRequest(http.MethodGet, baseURL+"/v2/invoices/"+charge.ID, nil)
+	require.NoError(t, err)
+	req.SetBasicAuth("secret", "")
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	var created invoice
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&created))
+	assert.Equal(t, "IDR", created.Currency)
+	assert.Equal(t, []string{"BCA", "OVO"}, created.PaymentMethods)
+	assert.Equal(t, "https://shop.test/payment/success?ref=order-6", created.SuccessRedirectURL)
+	assert.Equal(t, "https://shop.test/payment/failure", created.FailureRedirectURL)
+	assert.Equal(t, &customer{GivenNames: "Deni", Email: "deni@example.com", MobileNumber: "+6281234567890"}, created.Customer)
+	assert.Equal(t, []item{{Name: "Kopi", Quantity: 2, Price: 1500, Category: "beverage"}}, created.Items)
+}
+
+func Test_Emulator_MultiCurrency(t *testing.T) {
+	_, gateway, baseURL, webhooks := setupEmulator(t, refundStatusSucceeded)
+	ctx := context.Background()
+
+	amount := models.NewMoney(1235, models.CurrencyUSD)
+	charge, err := gateway.CreateCharge(ctx, models.ChargeRequest{
+		ExternalID: "order-7",
+		Amount:     amount,
+		Items: []models.OrderItem{
+			{Name: "Coffee", Quantity: 1, Price: "12.345"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, amount, charge.Amount)
+
+	control(t, baseURL, "/invoices/order-7/pay")
+
+	payload, err := gateway.ParseWebhook((<-webhooks).body)
+	require.NoError(t, err)
+	assert.Equal(t, amount, payload.Amount)
+}
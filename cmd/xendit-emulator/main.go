@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// xendit-emulator is an in-memory fake of the xendit invoice and refund api, for local development and CI.
+// Point xendit.base_url of the payment service at it:
+//
+//	go run ./cmd/xendit-emulator -addr=:4010 -webhook-url=http://localhost:8080/v1/payment/webhook -webhook-token=TOKEN
+//
+// Invoice is only paid or expired through the control api, which send the webhook to -webhook-url:
+//
+//	curl -X POST localhost:4010/_control/invoices/order-1/pay -d '{"payment_method":"BCA"}'
+//	curl -X POST localhost:4010/_control/invoices/order-2/expire
+//	curl -X POST localhost:4010/_control/invoices/order-1/webhook
+//	curl -X POST localhost:4010/_control/refunds/rfd-xxx/succeed
+//	curl -X POST localhost:4010/_control/faults -d '{"status":503,"count":2}'
+//	curl -X POST localhost:4010/_control/reset
+func main() {
+	addr := flag.String("addr", ":4010", "listen address")
+	publicURL := flag.String("public-url", "http://localhost:4010", "base url of invoice_url")
+	secretKey := flag.String("secret-key", "", "accepted xendit secret api key, empty accept every key")
+	webhookURL := flag.String("webhook-url", "http://localhost:8080/v1/payment/webhook", "payment service webhook url")
+	webhookToken := flag.String("webhook-token", "", "x-callback-token sent with webhook, same as xendit.webhook_token")
+	invoiceDuration := flag.Duration("invoice-duration", 24*time.Hour, "default invoice duration")
+	refundStatus := flag.String("refund-status", refundStatusSucceeded, "status of a new refund, SUCCEEDED or PENDING")
+	flag.Parse()
+
+	if *refundStatus != refundStatusSucceeded && *refundStatus != refundStatusPending {
+		log.Fatalf("invalid -refund-status: %s", *refundStatus)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	router := gin.Default()
+	newEmulator(emulatorConfig{
+		SecretKey:       *secretKey,
+		WebhookURL:      *webhookURL,
+		WebhookToken:    *webhookToken,
+		PublicURL:       *publicURL,
+		InvoiceDuration: *invoiceDuration,
+		RefundStatus:    *refundStatus,
+	}).register(router)
+
+	server := &http.Server{
+		Addr:    *addr,
+		Handler: router,
+	}
+
+	go func() {
+		log.Printf("Xendit emulator listening on %s", *addr)
+
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("server.ListenAndServe() got error: %v", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("server.Shutdown() got error: %v", err)
+	}
+}
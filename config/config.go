@@ -9,16 +9,18 @@ import (
 func LoadConfig() Config {
 	var cfg Config
 
-	viper.AddConfigPath("./files/config")
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	err := viper.ReadInConfig()
+	// topic names contain ".", use other key delimiter so kafka.topics is kept as a flat map
+	v := viper.NewWithOptions(viper.KeyDelimiter("::"))
+	v.AddConfigPath("./files/config")
+	v.SetConfigName("config")
+	v.SetConfigType("yaml")
+	err := v.ReadInConfig()
 
 	if err != nil {
 		log.Fatalf("error read config file: %s", err)
 	}
 
-	if err := viper.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg); err != nil {
 		log.Fatalf("error unmarshal app config: %s", err)
 	}
 
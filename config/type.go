@@ -1,21 +1,56 @@
 package config
 
+import "time"
+
 type Config struct {
-	App      AppConfig      `yaml:"app" validate:"required"`
-	Database DatabaseConfig `yaml:"database" validate:"required"`
-	Redis    RedisConfig    `yaml:"redis" validate:"required"`
-	Secret   SecretConfig   `yaml:"app" validate:"required"`
-	Kafka    KafkaConfig    `yaml:"kafka" validate:"required"`
-	Xendit   XenditConfig   `yaml:"xendit" validate:"required"`
-	Toggle   ToggleConfig   `yaml:"toggle" validate:"required"`
+	App       AppConfig       `yaml:"app" validate:"required"`
+	Database  DatabaseConfig  `yaml:"database" validate:"required"`
+	Redis     RedisConfig     `yaml:"redis" validate:"required"`
+	Secret    SecretConfig    `yaml:"secret" validate:"required"`
+	Kafka     KafkaConfig     `yaml:"kafka" validate:"required"`
+	Xendit    XenditConfig    `yaml:"xendit" validate:"required"`
+	Midtrans  MidtransConfig  `yaml:"midtrans"`
+	Gateway   GatewayConfig   `yaml:"gateway"`
+	Toggle    ToggleConfig    `yaml:"toggle" validate:"required"`
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+	// keyed by job name, job which is not configured keeps its default schedule
+	Jobs map[string]JobConfig `yaml:"jobs"`
+	// keyed by breaker name (xendit, midtrans, user_service), breaker which is not configured keeps the default
+	CircuitBreakers map[string]CircuitBreakerConfig `yaml:"circuit_breakers" mapstructure:"circuit_breakers"`
 }
 
 type AppConfig struct {
 	Port string `yaml:"port"`
+	// max time to wait for in-flight work on SIGTERM before connections are closed, e.g. 30s
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" mapstructure:"shutdown_timeout"`
 }
 
 type ToggleConfig struct {
-	DisableCreateInvoiceDirectly bool `yaml:"disable_create_invoice_directly"`
+	DisableCreateInvoiceDirectly bool `yaml:"disable_create_invoice_directly" mapstructure:"disable_create_invoice_directly"`
+}
+
+type SchedulerConfig struct {
+	// number of payment requests claimed per iteration
+	PaymentRequestBatchSize int `yaml:"payment_request_batch_size" mapstructure:"payment_request_batch_size"`
+	// claimed payment request which is not finished within the lease is claimed again, e.g. 5m
+	PaymentRequestClaimTTL time.Duration `yaml:"payment_request_claim_ttl" mapstructure:"payment_request_claim_ttl"`
+}
+
+type JobConfig struct {
+	// nil keeps the default, job is enabled by default
+	Enabled  *bool         `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+	Jitter   time.Duration `yaml:"jitter"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+type CircuitBreakerConfig struct {
+	// consecutive failures which open the breaker
+	FailureThreshold int `yaml:"failure_threshold" mapstructure:"failure_threshold"`
+	// how long every call is rejected before probe calls are let through, e.g. 30s
+	OpenTimeout time.Duration `yaml:"open_timeout" mapstructure:"open_timeout"`
+	// probe calls which must succeed to close the breaker
+	HalfOpenRequests int `yaml:"half_open_requests" mapstructure:"half_open_requests"`
 }
 
 type DatabaseConfig struct {
@@ -33,15 +68,67 @@ type RedisConfig struct {
 }
 
 type SecretConfig struct {
-	JWTSecret string `yaml:"jwt_secret_key"`
+	JWTSecret string `yaml:"jwt_secret_key" mapstructure:"jwt_secret_key"`
 }
 
 type KafkaConfig struct {
-	Broker string            `yaml:"broker"`
-	Topics map[string]string `yaml:"topics"`
+	Broker   string              `yaml:"broker"`
+	Topics   map[string]string   `yaml:"topics"`
+	Consumer KafkaConsumerConfig `yaml:"consumer"`
+}
+
+type KafkaConsumerConfig struct {
+	// number of workers, messages with the same key are always handled by the same worker
+	Workers int `yaml:"workers"`
+	// buffered messages per worker, fetching is paused when the worker queue is full
+	QueueSize int `yaml:"queue_size" mapstructure:"queue_size"`
+}
+
+// Topic return configured topic name, fallback to the default name when not configured.
+func (k KafkaConfig) Topic(name string) string {
+	if topic, ok := k.Topics[name]; ok && topic != "" {
+		return topic
+	}
+
+	return name
 }
 
 type XenditConfig struct {
-	SecretApiKey string `yaml:"secret_api_key"`
-	WebhookToken string `yaml:"webhook_token"`
+	SecretApiKey string `yaml:"secret_api_key" mapstructure:"secret_api_key"`
+	WebhookToken string `yaml:"webhook_token" mapstructure:"webhook_token"`
+	// empty means https://api.xendit.co, set it to the emulator url for local development
+	BaseURL string `yaml:"base_url" mapstructure:"base_url"`
+	// timeout of each attempt, e.g. 10s
+	Timeout time.Duration `yaml:"timeout"`
+	// nil keeps the default, 0 disable retry
+	MaxRetries *int `yaml:"max_retries" mapstructure:"max_retries"`
+	// backoff of the first retry, e.g. 200ms
+	RetryBackoff time.Duration       `yaml:"retry_backoff" mapstructure:"retry_backoff"`
+	Invoice      XenditInvoiceConfig `yaml:"invoice"`
+}
+
+// XenditInvoiceConfig is applied to every invoice, empty field keeps the xendit default.
+type XenditInvoiceConfig struct {
+	// e.g. 24h
+	InvoiceDuration time.Duration `yaml:"invoice_duration" mapstructure:"invoice_duration"`
+	// channels shown on the invoice, e.g. BCA, OVO. empty shows every channel enabled at xendit
+	PaymentMethods []string `yaml:"payment_methods" mapstructure:"payment_methods"`
+	// customer is redirected here after payment, {external_id} is replaced with the external id of the payment
+	SuccessRedirectURL string `yaml:"success_redirect_url" mapstructure:"success_redirect_url"`
+	FailureRedirectURL string `yaml:"failure_redirect_url" mapstructure:"failure_redirect_url"`
+}
+
+type MidtransConfig struct {
+	// midtrans gateway is only enabled when server key is set
+	ServerKey  string `yaml:"server_key" mapstructure:"server_key"`
+	Production bool   `yaml:"production"`
+	// timeout of each call, e.g. 10s
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type GatewayConfig struct {
+	// provider of payment method which is not configured, default is xendit
+	Default string `yaml:"default"`
+	// keyed by payment method of the order, e.g. gopay: midtrans
+	PaymentMethods map[string]string `yaml:"payment_methods" mapstructure:"payment_methods"`
 }
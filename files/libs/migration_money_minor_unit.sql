@@ -0,0 +1,18 @@
+-- amounts are stored as integer in minor unit of the currency, e.g. rupiah for IDR and cent for USD.
+-- every existing row is IDR which has no decimals, so the amount is only rounded
+ALTER TABLE payments ALTER COLUMN amount TYPE BIGINT USING ROUND(amount);
+ALTER TABLE payments ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'IDR';
+
+UPDATE payment_requests SET currency = 'IDR' WHERE currency IS NULL OR currency = '';
+ALTER TABLE payment_requests ALTER COLUMN amount TYPE BIGINT USING ROUND(amount);
+ALTER TABLE payment_requests ALTER COLUMN currency SET DEFAULT 'IDR';
+ALTER TABLE payment_requests ALTER COLUMN currency SET NOT NULL;
+
+ALTER TABLE refunds ALTER COLUMN amount TYPE BIGINT USING ROUND(amount);
+ALTER TABLE refunds ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'IDR';
+
+ALTER TABLE reconciliation_discrepancies ALTER COLUMN local_amount TYPE BIGINT USING ROUND(local_amount);
+ALTER TABLE reconciliation_discrepancies ALTER COLUMN xendit_amount TYPE BIGINT USING ROUND(xendit_amount);
+ALTER TABLE reconciliation_discrepancies ADD COLUMN local_currency VARCHAR(3);
+ALTER TABLE reconciliation_discrepancies ADD COLUMN xendit_currency VARCHAR(3);
+UPDATE reconciliation_discrepancies SET local_currency = 'IDR', xendit_currency = 'IDR';
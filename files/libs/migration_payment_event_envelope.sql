@@ -0,0 +1,12 @@
+-- payment method and paid time are published in payment.success event
+ALTER TABLE payments ADD COLUMN payment_method VARCHAR(50);
+ALTER TABLE payments ADD COLUMN paid_time TIMESTAMP;
+
+-- request id of the webhook, used as correlation id when inbox is processed asynchronously
+ALTER TABLE webhook_inbox ADD COLUMN request_id VARCHAR(100);
+
+-- event id of the envelope, stable across relay retries so consumer can drop duplicate event
+ALTER TABLE outbox ADD COLUMN event_id VARCHAR(100);
+UPDATE outbox SET event_id = 'outbox-' || id WHERE event_id IS NULL;
+ALTER TABLE outbox ALTER COLUMN event_id SET NOT NULL;
+ALTER TABLE outbox ADD CONSTRAINT outbox_event_id_key UNIQUE (event_id);
@@ -0,0 +1,4 @@
+-- payment is created at the gateway chosen by payment method, existing payments are xendit invoices
+ALTER TABLE payments ADD COLUMN provider VARCHAR(50) NOT NULL DEFAULT 'xendit';
+ALTER TABLE payment_requests ADD COLUMN payment_method VARCHAR(50);
+ALTER TABLE refunds RENAME COLUMN xendit_refund_id TO gateway_refund_id;
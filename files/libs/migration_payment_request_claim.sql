@@ -0,0 +1,4 @@
+-- request is claimed by a worker before invoice is created, stuck claim is claimed again after lease expire time
+ALTER TABLE payment_requests ADD COLUMN claimed_by VARCHAR(100);
+ALTER TABLE payment_requests ADD COLUMN lease_expire_time TIMESTAMP;
+CREATE INDEX idx_payment_requests_status_create_time ON payment_requests (status, create_time);
@@ -0,0 +1,2 @@
+-- MarkPaid used to store lowercase status, normalize it before payment status transitions are enforced
+UPDATE payments SET status = UPPER(status), update_time = CURRENT_TIMESTAMP WHERE status <> UPPER(status);
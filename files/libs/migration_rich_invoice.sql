@@ -0,0 +1,8 @@
+-- invoice or transaction id at the gateway, payments created before this migration are looked up by external id
+ALTER TABLE payments ADD COLUMN gateway_charge_id VARCHAR(100);
+
+-- order detail which is sent to the gateway when the request is processed by the scheduler
+ALTER TABLE payment_requests ADD COLUMN currency VARCHAR(3);
+ALTER TABLE payment_requests ADD COLUMN customer_name VARCHAR(255);
+ALTER TABLE payment_requests ADD COLUMN customer_phone VARCHAR(50);
+ALTER TABLE payment_requests ADD COLUMN items JSONB;
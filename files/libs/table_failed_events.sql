@@ -6,6 +6,8 @@ CREATE TABLE failed_events (
     failed_type integer NOT NULL,
     notes TEXT,
     status integer NOT NULL,
+    retry_count integer NOT NULL DEFAULT 0,
+    payload TEXT,
     create_time TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     update_time TIMESTAMP
 )
\ No newline at end of file
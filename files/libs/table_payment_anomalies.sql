@@ -4,7 +4,10 @@ CREATE TABLE payment_anomalies (
     external_id TEXT,
     anomaly_type INTEGER,
     notes text,
-    statis integer,
+    status integer,
     create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    update_time TIMESTAMP
+    update_time TIMESTAMP,
+    resolved_by BIGINT,
+    resolution_notes TEXT,
+    resolved_time TIMESTAMP
 )
\ No newline at end of file
@@ -1,11 +1,21 @@
 CREATE TABLE payment_requests (
     id BIGSERIAL PRIMARY KEY,
     order_id BIGINT NOT NULL,
-    amount NUMERIC,
+    amount BIGINT,
     user_email varchar(255),
+    payment_method varchar(50),
+    currency varchar(3) NOT NULL DEFAULT 'IDR',
+    customer_name varchar(255),
+    customer_phone varchar(50),
+    items jsonb,
     status varchar(50),
     retry_count INT,
     notes text,
+    claimed_by varchar(100),
+    lease_expire_time TIMESTAMP,
     create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     update_time TIMESTAMP
-)
\ No newline at end of file
+);
+
+-- pending requests are claimed in create_time order
+CREATE INDEX idx_payment_requests_status_create_time ON payment_requests (status, create_time);
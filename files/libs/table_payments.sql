@@ -3,8 +3,15 @@ CREATE TABLE payments (
     order_id BIGINT,
     user_id BIGINT,
     external_id TEXT UNIQUE NOT NULL,
-    amount NUMERIC,
+    -- minor unit of the currency
+    amount BIGINT,
+    currency VARCHAR(3) NOT NULL DEFAULT 'IDR',
     status VARCHAR,
+    provider VARCHAR(50) NOT NULL DEFAULT 'xendit',
+    gateway_charge_id VARCHAR(100),
+    invoice_url TEXT,
+    payment_method VARCHAR(50),
+    paid_time TIMESTAMP,
     expired_time TIMESTAMP,
     create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     update_time TIMESTAMP
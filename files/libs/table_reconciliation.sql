@@ -0,0 +1,31 @@
+CREATE TABLE reconciliation_runs (
+    id BIGSERIAL PRIMARY KEY,
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    xendit_invoice_count INTEGER,
+    local_payment_count INTEGER,
+    discrepancy_count INTEGER,
+    triggered_by VARCHAR(100),
+    create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_reconciliation_runs_period ON reconciliation_runs (period_start, period_end);
+
+CREATE TABLE reconciliation_discrepancies (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT NOT NULL,
+    discrepancy_type VARCHAR(50) NOT NULL,
+    order_id BIGINT,
+    external_id TEXT,
+    xendit_invoice_id TEXT,
+    local_status VARCHAR(50),
+    xendit_status VARCHAR(50),
+    local_amount BIGINT,
+    local_currency VARCHAR(3),
+    xendit_amount BIGINT,
+    xendit_currency VARCHAR(3),
+    notes TEXT,
+    create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_reconciliation_discrepancies_run_id ON reconciliation_discrepancies (run_id);
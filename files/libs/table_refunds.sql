@@ -0,0 +1,17 @@
+CREATE TABLE refunds (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL,
+    payment_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    external_id TEXT NOT NULL,
+    reference_id TEXT UNIQUE NOT NULL,
+    gateway_refund_id TEXT,
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'IDR',
+    reason VARCHAR(50),
+    status VARCHAR(50) NOT NULL,
+    notes TEXT,
+    requested_by BIGINT,
+    create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    update_time TIMESTAMP
+)
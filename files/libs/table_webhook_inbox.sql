@@ -0,0 +1,16 @@
+CREATE TABLE webhook_inbox (
+    id BIGSERIAL PRIMARY KEY,
+    source VARCHAR(50) NOT NULL,
+    external_id TEXT,
+    request_id VARCHAR(100),
+    headers JSONB,
+    raw_body TEXT NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    result TEXT,
+    received_time TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    processed_time TIMESTAMP,
+    update_time TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_inbox_status_received_time ON webhook_inbox (status, received_time);
@@ -11,25 +11,32 @@ import (
 
 type UserClient interface {
 	GetUserInfoByUserId(ctx context.Context, userID int64) (*userpb.GetUserInfoResult, error)
+	Close() error
 }
 
 type userClient struct {
 	Client userpb.UserServiceClient
+	conn   *grpc.ClientConn
 }
 
 func NewUserClient() UserClient {
 	conn, err := grpc.Dial("localhost:50051", grpc.WithInsecure())
 	if err != nil {
-		log.Fatalf("Failed to connect to gRPC server: " + err.Error())
+		log.Fatalf("Failed to connect to gRPC server: %v", err)
 	}
 
 	client := userpb.NewUserServiceClient(conn)
 
 	return &userClient{
 		Client: client,
+		conn:   conn,
 	}
 }
 
+func (uc *userClient) Close() error {
+	return uc.conn.Close()
+}
+
 func (uc *userClient) GetUserInfoByUserId(ctx context.Context, userID int64) (*userpb.GetUserInfoResult, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	"context"
+	"payment/infrastructure/breaker"
+	"payment/proto/userpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type breakerUserClient struct {
+	UserClient
+	breaker *breaker.Breaker
+}
+
+// NewBreakerUserClient wrap the client with a circuit breaker, breaker.ErrOpen is returned while it is open.
+func NewBreakerUserClient(client UserClient, cb *breaker.Breaker) UserClient {
+	return &breakerUserClient{
+		UserClient: client,
+		breaker:    cb,
+	}
+}
+
+// IsUserServiceFailure is true when the error means user service is unhealthy, e.g. unknown user is not.
+func IsUserServiceFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
+
+func (uc *breakerUserClient) GetUserInfoByUserId(ctx context.Context, userID int64) (userInfo *userpb.GetUserInfoResult, err error) {
+	err = uc.breaker.Execute(func() (err error) {
+		userInfo, err = uc.UserClient.GetUserInfoByUserId(ctx, userID)
+		return err
+	})
+
+	return userInfo, err
+}
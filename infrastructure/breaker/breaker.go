@@ -0,0 +1,232 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrOpen is returned without calling the dependency while the breaker is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+type Settings struct {
+	Name string
+	// consecutive failures which open the breaker
+	FailureThreshold int
+	// how long the breaker stays open before probe calls are let through
+	OpenTimeout time.Duration
+	// concurrent probe calls in half open state, breaker is closed when all of them succeed
+	HalfOpenRequests int
+	// IsFailure decide whether the error means the dependency is unhealthy, e.g. validation error is not.
+	// nil count every error except canceled context
+	IsFailure func(err error) bool
+}
+
+// Status is the state of the breaker in this replica, each replica has its own breaker.
+type Status struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	FailureThreshold    int        `json:"failure_threshold"`
+	OpenedAt            *time.Time `json:"opened_at"`
+	// open breaker let probe calls through after this time
+	HalfOpenAt      *time.Time `json:"half_open_at"`
+	Rejected        int64      `json:"rejected"`
+	LastError       string     `json:"last_error"`
+	LastFailureTime *time.Time `json:"last_failure_time"`
+}
+
+// Breaker stop calling a dependency after consecutive failures, so callers fail fast instead of waiting
+// for the timeout of every call.
+type Breaker struct {
+	settings Settings
+	now      func() time.Time
+
+	mu                  sync.Mutex
+	state               string
+	consecutiveFailures int
+	openedAt            time.Time
+	halfOpenInFlight    int
+	halfOpenSuccesses   int
+	// changed on every state change, result of a call started in the previous state is ignored
+	generation      uint64
+	rejected        int64
+	lastError       string
+	lastFailureTime time.Time
+}
+
+func New(settings Settings) *Breaker {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = constant.DefaultCircuitBreakerFailureThreshold
+	}
+
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = constant.DefaultCircuitBreakerOpenTimeout
+	}
+
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = constant.DefaultCircuitBreakerHalfOpenRequests
+	}
+
+	if settings.IsFailure == nil {
+		settings.IsFailure = isFailure
+	}
+
+	return &Breaker{
+		settings: settings,
+		now:      time.Now,
+		state:    constant.CircuitBreakerStateClosed,
+	}
+}
+
+func (b *Breaker) Name() string {
+	return b.settings.Name
+}
+
+// Execute call fn when the breaker allows it and record the result. ErrOpen is returned when it does not.
+func (b *Breaker) Execute(fn func() error) error {
+	generation, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	b.record(generation, err)
+
+	return err
+}
+
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := Status{
+		Name:                b.settings.Name,
+		State:               b.currentState(),
+		ConsecutiveFailures: b.consecutiveFailures,
+		FailureThreshold:    b.settings.FailureThreshold,
+		Rejected:            b.rejected,
+		LastError:           b.lastError,
+	}
+
+	if status.State != constant.CircuitBreakerStateClosed {
+		openedAt := b.openedAt
+		halfOpenAt := b.openedAt.Add(b.settings.OpenTimeout)
+		status.OpenedAt = &openedAt
+		status.HalfOpenAt = &halfOpenAt
+	}
+
+	if !b.lastFailureTime.IsZero() {
+		lastFailureTime := b.lastFailureTime
+		status.LastFailureTime = &lastFailureTime
+	}
+
+	return status
+}
+
+func (b *Breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState() {
+	case constant.CircuitBreakerStateOpen:
+		b.rejected++
+		return 0, fmt.Errorf("%w: %s", ErrOpen, b.settings.Name)
+	case constant.CircuitBreakerStateHalfOpen:
+		if b.state == constant.CircuitBreakerStateOpen {
+			b.setState(constant.CircuitBreakerStateHalfOpen)
+		}
+
+		if b.halfOpenInFlight >= b.settings.HalfOpenRequests {
+			b.rejected++
+			return 0, fmt.Errorf("%w: %s", ErrOpen, b.settings.Name)
+		}
+
+		b.halfOpenInFlight++
+	}
+
+	return b.generation, nil
+}
+
+func (b *Breaker) record(generation uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+
+	failed := err != nil && b.settings.IsFailure(err)
+	if failed {
+		b.lastError = err.Error()
+		b.lastFailureTime = b.now()
+	}
+
+	switch b.state {
+	case constant.CircuitBreakerStateClosed:
+		if !failed {
+			b.consecutiveFailures = 0
+			return
+		}
+
+		b.consecutiveFailures++
+		if b.consecutiveFailures >= b.settings.FailureThreshold {
+			b.setState(constant.CircuitBreakerStateOpen)
+		}
+	case constant.CircuitBreakerStateHalfOpen:
+		b.halfOpenInFlight--
+		if failed {
+			b.consecutiveFailures++
+			b.setState(constant.CircuitBreakerStateOpen)
+			return
+		}
+
+		b.halfOpenSuccesses++
+		if b.halfOpenSuccesses >= b.settings.HalfOpenRequests {
+			b.setState(constant.CircuitBreakerStateClosed)
+		}
+	}
+}
+
+// currentState report open breaker whose timeout is passed as half open, the state is changed by the next call.
+func (b *Breaker) currentState() string {
+	if b.state == constant.CircuitBreakerStateOpen && !b.now().Before(b.openedAt.Add(b.settings.OpenTimeout)) {
+		return constant.CircuitBreakerStateHalfOpen
+	}
+
+	return b.state
+}
+
+func (b *Breaker) setState(state string) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.halfOpenInFlight = 0
+	b.halfOpenSuccesses = 0
+
+	switch state {
+	case constant.CircuitBreakerStateOpen:
+		b.openedAt = b.now()
+	case constant.CircuitBreakerStateClosed:
+		b.consecutiveFailures = 0
+	}
+
+	log.Logger.WithFields(logrus.Fields{
+		"breaker":              b.settings.Name,
+		"from":                 from,
+		"to":                   state,
+		"consecutive_failures": b.consecutiveFailures,
+		"last_error":           b.lastError,
+	}).Warn("Circuit breaker state is changed")
+}
+
+// caller which gives up does not mean the dependency is unhealthy
+func isFailure(err error) bool {
+	return !errors.Is(err, context.Canceled)
+}
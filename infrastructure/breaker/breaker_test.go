@@ -0,0 +1,138 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestBreaker(settings Settings) (*Breaker, *fakeClock) {
+	log.SetupLogger()
+
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := New(settings)
+	b.now = clock.Now
+
+	return b, clock
+}
+
+func fail() error {
+	return errUnavailable
+}
+
+func succeed() error {
+	return nil
+}
+
+func Test_Breaker_OpenAfterConsecutiveFailures(t *testing.T) {
+	b, _ := newTestBreaker(Settings{Name: "test", FailureThreshold: 3, OpenTimeout: time.Minute})
+
+	assert.ErrorIs(t, b.Execute(fail), errUnavailable)
+	assert.ErrorIs(t, b.Execute(fail), errUnavailable)
+	// success reset the consecutive failures
+	assert.NoError(t, b.Execute(succeed))
+	assert.ErrorIs(t, b.Execute(fail), errUnavailable)
+	assert.ErrorIs(t, b.Execute(fail), errUnavailable)
+	assert.Equal(t, constant.CircuitBreakerStateClosed, b.Status().State)
+
+	assert.ErrorIs(t, b.Execute(fail), errUnavailable)
+	assert.Equal(t, constant.CircuitBreakerStateOpen, b.Status().State)
+
+	called := false
+	err := b.Execute(func() error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.False(t, called)
+
+	status := b.Status()
+	assert.Equal(t, int64(1), status.Rejected)
+	assert.Equal(t, errUnavailable.Error(), status.LastError)
+	assert.NotNil(t, status.OpenedAt)
+}
+
+func Test_Breaker_HalfOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		probe     func() error
+		wantState string
+	}{
+		{
+			name:      "success probe close the breaker",
+			probe:     succeed,
+			wantState: constant.CircuitBreakerStateClosed,
+		},
+		{
+			name:      "failed probe open the breaker again",
+			probe:     fail,
+			wantState: constant.CircuitBreakerStateOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, clock := newTestBreaker(Settings{Name: "test", FailureThreshold: 1, OpenTimeout: time.Minute})
+
+			assert.ErrorIs(t, b.Execute(fail), errUnavailable)
+			assert.ErrorIs(t, b.Execute(succeed), ErrOpen)
+
+			clock.now = clock.now.Add(time.Minute)
+			assert.Equal(t, constant.CircuitBreakerStateHalfOpen, b.Status().State)
+
+			// only one probe is let through at a time
+			_ = b.Execute(func() error {
+				assert.ErrorIs(t, b.Execute(succeed), ErrOpen)
+				return tt.probe()
+			})
+
+			assert.Equal(t, tt.wantState, b.Status().State)
+		})
+	}
+}
+
+func Test_Breaker_IsFailure(t *testing.T) {
+	errNotFound := errors.New("not found")
+	b, _ := newTestBreaker(Settings{
+		Name:             "test",
+		FailureThreshold: 1,
+		IsFailure: func(err error) bool {
+			return !errors.Is(err, errNotFound)
+		},
+	})
+
+	assert.ErrorIs(t, b.Execute(func() error { return errNotFound }), errNotFound)
+	assert.ErrorIs(t, b.Execute(func() error { return context.Canceled }), context.Canceled)
+	assert.Equal(t, constant.CircuitBreakerStateOpen, b.Status().State, "custom IsFailure replace the default")
+
+	b, _ = newTestBreaker(Settings{Name: "test", FailureThreshold: 1})
+	assert.ErrorIs(t, b.Execute(func() error { return context.Canceled }), context.Canceled)
+	assert.Equal(t, constant.CircuitBreakerStateClosed, b.Status().State)
+}
+
+func Test_Breaker_IgnoreResultOfPreviousState(t *testing.T) {
+	b, _ := newTestBreaker(Settings{Name: "test", FailureThreshold: 1, OpenTimeout: time.Minute})
+
+	// slow call started while closed finish after another call opened the breaker
+	_ = b.Execute(func() error {
+		assert.ErrorIs(t, b.Execute(fail), errUnavailable)
+		return nil
+	})
+
+	assert.Equal(t, constant.CircuitBreakerStateOpen, b.Status().State)
+}
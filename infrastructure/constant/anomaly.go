@@ -1,7 +1,10 @@
 package constant
 
 const (
-	AnomalyTypeInvalidAmount = 1
+	AnomalyTypeInvalidAmount           = 1
+	AnomalyTypeInvalidStatusTransition = 2
+	AnomalyTypeUnknownWebhookStatus    = 3
+	AnomalyTypeCurrencyMismatch        = 4
 )
 
 const (
@@ -0,0 +1,27 @@
+package constant
+
+import "time"
+
+// circuit breaker names, used as the key of circuit_breakers config
+const (
+	CircuitBreakerXendit      = "xendit"
+	CircuitBreakerMidtrans    = "midtrans"
+	CircuitBreakerUserService = "user_service"
+)
+
+const (
+	CircuitBreakerStateClosed   = "CLOSED"
+	CircuitBreakerStateOpen     = "OPEN"
+	CircuitBreakerStateHalfOpen = "HALF_OPEN"
+)
+
+const (
+	// consecutive failures which open the breaker
+	DefaultCircuitBreakerFailureThreshold = 5
+
+	// open breaker reject every call for the timeout, then let probe calls through
+	DefaultCircuitBreakerOpenTimeout = 30 * time.Second
+
+	// probe calls in half open state, breaker is closed when all of them succeed
+	DefaultCircuitBreakerHalfOpenRequests = 1
+)
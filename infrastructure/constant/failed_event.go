@@ -1,11 +1,18 @@
 package constant
 
 const (
-	FailedPublishEventPaymentSuccess = 1
+	FailedPublishEventPaymentSuccess  = 1
+	FailedPublishEventPaymentRefunded = 2
+	FailedPublishEventPaymentExpired  = 3
+	FailedPublishEventPaymentFailed   = 4
 )
 
 const (
 	FailedPublishEventStatusSuccess     = 1
 	FailedPublishEventStatusRetry       = 2
+	FailedPublishEventStatusClosed      = 3
 	FailedPublishEventStatusNeedToCheck = 99
 )
+
+// max replay attempt by scheduler before it is moved back to need to check
+const MaxRetryFailedEvent = 5
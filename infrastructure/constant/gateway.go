@@ -0,0 +1,22 @@
+package constant
+
+import "time"
+
+const (
+	GatewayProviderXendit   = "xendit"
+	GatewayProviderMidtrans = "midtrans"
+
+	DefaultGatewayProvider = GatewayProviderXendit
+)
+
+// midtrans call is not retried, a hung connection is cut by the timeout
+const DefaultMidtransTimeout = 10 * time.Second
+
+// provider-neutral status of charge and webhook
+const (
+	GatewayStatusPending  = "PENDING"
+	GatewayStatusPaid     = "PAID"
+	GatewayStatusExpired  = "EXPIRED"
+	GatewayStatusFailed   = "FAILED"
+	GatewayStatusRefunded = "REFUNDED"
+)
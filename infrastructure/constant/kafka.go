@@ -1,6 +1,28 @@
 package constant
 
 const (
-	KafkaTopicPaymentSuccess = "payment.success"
-	KafkaTopicOrderCreated   = "order.created"
+	KafkaTopicPaymentSuccess  = "payment.success"
+	KafkaTopicPaymentExpired  = "payment.expired"
+	KafkaTopicPaymentFailed   = "payment.failed"
+	KafkaTopicPaymentRefunded = "payment.refunded"
+	KafkaTopicOrderCreated    = "order.created"
+	KafkaTopicOrderCreatedDLQ = "order.created.dlq"
+)
+
+// headers of message forwarded to dlq
+const (
+	DLQHeaderErrorType         = "x-error-type"
+	DLQHeaderErrorMessage      = "x-error-message"
+	DLQHeaderAttempts          = "x-attempts"
+	DLQHeaderOriginalTopic     = "x-original-topic"
+	DLQHeaderOriginalPartition = "x-original-partition"
+	DLQHeaderOriginalOffset    = "x-original-offset"
+	DLQHeaderFailedAt          = "x-failed-at"
+)
+
+// value of DLQHeaderErrorType
+const (
+	DLQErrorTypeDecode      = "decode"
+	DLQErrorTypePermanent   = "permanent"
+	DLQErrorTypeMaxAttempts = "max_attempts"
 )
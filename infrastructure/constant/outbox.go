@@ -0,0 +1,9 @@
+package constant
+
+const (
+	OutboxStatusPending   = "PENDING"
+	OutboxStatusPublished = "PUBLISHED"
+)
+
+// kafka header which contains event id of the envelope, consumer use it to drop duplicate event
+const KafkaHeaderEventID = "event_id"
@@ -0,0 +1,17 @@
+package constant
+
+import "time"
+
+const (
+	PaymentRequestStatusPending    = "PENDING"
+	PaymentRequestStatusProcessing = "PROCESSING"
+	PaymentRequestStatusSuccess    = "SUCCESS"
+	PaymentRequestStatusFailed     = "FAILED"
+)
+
+const (
+	DefaultPaymentRequestBatchSize = 5
+
+	// claimed request which is not finished before the lease expires is claimed again by other worker
+	DefaultPaymentRequestClaimTTL = 5 * time.Minute
+)
@@ -0,0 +1,31 @@
+package constant
+
+import "time"
+
+const (
+	// paid at xendit, but still pending, expired or failed in payments table
+	ReconciliationXenditPaidLocalUnpaid = "XENDIT_PAID_LOCAL_UNPAID"
+	// paid in payments table, but the invoice is not paid or not found at xendit
+	ReconciliationLocalPaidXenditUnpaid = "LOCAL_PAID_XENDIT_UNPAID"
+	ReconciliationAmountMismatch        = "AMOUNT_MISMATCH"
+	// invoice at xendit which has no payment in payments table
+	ReconciliationUnknownInvoice = "UNKNOWN_INVOICE"
+)
+
+const (
+	XenditInvoiceStatusPending = "PENDING"
+	XenditInvoiceStatusPaid    = "PAID"
+	XenditInvoiceStatusSettled = "SETTLED"
+	XenditInvoiceStatusExpired = "EXPIRED"
+	XenditInvoiceStatusFailed  = "FAILED"
+)
+
+const (
+	// max page size of xendit list invoices
+	XenditListInvoicesLimit = 100
+
+	// previous day is reconciled after the delay, so late webhook of the day is already processed
+	ReconciliationDelay = time.Hour
+
+	ReconciliationActorScheduler = "scheduler"
+)
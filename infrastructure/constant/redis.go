@@ -0,0 +1,33 @@
+package constant
+
+import "time"
+
+const (
+	// payment:webhook:{source}:{callback id}:{status}
+	RedisKeyWebhookDedup = "payment:webhook:%s:%s:%s"
+
+	// payment:lock:order:{order id}
+	RedisKeyPaymentLock = "payment:lock:order:%d"
+
+	// payment:lease:scheduler:{job}
+	RedisKeySchedulerLease = "payment:lease:scheduler:%s"
+
+	// payment:job:{job}:status, payment:job:{job}:paused, payment:job:{job}:trigger
+	RedisKeyJobStatus  = "payment:job:%s:status"
+	RedisKeyJobPaused  = "payment:job:%s:paused"
+	RedisKeyJobTrigger = "payment:job:%s:trigger"
+)
+
+const (
+	// xendit retry the webhook for at most a day
+	WebhookDedupTTL = 24 * time.Hour
+
+	// must be longer than the mark paid and save outbox transaction of ProcessPaymentSuccess, event is published by outbox relay
+	PaymentLockTTL = time.Minute
+
+	// leader is renewed every third of ttl, other replica takes over at most ttl after the leader dies
+	SchedulerLeaseTTL = 30 * time.Second
+
+	// trigger which is not taken, e.g. no replica is the leader, is dropped instead of run much later
+	JobTriggerTTL = 10 * time.Minute
+)
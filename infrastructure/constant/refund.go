@@ -0,0 +1,22 @@
+package constant
+
+import "time"
+
+const (
+	RefundStatusPending   = "PENDING"
+	RefundStatusSucceeded = "SUCCEEDED"
+	RefundStatusFailed    = "FAILED"
+)
+
+// refund whose create result is unknown, e.g. gateway timeout, is created again by scheduler after the delay,
+// so it is not sent twice at the same time as the request which is still waiting for the gateway
+const RefundRetryDelay = time.Minute
+
+// refund reason accepted by xendit
+const (
+	RefundReasonFraudulent          = "FRAUDULENT"
+	RefundReasonDuplicate           = "DUPLICATE"
+	RefundReasonRequestedByCustomer = "REQUESTED_BY_CUSTOMER"
+	RefundReasonCancellation        = "CANCELLATION"
+	RefundReasonOthers              = "OTHERS"
+)
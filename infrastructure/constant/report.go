@@ -0,0 +1,16 @@
+package constant
+
+// category of settlement summary
+const (
+	SettlementCategoryPaid     = "PAID"
+	SettlementCategoryRefunded = "REFUNDED"
+	SettlementCategoryExpired  = "EXPIRED"
+)
+
+const (
+	ReportFormatCSV  = "csv"
+	ReportFormatXLSX = "xlsx"
+
+	// settlement report longer than a year must be split
+	MaxSettlementReportDays = 366
+)
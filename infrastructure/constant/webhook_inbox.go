@@ -0,0 +1,11 @@
+package constant
+
+const (
+	WebhookInboxStatusReceived   = "RECEIVED"
+	WebhookInboxStatusProcessing = "PROCESSING"
+	WebhookInboxStatusProcessed  = "PROCESSED"
+	WebhookInboxStatusFailed     = "FAILED"
+)
+
+// max attempt of webhook inbox processed by worker, after that it can only be replayed manually
+const MaxWebhookInboxAttempts = 5
@@ -0,0 +1,13 @@
+package constant
+
+import "time"
+
+const (
+	// every attempt of a xendit call has its own timeout
+	DefaultXenditTimeout = 10 * time.Second
+
+	// call is retried on network error, 429 and 5xx, backoff is doubled per retry with jitter
+	DefaultXenditMaxRetries   = 3
+	DefaultXenditRetryBackoff = 200 * time.Millisecond
+	MaxXenditRetryBackoff     = 5 * time.Second
+)
@@ -0,0 +1,93 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobDisabled = errors.New("job is disabled")
+)
+
+// Func run a single iteration of the job. ctx is canceled by the job timeout, when the lease is lost or on
+// shutdown, job must check it between items so it does not keep running while other replica is the leader.
+type Func func(ctx context.Context) (Result, error)
+
+type Result struct {
+	// items which are handled successfully
+	Processed int
+	// items which are failed, failed item does not fail the whole run
+	Failed int
+	// run again immediately, e.g. a full batch is handled and there may be more items
+	HasMore bool
+}
+
+type Job struct {
+	Name    string
+	Enabled bool
+	// delay between the end of a run and the start of the next run
+	Interval time.Duration
+	// delay after the run return error, fallback to interval
+	RetryInterval time.Duration
+	// random delay up to jitter is added to the delay, so replicas do not hit dependencies at the same time
+	Jitter time.Duration
+	// max duration of a run, 0 means no timeout
+	Timeout time.Duration
+	Run     Func
+}
+
+// Lease make sure a job is only run by one replica at a time.
+type Lease interface {
+	AcquireLock(ctx context.Context, key string, ttl time.Duration) (string, bool, error)
+	RenewLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error)
+	ReleaseLock(ctx context.Context, key string, token string) error
+}
+
+// Store keep job state which is shared by replicas, so status and admin actions work on any replica.
+type Store interface {
+	SaveRunStatus(ctx context.Context, name string, status RunStatus) error
+	// GetRunStatus return empty status when the job never run
+	GetRunStatus(ctx context.Context, name string) (RunStatus, error)
+	SetPaused(ctx context.Context, name string, paused bool) error
+	IsPaused(ctx context.Context, name string) (bool, error)
+	RequestTrigger(ctx context.Context, name string) error
+	// TakeTrigger return true once for every requested trigger
+	TakeTrigger(ctx context.Context, name string) (bool, error)
+}
+
+// Manager is used by admin to see and control registered jobs.
+type Manager interface {
+	Statuses(ctx context.Context) ([]Status, error)
+	Trigger(ctx context.Context, name string) error
+	Pause(ctx context.Context, name string) error
+	Resume(ctx context.Context, name string) error
+}
+
+// RunStatus is the result of the latest run, written by the replica which run the job.
+type RunStatus struct {
+	InstanceID      string     `json:"instance_id"`
+	Running         bool       `json:"running"`
+	LastStartTime   *time.Time `json:"last_start_time"`
+	LastEndTime     *time.Time `json:"last_end_time"`
+	LastDurationMs  int64      `json:"last_duration_ms"`
+	LastProcessed   int        `json:"last_processed"`
+	LastFailed      int        `json:"last_failed"`
+	LastError       string     `json:"last_error"`
+	LastErrorTime   *time.Time `json:"last_error_time"`
+	LastSuccessTime *time.Time `json:"last_success_time"`
+	NextRunTime     *time.Time `json:"next_run_time"`
+	RunCount        int64      `json:"run_count"`
+	ErrorCount      int64      `json:"error_count"`
+}
+
+type Status struct {
+	Name     string `json:"name"`
+	Enabled  bool   `json:"enabled"`
+	Paused   bool   `json:"paused"`
+	Interval string `json:"interval"`
+	Jitter   string `json:"jitter"`
+	Timeout  string `json:"timeout"`
+	RunStatus
+}
@@ -0,0 +1,97 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runAsLeader acquire the lease of the job and run fn while the lease is held. ctx passed to fn is
+// canceled when the parent ctx is done or the lease can not be renewed, then the lease is released.
+// It return immediately when the lease is held by other replica.
+func (r *Runner) runAsLeader(ctx context.Context, name string, fn func(ctx context.Context)) {
+	key := fmt.Sprintf(constant.RedisKeySchedulerLease, name)
+
+	token, acquired, err := r.lease.AcquireLock(ctx, key, r.leaseTTL)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"job": name,
+		}).Errorf("r.lease.AcquireLock() got error: %v", err)
+		return
+	}
+
+	if !acquired {
+		return
+	}
+
+	log.Logger.WithFields(logrus.Fields{
+		"job": name,
+	}).Info("Job lease is acquired")
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		r.renewLease(leaderCtx, cancel, name, key, token)
+	}()
+
+	fn(leaderCtx)
+
+	cancel()
+	<-renewed
+
+	// let other replica take over immediately instead of waiting for the lease to expire
+	err = r.lease.ReleaseLock(context.WithoutCancel(ctx), key, token)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"job": name,
+		}).Errorf("r.lease.ReleaseLock() got error: %v", err)
+	}
+}
+
+// renewLease extend the lease every third of ttl until ctx is done. lost is called as soon as the lease is
+// owned by other replica, or when it is not renewed for 2/3 of ttl because redis is not reachable, so the
+// job is stopped before the lease expires and other replica can take over.
+func (r *Runner) renewLease(ctx context.Context, lost context.CancelFunc, name string, key string, token string) {
+	ticker := time.NewTicker(r.leaseTTL / 3)
+	defer ticker.Stop()
+
+	giveUpAfter := r.leaseTTL * 2 / 3
+	lastRenewed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// slow redis must not hold the renew past the point the lease is given up
+		renewCtx, cancel := context.WithTimeout(ctx, r.leaseTTL/3)
+		renewed, err := r.lease.RenewLock(renewCtx, key, token, r.leaseTTL)
+		cancel()
+		if err == nil && renewed {
+			lastRenewed = time.Now()
+			continue
+		}
+
+		if err != nil && time.Since(lastRenewed) < giveUpAfter {
+			log.Logger.WithFields(logrus.Fields{
+				"job": name,
+			}).Warnf("r.lease.RenewLock() got error: %v", err)
+			continue
+		}
+
+		log.Logger.WithFields(logrus.Fields{
+			"job": name,
+		}).Warn("Job lease is lost, stop running the job")
+
+		lost()
+		return
+	}
+}
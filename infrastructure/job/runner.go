@@ -0,0 +1,320 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/utils"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// replica which is not the leader of a job try to take over the lease every interval
+	LeaseRetryInterval = 10 * time.Second
+
+	// leader check trigger request every interval while waiting for the next run
+	TriggerPollInterval = 5 * time.Second
+)
+
+// Runner run registered jobs in background. Every replica start the runner, but a job is only run by
+// the replica holding its lease, another replica takes over when the leader dies.
+type Runner struct {
+	lease      Lease
+	store      Store
+	instanceID string
+
+	leaseTTL           time.Duration
+	leaseRetryInterval time.Duration
+	pollInterval       time.Duration
+
+	jobs   []*Job
+	byName map[string]*Job
+	wg     sync.WaitGroup
+}
+
+func NewRunner(lease Lease, store Store, instanceID string) *Runner {
+	return &Runner{
+		lease:              lease,
+		store:              store,
+		instanceID:         instanceID,
+		leaseTTL:           constant.SchedulerLeaseTTL,
+		leaseRetryInterval: LeaseRetryInterval,
+		pollInterval:       TriggerPollInterval,
+		byName:             make(map[string]*Job),
+	}
+}
+
+// Register must be called before Start.
+func (r *Runner) Register(job Job) {
+	if job.RetryInterval <= 0 {
+		job.RetryInterval = job.Interval
+	}
+
+	r.jobs = append(r.jobs, &job)
+	r.byName[job.Name] = &job
+}
+
+// Start run every enabled job until ctx is done, running job is canceled through its ctx.
+func (r *Runner) Start(ctx context.Context) {
+	for _, job := range r.jobs {
+		if !job.Enabled {
+			log.Logger.WithFields(logrus.Fields{
+				"job": job.Name,
+			}).Info("Job is disabled")
+			continue
+		}
+
+		r.wg.Add(1)
+		go func(job *Job) {
+			defer r.wg.Done()
+
+			for {
+				r.runAsLeader(ctx, job.Name, func(leaderCtx context.Context) {
+					r.loop(leaderCtx, job)
+				})
+
+				if !sleepContext(ctx, r.leaseRetryInterval) {
+					return
+				}
+			}
+		}(job)
+	}
+}
+
+// Wait block until every job is stopped, or return ctx error when ctx is done first.
+func (r *Runner) Wait(ctx context.Context) error {
+	return utils.WaitContext(ctx, &r.wg)
+}
+
+// loop run the job while ctx is not done, ctx is canceled when the lease is lost.
+func (r *Runner) loop(ctx context.Context, job *Job) {
+	// status is written even after the lease is lost, so the admin see the result of the last run
+	storeCtx := context.WithoutCancel(ctx)
+
+	// continue counters written by the previous leader
+	status, err := r.store.GetRunStatus(ctx, job.Name)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"job": job.Name,
+		}).Warnf("r.store.GetRunStatus() got error: %v", err)
+	}
+
+	next := time.Now().Add(jitter(job.Jitter))
+	for {
+		status.NextRunTime = &next
+		r.saveRunStatus(storeCtx, job.Name, status)
+
+		triggered, ok := r.wait(ctx, job.Name, next)
+		if !ok {
+			return
+		}
+
+		if !triggered {
+			paused, err := r.store.IsPaused(ctx, job.Name)
+			if err != nil {
+				log.Logger.WithFields(logrus.Fields{
+					"job": job.Name,
+				}).Warnf("r.store.IsPaused() got error: %v", err)
+			}
+
+			// paused job is run as soon as it is resumed
+			if paused {
+				next = time.Now().Add(r.pollInterval)
+				continue
+			}
+		}
+
+		delay := r.run(ctx, job, &status)
+		next = time.Now().Add(delay)
+	}
+}
+
+// wait until the next run time, return true when a run is triggered by admin before that.
+// It return false ok when ctx is done.
+func (r *Runner) wait(ctx context.Context, name string, until time.Time) (triggered bool, ok bool) {
+	for {
+		remaining := time.Until(until)
+		if remaining <= 0 {
+			return false, true
+		}
+
+		if !sleepContext(ctx, min(remaining, r.pollInterval)) {
+			return false, false
+		}
+
+		triggered, err := r.store.TakeTrigger(context.WithoutCancel(ctx), name)
+		if err != nil {
+			log.Logger.WithFields(logrus.Fields{
+				"job": name,
+			}).Warnf("r.store.TakeTrigger() got error: %v", err)
+			continue
+		}
+
+		if triggered {
+			return true, true
+		}
+	}
+}
+
+// run the job once, record the result in status and return the delay before the next run. The job get ctx
+// which is canceled when the lease is lost, status is still written after that.
+func (r *Runner) run(ctx context.Context, job *Job, status *RunStatus) time.Duration {
+	storeCtx := context.WithoutCancel(ctx)
+
+	startTime := time.Now()
+	status.InstanceID = r.instanceID
+	status.Running = true
+	status.LastStartTime = &startTime
+	r.saveRunStatus(storeCtx, job.Name, *status)
+
+	result, err := r.execute(ctx, job)
+
+	endTime := time.Now()
+	status.Running = false
+	status.LastEndTime = &endTime
+	status.LastDurationMs = endTime.Sub(startTime).Milliseconds()
+	status.LastProcessed = result.Processed
+	status.LastFailed = result.Failed
+	status.RunCount++
+
+	if err != nil {
+		status.LastError = err.Error()
+		status.LastErrorTime = &endTime
+		status.ErrorCount++
+
+		log.Logger.WithFields(logrus.Fields{
+			"job":      job.Name,
+			"duration": endTime.Sub(startTime).String(),
+		}).Errorf("Job got error: %v", err)
+
+		return job.RetryInterval + jitter(job.Jitter)
+	}
+
+	status.LastError = ""
+	status.LastSuccessTime = &endTime
+
+	if result.HasMore {
+		return 0
+	}
+
+	return job.Interval + jitter(job.Jitter)
+}
+
+// execute call the job with timeout, panic is returned as error so the loop keep running.
+func (r *Runner) execute(ctx context.Context, job *Job) (result Result, err error) {
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panic: %v", recovered)
+		}
+	}()
+
+	return job.Run(ctx)
+}
+
+func (r *Runner) saveRunStatus(ctx context.Context, name string, status RunStatus) {
+	err := r.store.SaveRunStatus(ctx, name, status)
+	if err != nil {
+		log.Logger.WithFields(logrus.Fields{
+			"job": name,
+		}).Warnf("r.store.SaveRunStatus() got error: %v", err)
+	}
+}
+
+// Statuses return registered jobs in registration order.
+func (r *Runner) Statuses(ctx context.Context) ([]Status, error) {
+	statuses := make([]Status, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		runStatus, err := r.store.GetRunStatus(ctx, job.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		paused, err := r.store.IsPaused(ctx, job.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, Status{
+			Name:      job.Name,
+			Enabled:   job.Enabled,
+			Paused:    paused,
+			Interval:  job.Interval.String(),
+			Jitter:    job.Jitter.String(),
+			Timeout:   job.Timeout.String(),
+			RunStatus: runStatus,
+		})
+	}
+
+	return statuses, nil
+}
+
+// Trigger request the leader to run the job immediately, even when it is paused.
+func (r *Runner) Trigger(ctx context.Context, name string) error {
+	job, ok := r.byName[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	if !job.Enabled {
+		return ErrJobDisabled
+	}
+
+	return r.store.RequestTrigger(ctx, name)
+}
+
+// Pause stop the job from running on every replica until it is resumed, running job is not stopped.
+func (r *Runner) Pause(ctx context.Context, name string) error {
+	if _, ok := r.byName[name]; !ok {
+		return ErrJobNotFound
+	}
+
+	return r.store.SetPaused(ctx, name, true)
+}
+
+func (r *Runner) Resume(ctx context.Context, name string) error {
+	if _, ok := r.byName[name]; !ok {
+		return ErrJobNotFound
+	}
+
+	return r.store.SetPaused(ctx, name, false)
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// sleepContext return false when ctx is done before d elapsed.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,382 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"payment/infrastructure/log"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeLease struct {
+	mu         sync.Mutex
+	holder     map[string]string
+	acquireErr error
+	renewErr   error
+	// lease is taken by other replica on the next renew
+	stolen   bool
+	released []string
+}
+
+func (l *fakeLease) AcquireLock(ctx context.Context, key string, ttl time.Duration) (string, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.acquireErr != nil {
+		return "", false, l.acquireErr
+	}
+
+	if l.holder == nil {
+		l.holder = map[string]string{}
+	}
+
+	if _, ok := l.holder[key]; ok {
+		return "", false, nil
+	}
+
+	l.holder[key] = "token"
+	return "token", true, nil
+}
+
+func (l *fakeLease) RenewLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.renewErr != nil {
+		return false, l.renewErr
+	}
+
+	return !l.stolen, nil
+}
+
+func (l *fakeLease) ReleaseLock(ctx context.Context, key string, token string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.holder[key] == token {
+		delete(l.holder, key)
+	}
+	l.released = append(l.released, key)
+
+	return nil
+}
+
+type memoryStore struct {
+	mu       sync.Mutex
+	statuses map[string]RunStatus
+	paused   map[string]bool
+	triggers map[string]bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		statuses: map[string]RunStatus{},
+		paused:   map[string]bool{},
+		triggers: map[string]bool{},
+	}
+}
+
+func (s *memoryStore) SaveRunStatus(ctx context.Context, name string, status RunStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.statuses[name] = status
+	return nil
+}
+
+func (s *memoryStore) GetRunStatus(ctx context.Context, name string) (RunStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.statuses[name], nil
+}
+
+func (s *memoryStore) SetPaused(ctx context.Context, name string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paused[name] = paused
+	return nil
+}
+
+func (s *memoryStore) IsPaused(ctx context.Context, name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.paused[name], nil
+}
+
+func (s *memoryStore) RequestTrigger(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.triggers[name] = true
+	return nil
+}
+
+func (s *memoryStore) TakeTrigger(ctx context.Context, name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	triggered := s.triggers[name]
+	delete(s.triggers, name)
+	return triggered, nil
+}
+
+func newTestRunner(lease *fakeLease, store *memoryStore) *Runner {
+	runner := NewRunner(lease, store, "instance-1")
+	runner.leaseTTL = 30 * time.Millisecond
+	runner.leaseRetryInterval = 10 * time.Millisecond
+	runner.pollInterval = 5 * time.Millisecond
+
+	return runner
+}
+
+func Test_RunAsLeader(t *testing.T) {
+	log.SetupLogger()
+
+	tests := []struct {
+		name         string
+		lease        *fakeLease
+		fn           func(ctx context.Context) bool
+		wantRun      bool
+		wantReleased bool
+	}{
+		{
+			name:  "given_lease_held_by_other_replica_then_it_should_not_run",
+			lease: &fakeLease{holder: map[string]string{"payment:lease:scheduler:test_job": "other"}},
+		},
+		{
+			name:  "given_error_AcquireLock_then_it_should_not_run",
+			lease: &fakeLease{acquireErr: assert.AnError},
+		},
+		{
+			name:  "given_lease_acquired_then_it_should_run_and_release",
+			lease: &fakeLease{},
+			fn: func(ctx context.Context) bool {
+				return ctx.Err() == nil
+			},
+			wantRun:      true,
+			wantReleased: true,
+		},
+		{
+			name:  "given_lease_taken_by_other_replica_then_it_should_cancel_ctx",
+			lease: &fakeLease{stolen: true},
+			fn: func(ctx context.Context) bool {
+				<-ctx.Done()
+				return true
+			},
+			wantRun:      true,
+			wantReleased: true,
+		},
+		{
+			name:  "given_error_RenewLock_then_it_should_cancel_ctx_before_lease_expire",
+			lease: &fakeLease{renewErr: assert.AnError},
+			fn: func(ctx context.Context) bool {
+				acquireTime := time.Now()
+				<-ctx.Done()
+				return time.Since(acquireTime) < 300*time.Millisecond
+			},
+			wantRun:      true,
+			wantReleased: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runner := newTestRunner(test.lease, newMemoryStore())
+			runner.leaseTTL = 300 * time.Millisecond
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			run := false
+			runner.runAsLeader(ctx, "test_job", func(ctx context.Context) {
+				run = test.fn(ctx)
+			})
+			assert.Equal(t, test.wantRun, run)
+			assert.Equal(t, test.wantReleased, len(test.lease.released) == 1)
+			assert.NoError(t, ctx.Err(), "lease must be released before parent ctx is done")
+		})
+	}
+}
+
+func Test_Runner_Run(t *testing.T) {
+	log.SetupLogger()
+
+	tests := []struct {
+		name       string
+		run        Func
+		wantDelay  time.Duration
+		wantStatus RunStatus
+	}{
+		{
+			name: "given_success_then_it_should_record_result_and_wait_interval",
+			run: func(ctx context.Context) (Result, error) {
+				return Result{Processed: 3, Failed: 1}, nil
+			},
+			wantDelay:  time.Minute,
+			wantStatus: RunStatus{InstanceID: "instance-1", LastProcessed: 3, LastFailed: 1, RunCount: 1},
+		},
+		{
+			name: "given_has_more_then_it_should_run_again_immediately",
+			run: func(ctx context.Context) (Result, error) {
+				return Result{Processed: 100, HasMore: true}, nil
+			},
+			wantDelay:  0,
+			wantStatus: RunStatus{InstanceID: "instance-1", LastProcessed: 100, RunCount: 1},
+		},
+		{
+			name: "given_error_then_it_should_record_error_and_wait_retry_interval",
+			run: func(ctx context.Context) (Result, error) {
+				return Result{}, assert.AnError
+			},
+			wantDelay:  10 * time.Second,
+			wantStatus: RunStatus{InstanceID: "instance-1", LastError: assert.AnError.Error(), RunCount: 1, ErrorCount: 1},
+		},
+		{
+			name: "given_panic_then_it_should_record_error",
+			run: func(ctx context.Context) (Result, error) {
+				panic("boom")
+			},
+			wantDelay:  10 * time.Second,
+			wantStatus: RunStatus{InstanceID: "instance-1", LastError: "job panic: boom", RunCount: 1, ErrorCount: 1},
+		},
+		{
+			name: "given_timeout_then_it_should_cancel_ctx",
+			run: func(ctx context.Context) (Result, error) {
+				<-ctx.Done()
+				return Result{}, ctx.Err()
+			},
+			wantDelay:  10 * time.Second,
+			wantStatus: RunStatus{InstanceID: "instance-1", LastError: context.DeadlineExceeded.Error(), RunCount: 1, ErrorCount: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newMemoryStore()
+			runner := newTestRunner(&fakeLease{}, store)
+
+			job := &Job{
+				Name:          "test_job",
+				Enabled:       true,
+				Interval:      time.Minute,
+				RetryInterval: 10 * time.Second,
+				Timeout:       10 * time.Millisecond,
+				Run:           test.run,
+			}
+
+			var status RunStatus
+			delay := runner.run(context.Background(), job, &status)
+			assert.Equal(t, test.wantDelay, delay)
+
+			assert.False(t, status.Running)
+			assert.NotNil(t, status.LastStartTime)
+			assert.NotNil(t, status.LastEndTime)
+			assert.Equal(t, test.wantStatus.InstanceID, status.InstanceID)
+			assert.Equal(t, test.wantStatus.LastProcessed, status.LastProcessed)
+			assert.Equal(t, test.wantStatus.LastFailed, status.LastFailed)
+			assert.Equal(t, test.wantStatus.LastError, status.LastError)
+			assert.Equal(t, test.wantStatus.RunCount, status.RunCount)
+			assert.Equal(t, test.wantStatus.ErrorCount, status.ErrorCount)
+			assert.Equal(t, test.wantStatus.LastError == "", status.LastSuccessTime != nil)
+		})
+	}
+}
+
+func Test_Runner_TriggerAndPause(t *testing.T) {
+	log.SetupLogger()
+
+	store := newMemoryStore()
+	runner := newTestRunner(&fakeLease{}, store)
+
+	var runs atomic.Int64
+	runner.Register(Job{
+		Name:     "test_job",
+		Enabled:  true,
+		Interval: time.Hour,
+		Run: func(ctx context.Context) (Result, error) {
+			runs.Add(1)
+			return Result{Processed: 1}, nil
+		},
+	})
+	runner.Register(Job{
+		Name:     "disabled_job",
+		Enabled:  false,
+		Interval: time.Hour,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// paused job is not run on start
+	assert.NoError(t, runner.Pause(ctx, "test_job"))
+	runner.Start(ctx)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int64(0), runs.Load())
+
+	// job is run once it is resumed, then wait for the interval
+	assert.NoError(t, runner.Resume(ctx, "test_job"))
+	assert.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	// trigger run the job without waiting for the interval
+	assert.NoError(t, runner.Trigger(ctx, "test_job"))
+	assert.Eventually(t, func() bool { return runs.Load() == 2 }, time.Second, 5*time.Millisecond)
+
+	assert.True(t, errors.Is(runner.Trigger(ctx, "unknown_job"), ErrJobNotFound))
+	assert.True(t, errors.Is(runner.Trigger(ctx, "disabled_job"), ErrJobDisabled))
+	assert.True(t, errors.Is(runner.Pause(ctx, "unknown_job"), ErrJobNotFound))
+
+	// status is saved after the job return
+	assert.Eventually(t, func() bool {
+		status, _ := store.GetRunStatus(ctx, "test_job")
+		return status.RunCount == 2 && !status.Running
+	}, time.Second, 5*time.Millisecond)
+
+	statuses, err := runner.Statuses(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, "test_job", statuses[0].Name)
+	assert.Equal(t, 1, statuses[0].LastProcessed)
+	assert.False(t, statuses[1].Enabled)
+
+	cancel()
+	assert.NoError(t, runner.Wait(context.Background()))
+}
+
+func Test_Runner_LeaseLost(t *testing.T) {
+	log.SetupLogger()
+
+	store := newMemoryStore()
+	runner := newTestRunner(&fakeLease{stolen: true}, store)
+
+	// job is stopped between items once the lease is lost
+	runner.Register(Job{
+		Name:     "test_job",
+		Enabled:  true,
+		Interval: time.Hour,
+		Run: func(ctx context.Context) (Result, error) {
+			<-ctx.Done()
+			return Result{Processed: 1}, ctx.Err()
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runner.Start(ctx)
+
+	// status of the canceled run is still saved
+	assert.Eventually(t, func() bool {
+		status, _ := store.GetRunStatus(ctx, "test_job")
+		return status.RunCount >= 1 && !status.Running && status.LastError == context.Canceled.Error()
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, runner.Wait(context.Background()))
+}
@@ -0,0 +1,78 @@
+package kafka
+
+import (
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// commitTracker keep offsets of in-flight messages per partition in fetch order.
+// Messages are handled concurrently, so offset is only committed when every earlier
+// message of the same partition is done, otherwise unhandled message is skipped after restart.
+type commitTracker struct {
+	mu         sync.Mutex
+	partitions map[int][]*trackedMessage
+}
+
+type trackedMessage struct {
+	message kafka.Message
+	done    bool
+}
+
+func newCommitTracker() *commitTracker {
+	return &commitTracker{
+		partitions: make(map[int][]*trackedMessage),
+	}
+}
+
+// track must be called in fetch order, before the message is handled.
+func (t *commitTracker) track(message kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.partitions[message.Partition] = append(t.partitions[message.Partition], &trackedMessage{message: message})
+}
+
+// done mark the message as handled, then commit the last message of the done prefix of its partition.
+// commit is called while holding the lock so committed offset never goes backward.
+func (t *commitTracker) done(message kafka.Message, commit func(kafka.Message)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pending := t.partitions[message.Partition]
+	for _, tracked := range pending {
+		if tracked.message.Offset == message.Offset {
+			tracked.done = true
+			break
+		}
+	}
+
+	var (
+		last      kafka.Message
+		completed int
+	)
+	for completed < len(pending) && pending[completed].done {
+		last = pending[completed].message
+		completed++
+	}
+
+	if completed == 0 {
+		return
+	}
+
+	t.partitions[message.Partition] = pending[completed:]
+	commit(last)
+}
+
+// inFlight return number of messages which are fetched but not committed yet.
+func (t *commitTracker) inFlight() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	total := 0
+	for _, pending := range t.partitions {
+		total += len(pending)
+	}
+
+	return total
+}
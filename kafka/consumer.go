@@ -3,38 +3,340 @@ package kafka
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"payment/config"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
 	"payment/models"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
 )
 
-func StartOrderConsumer(broker string, topic string, handler func(models.OrderCreatedEvent)) {
-	consumer := kafka.NewReader(kafka.ReaderConfig{
-		Brokers: []string{broker},
-		Topic:   topic,
-		GroupID: "payment",
+const (
+	ConsumerGroupID = "payment"
+
+	DefaultConsumerWorkers   = 4
+	DefaultConsumerQueueSize = 16
+
+	// offset is committed periodically, so commit is cheap for every handled message
+	ConsumerCommitInterval = time.Second
+
+	// attempts of handler before the message is forwarded to dlq
+	MaxHandleAttempts = 5
+	RetryBaseDelay    = time.Second
+	RetryMaxDelay     = 30 * time.Second
+)
+
+// ErrPermanent mark handler error which must not be retried, e.g. invalid event.
+var ErrPermanent = errors.New("permanent error")
+
+// Permanent wrap err so the message is forwarded to dlq without retry.
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %w", ErrPermanent, err)
+}
+
+type OrderCreatedHandler func(ctx context.Context, event models.OrderCreatedEvent) error
+
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+type orderConsumer struct {
+	reader    messageReader
+	dlq       messageWriter
+	dlqTopic  string
+	handler   OrderCreatedHandler
+	sleep     func(ctx context.Context, d time.Duration) error
+	workers   int
+	queueSize int
+	tracker   *commitTracker
+}
+
+// StartOrderConsumer consume order.created with a pool of workers. Messages are fanned out by key,
+// so events of the same order are handled in order. Offset is only committed after the message and
+// every earlier message of the partition are handled or forwarded to dlq, so failed message is
+// consumed again after restart. dlqWriter must be created without topic.
+//
+// Fetching is stopped when ctx is done, in-flight messages are finished and committed offsets are
+// flushed before the reader is closed. The returned channel is closed once the consumer is stopped.
+func StartOrderConsumer(ctx context.Context, kafkaConfig config.KafkaConfig, dlqWriter *kafka.Writer, handler OrderCreatedHandler) <-chan struct{} {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        []string{kafkaConfig.Broker},
+		Topic:          kafkaConfig.Topic(constant.KafkaTopicOrderCreated),
+		GroupID:        ConsumerGroupID,
+		CommitInterval: ConsumerCommitInterval,
 	})
 
-	// listen with go r
-	go func(r *kafka.Reader) {
-		for {
-			message, err := r.ReadMessage(context.Background())
-			if err != nil {
-				log.Println("Error while read Kafka Message: ", err.Error())
-				// store data to database
-				continue
-			}
+	consumer := newOrderConsumer(reader, dlqWriter, kafkaConfig.Topic(constant.KafkaTopicOrderCreatedDLQ), handler, kafkaConfig.Consumer)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		consumer.run(ctx)
+
+		// flush offsets committed by workers
+		if err := reader.Close(); err != nil {
+			log.Logger.Errorf("Failed close kafka reader: %v", err)
+		}
+	}()
 
-			var event models.OrderCreatedEvent
-			err = json.Unmarshal(message.Value, &event)
-			if err != nil {
-				log.Println("Error while unmarshal kafka message: ", err.Error())
-				continue
+	return stopped
+}
+
+func newOrderConsumer(reader messageReader, dlq messageWriter, dlqTopic string, handler OrderCreatedHandler, consumerConfig config.KafkaConsumerConfig) *orderConsumer {
+	workers := consumerConfig.Workers
+	if workers <= 0 {
+		workers = DefaultConsumerWorkers
+	}
+
+	queueSize := consumerConfig.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultConsumerQueueSize
+	}
+
+	return &orderConsumer{
+		reader:    reader,
+		dlq:       dlq,
+		dlqTopic:  dlqTopic,
+		handler:   handler,
+		sleep:     sleepContext,
+		workers:   workers,
+		queueSize: queueSize,
+		tracker:   newCommitTracker(),
+	}
+}
+
+// run fetch messages and dispatch them to workers until ctx is done, then wait for workers to finish.
+// In-flight messages are bounded by workers * (queue size + 1).
+func (c *orderConsumer) run(ctx context.Context) {
+	var wg sync.WaitGroup
+	queues := make([]chan kafka.Message, c.workers)
+	for i := range queues {
+		queues[i] = make(chan kafka.Message, c.queueSize)
+
+		wg.Add(1)
+		go func(queue <-chan kafka.Message) {
+			defer wg.Done()
+			c.work(ctx, queue)
+		}(queues[i])
+	}
+
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+	}()
+
+	for {
+		message, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
 			}
 
-			log.Printf("Received Event order_created: %+v", event)
-			handler(event)
+			log.Logger.Errorf("Error while fetch Kafka Message: %v", err)
+			_ = c.sleep(ctx, RetryBaseDelay)
+			continue
 		}
-	}(consumer)
+
+		if !c.dispatch(ctx, queues, message) {
+			return
+		}
+	}
+}
+
+// dispatch block when the worker queue is full, so fetching is paused until the worker catch up.
+func (c *orderConsumer) dispatch(ctx context.Context, queues []chan kafka.Message, message kafka.Message) bool {
+	c.tracker.track(message)
+	queue := queues[workerIndex(message, len(queues))]
+
+	select {
+	case queue <- message:
+		return true
+	default:
+	}
+
+	log.Logger.WithFields(logrus.Fields{
+		"partition": message.Partition,
+		"offset":    message.Offset,
+		"in_flight": c.tracker.inFlight(),
+	}).Warn("Order consumer worker is saturated, pause fetching")
+
+	select {
+	case queue <- message:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (c *orderConsumer) work(ctx context.Context, queue <-chan kafka.Message) {
+	// commit is not canceled on shutdown, so offset of message finished while stopping is not lost
+	commitCtx := context.WithoutCancel(ctx)
+
+	for message := range queue {
+		if ctx.Err() != nil {
+			// not started yet, message is consumed again after restart
+			continue
+		}
+
+		if err := c.handleMessage(ctx, message); err != nil {
+			continue
+		}
+
+		c.tracker.done(message, func(last kafka.Message) {
+			c.commit(commitCtx, last)
+		})
+	}
+}
+
+// workerIndex keep messages with the same key in the same worker, message without key is kept by partition.
+func workerIndex(message kafka.Message, workers int) int {
+	if len(message.Key) == 0 {
+		return message.Partition % workers
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write(message.Key)
+
+	return int(hash.Sum32() % uint32(workers))
+}
+
+// handleMessage return error only when ctx is done before the message is handled or forwarded to dlq.
+func (c *orderConsumer) handleMessage(ctx context.Context, message kafka.Message) error {
+	fields := logrus.Fields{
+		"topic":     message.Topic,
+		"partition": message.Partition,
+		"offset":    message.Offset,
+	}
+
+	var event models.OrderCreatedEvent
+	err := json.Unmarshal(message.Value, &event)
+	if err != nil {
+		log.Logger.WithFields(fields).Errorf("Error while unmarshal kafka message: %v", err)
+
+		return c.forwardToDLQ(ctx, message, constant.DLQErrorTypeDecode, err, 0)
+	}
+
+	log.Logger.WithFields(fields).Infof("Received Event order_created: %+v", event)
+
+	attempts := 0
+	for {
+		attempts++
+		// running handler is not canceled on shutdown, only the retry is stopped
+		err = c.handler(context.WithoutCancel(ctx), event)
+		if err == nil {
+			return nil
+		}
+
+		fields["attempts"] = attempts
+		fields["order_id"] = event.OrderID
+		log.Logger.WithFields(fields).Errorf("Failed handling order_created event: %v", err)
+
+		if errors.Is(err, ErrPermanent) {
+			return c.forwardToDLQ(ctx, message, constant.DLQErrorTypePermanent, err, attempts)
+		}
+
+		if attempts >= MaxHandleAttempts {
+			return c.forwardToDLQ(ctx, message, constant.DLQErrorTypeMaxAttempts, err, attempts)
+		}
+
+		if errSleep := c.sleep(ctx, retryDelay(attempts)); errSleep != nil {
+			return errSleep
+		}
+	}
+}
+
+// forwardToDLQ keep the original key and value, error is stored in the headers.
+// It is retried until succeed, offset must not be committed when message is not in dlq.
+func (c *orderConsumer) forwardToDLQ(ctx context.Context, message kafka.Message, errorType string, cause error, attempts int) error {
+	dlqMessage := kafka.Message{
+		Topic: c.dlqTopic,
+		Key:   message.Key,
+		Value: message.Value,
+		Headers: append(append([]kafka.Header{}, message.Headers...),
+			kafka.Header{Key: constant.DLQHeaderErrorType, Value: []byte(errorType)},
+			kafka.Header{Key: constant.DLQHeaderErrorMessage, Value: []byte(cause.Error())},
+			kafka.Header{Key: constant.DLQHeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+			kafka.Header{Key: constant.DLQHeaderOriginalTopic, Value: []byte(message.Topic)},
+			kafka.Header{Key: constant.DLQHeaderOriginalPartition, Value: []byte(strconv.Itoa(message.Partition))},
+			kafka.Header{Key: constant.DLQHeaderOriginalOffset, Value: []byte(strconv.FormatInt(message.Offset, 10))},
+			kafka.Header{Key: constant.DLQHeaderFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+		),
+	}
+
+	for retry := 1; ; retry++ {
+		err := c.dlq.WriteMessages(context.WithoutCancel(ctx), dlqMessage)
+		if err == nil {
+			break
+		}
+
+		log.Logger.WithFields(logrus.Fields{
+			"topic":     message.Topic,
+			"partition": message.Partition,
+			"offset":    message.Offset,
+			"dlq_topic": c.dlqTopic,
+		}).Errorf("Failed forward message to dlq: %v", err)
+
+		if errSleep := c.sleep(ctx, retryDelay(retry)); errSleep != nil {
+			return errSleep
+		}
+	}
+
+	log.Logger.WithFields(logrus.Fields{
+		"topic":      message.Topic,
+		"partition":  message.Partition,
+		"offset":     message.Offset,
+		"dlq_topic":  c.dlqTopic,
+		"error_type": errorType,
+	}).Warn("Message is forwarded to dlq")
+
+	return nil
+}
+
+func (c *orderConsumer) commit(ctx context.Context, message kafka.Message) {
+	err := c.reader.CommitMessages(ctx, message)
+	if err != nil {
+		// message is consumed again, handler must be idempotent
+		log.Logger.WithFields(logrus.Fields{
+			"topic":     message.Topic,
+			"partition": message.Partition,
+			"offset":    message.Offset,
+		}).Errorf("Failed commit kafka message: %v", err)
+	}
+}
+
+// retryDelay is exponential backoff, 1s 2s 4s ... capped by RetryMaxDelay.
+func retryDelay(attempts int) time.Duration {
+	delay := RetryBaseDelay << (attempts - 1)
+	if delay <= 0 || delay > RetryMaxDelay {
+		return RetryMaxDelay
+	}
+
+	return delay
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
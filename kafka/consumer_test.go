@@ -0,0 +1,341 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"payment/config"
+	"payment/infrastructure/constant"
+	"payment/infrastructure/log"
+	"payment/models"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeReader struct {
+	mu        sync.Mutex
+	messages  []kafka.Message
+	committed []kafka.Message
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	if len(r.messages) > 0 {
+		message := r.messages[0]
+		r.messages = r.messages[1:]
+		r.mu.Unlock()
+		return message, nil
+	}
+	r.mu.Unlock()
+
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func (r *fakeReader) lastCommitted() (kafka.Message, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.committed) == 0 {
+		return kafka.Message{}, false
+	}
+
+	return r.committed[len(r.committed)-1], true
+}
+
+type fakeWriter struct {
+	errs    []error
+	written []kafka.Message
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if len(w.errs) > 0 {
+		err := w.errs[0]
+		w.errs = w.errs[1:]
+		return err
+	}
+
+	w.written = append(w.written, msgs...)
+	return nil
+}
+
+func Test_HandleMessage(t *testing.T) {
+	log.SetupLogger()
+
+	message := kafka.Message{
+		Topic:     "order.created",
+		Partition: 1,
+		Offset:    10,
+		Key:       []byte("order-123"),
+		Value:     []byte(`{"order_id":123,"user_id":45,"amount":1000}`),
+	}
+
+	tests := []struct {
+		name         string
+		message      kafka.Message
+		handlerErrs  []error
+		dlqErrs      []error
+		wantAttempts int
+		wantDLQType  string
+		wantSleeps   []time.Duration
+	}{
+		{
+			name:         "given_handler_success_then_it_should_return_nil",
+			message:      message,
+			handlerErrs:  []error{nil},
+			wantAttempts: 1,
+		},
+		{
+			name:         "given_transient_error_then_it_should_retry_with_backoff",
+			message:      message,
+			handlerErrs:  []error{assert.AnError, assert.AnError, nil},
+			wantAttempts: 3,
+			wantSleeps:   []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:         "given_error_until_max_attempts_then_it_should_forward_to_dlq",
+			message:      message,
+			handlerErrs:  []error{assert.AnError, assert.AnError, assert.AnError, assert.AnError, assert.AnError},
+			wantAttempts: MaxHandleAttempts,
+			wantDLQType:  constant.DLQErrorTypeMaxAttempts,
+			wantSleeps:   []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			name:         "given_permanent_error_then_it_should_forward_to_dlq_without_retry",
+			message:      message,
+			handlerErrs:  []error{Permanent(assert.AnError)},
+			wantAttempts: 1,
+			wantDLQType:  constant.DLQErrorTypePermanent,
+		},
+		{
+			name: "given_undecodable_message_then_it_should_forward_to_dlq",
+			message: kafka.Message{
+				Topic: "order.created",
+				Value: []byte(`not json`),
+			},
+			wantAttempts: 0,
+			wantDLQType:  constant.DLQErrorTypeDecode,
+		},
+		{
+			name:         "given_error_write_dlq_then_it_should_retry_until_written",
+			message:      message,
+			handlerErrs:  []error{Permanent(assert.AnError)},
+			dlqErrs:      []error{assert.AnError},
+			wantAttempts: 1,
+			wantDLQType:  constant.DLQErrorTypePermanent,
+			wantSleeps:   []time.Duration{time.Second},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := &fakeReader{}
+			writer := &fakeWriter{errs: test.dlqErrs}
+
+			attempts := 0
+			var sleeps []time.Duration
+			consumer := &orderConsumer{
+				reader:   reader,
+				dlq:      writer,
+				dlqTopic: "order.created.dlq",
+				handler: func(ctx context.Context, event models.OrderCreatedEvent) error {
+					err := test.handlerErrs[attempts]
+					attempts++
+					return err
+				},
+				sleep: func(ctx context.Context, d time.Duration) error {
+					sleeps = append(sleeps, d)
+					return nil
+				},
+			}
+
+			err := consumer.handleMessage(context.Background(), test.message)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantAttempts, attempts)
+			assert.Equal(t, test.wantSleeps, sleeps)
+			assert.Empty(t, reader.committed)
+
+			if test.wantDLQType == "" {
+				assert.Empty(t, writer.written)
+				return
+			}
+
+			assert.Len(t, writer.written, 1)
+			dlqMessage := writer.written[0]
+			assert.Equal(t, "order.created.dlq", dlqMessage.Topic)
+			assert.Equal(t, test.message.Value, dlqMessage.Value)
+
+			headers := map[string]string{}
+			for _, header := range dlqMessage.Headers {
+				headers[header.Key] = string(header.Value)
+			}
+			assert.Equal(t, test.wantDLQType, headers[constant.DLQHeaderErrorType])
+			assert.NotEmpty(t, headers[constant.DLQHeaderErrorMessage])
+			assert.Equal(t, test.message.Topic, headers[constant.DLQHeaderOriginalTopic])
+		})
+	}
+}
+
+func Test_HandleMessage_ContextCanceled(t *testing.T) {
+	log.SetupLogger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader := &fakeReader{}
+	consumer := &orderConsumer{
+		reader:   reader,
+		dlq:      &fakeWriter{},
+		dlqTopic: "order.created.dlq",
+		handler: func(ctx context.Context, event models.OrderCreatedEvent) error {
+			cancel()
+			return assert.AnError
+		},
+		sleep: sleepContext,
+	}
+
+	// message must not be marked done, so it is consumed again after restart
+	err := consumer.handleMessage(ctx, kafka.Message{Value: []byte(`{"order_id":1}`)})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, reader.committed)
+}
+
+func Test_Run_KeyOrdered(t *testing.T) {
+	log.SetupLogger()
+
+	const (
+		orders         = 10
+		eventsPerOrder = 5
+	)
+
+	var messages []kafka.Message
+	for i := 0; i < eventsPerOrder; i++ {
+		for orderID := 1; orderID <= orders; orderID++ {
+			messages = append(messages, kafka.Message{
+				Partition: 0,
+				Offset:    int64(len(messages)),
+				Key:       []byte(fmt.Sprintf("order-%d", orderID)),
+				Value:     []byte(fmt.Sprintf(`{"order_id":%d,"amount":%d}`, orderID, i)),
+			})
+		}
+	}
+	lastOffset := messages[len(messages)-1].Offset
+
+	var (
+		mu      sync.Mutex
+		handled = map[int64][]string{}
+	)
+	reader := &fakeReader{messages: messages}
+	consumer := newOrderConsumer(reader, &fakeWriter{}, "order.created.dlq", func(ctx context.Context, event models.OrderCreatedEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		handled[event.OrderID] = append(handled[event.OrderID], event.TotalAmount.String())
+		return nil
+	}, config.KafkaConsumerConfig{Workers: 3, QueueSize: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	go func() {
+		consumer.run(ctx)
+		close(finished)
+	}()
+
+	assert.Eventually(t, func() bool {
+		last, ok := reader.lastCommitted()
+		return ok && last.Offset == lastOffset
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-finished
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, handled, orders)
+	for orderID, amounts := range handled {
+		assert.Equal(t, []string{"0", "1", "2", "3", "4"}, amounts, "order %d is handled out of order", orderID)
+	}
+	assert.Zero(t, consumer.tracker.inFlight())
+}
+
+func Test_CommitTracker_Done(t *testing.T) {
+	tracker := newCommitTracker()
+	for offset := int64(0); offset < 3; offset++ {
+		tracker.track(kafka.Message{Partition: 0, Offset: offset})
+	}
+	tracker.track(kafka.Message{Partition: 1, Offset: 0})
+
+	var committed []kafka.Message
+	commit := func(message kafka.Message) {
+		committed = append(committed, message)
+	}
+
+	// offset 0 is not done yet, so committing offset 2 would skip it after restart
+	tracker.done(kafka.Message{Partition: 0, Offset: 2}, commit)
+	assert.Empty(t, committed)
+
+	tracker.done(kafka.Message{Partition: 1, Offset: 0}, commit)
+	tracker.done(kafka.Message{Partition: 0, Offset: 0}, commit)
+	tracker.done(kafka.Message{Partition: 0, Offset: 1}, commit)
+
+	assert.Equal(t, []kafka.Message{
+		{Partition: 1, Offset: 0},
+		{Partition: 0, Offset: 0},
+		{Partition: 0, Offset: 2},
+	}, committed)
+	assert.Zero(t, tracker.inFlight())
+}
+
+func Test_WorkerIndex(t *testing.T) {
+	keyed := kafka.Message{Partition: 2, Key: []byte("order-123")}
+	assert.Equal(t, workerIndex(keyed, 8), workerIndex(kafka.Message{Partition: 5, Key: []byte("order-123")}, 8))
+	assert.Equal(t, 3, workerIndex(kafka.Message{Partition: 11}, 8))
+}
+
+func Test_Run_Shutdown(t *testing.T) {
+	log.SetupLogger()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	reader := &fakeReader{messages: []kafka.Message{
+		{Partition: 0, Offset: 0, Key: []byte("order-1"), Value: []byte(`{"order_id":1}`)},
+	}}
+	consumer := newOrderConsumer(reader, &fakeWriter{}, "order.created.dlq", func(ctx context.Context, event models.OrderCreatedEvent) error {
+		close(started)
+		<-release
+		return ctx.Err()
+	}, config.KafkaConsumerConfig{Workers: 1, QueueSize: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	go func() {
+		consumer.run(ctx)
+		close(finished)
+	}()
+
+	<-started
+	cancel()
+
+	// in-flight message is finished and committed before run return
+	select {
+	case <-finished:
+		t.Fatal("run returned before in-flight message is finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-finished
+
+	last, ok := reader.lastCommitted()
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), last.Offset)
+}
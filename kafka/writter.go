@@ -6,8 +6,9 @@ import (
 
 func NewWriter(broker string, topic string) *kafka.Writer {
 	return &kafka.Writer{
-		Addr:     kafka.TCP(broker),
-		Topic:    topic,
-		Balancer: &kafka.LeastBytes{},
+		Addr:  kafka.TCP(broker),
+		Topic: topic,
+		// partition is chosen by message key, so events with the same key are read in publish order
+		Balancer: &kafka.Hash{},
 	}
 }
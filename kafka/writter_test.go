@@ -0,0 +1,20 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewWriter_PartitionByKey(t *testing.T) {
+	writer := NewWriter("localhost:9092", "")
+	partitions := []int{0, 1, 2, 3, 4, 5}
+
+	// every event of the same order must land in the same partition
+	want := writer.Balancer.Balance(kafka.Message{Key: []byte("order-123"), Value: []byte("payment.paid")}, partitions...)
+	for i := 0; i < 10; i++ {
+		got := writer.Balancer.Balance(kafka.Message{Key: []byte("order-123"), Value: make([]byte, i*100)}, partitions...)
+		assert.Equal(t, want, got)
+	}
+}
@@ -2,6 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
 	"payment/cmd/payment/handler"
 	"payment/cmd/payment/repository"
 	"payment/cmd/payment/resource"
@@ -10,74 +15,241 @@ import (
 	"payment/config"
 	"payment/grpc"
 	"payment/infrastructure/constant"
+	"payment/infrastructure/job"
 	"payment/infrastructure/log"
 	"payment/kafka"
 	"payment/models"
 	"payment/routes"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+const DefaultShutdownTimeout = 30 * time.Second
+
 func main() {
+	// canceled on SIGINT / SIGTERM, every background loop is stopped by this context
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// init config
 	cfg := config.LoadConfig()
 	// init connection
 	db := resource.InitDb(&cfg)
-	kafkaWriter := kafka.NewWriter(cfg.Kafka.Broker, cfg.Kafka.Topics[constant.KafkaTopicPaymentSuccess])
+	redisClient := resource.InitRedis(&cfg)
+	// topic is set per message by publisher
+	kafkaWriter := kafka.NewWriter(cfg.Kafka.Broker, "")
 
 	// setup logger
 	log.SetupLogger()
 
-	// grpc user client
+	// grpc user client, calls fail fast while the user service is unhealthy
 	grpcUserClient := grpc.NewUserClient()
+	userServiceBreaker := resource.InitCircuitBreaker(&cfg, constant.CircuitBreakerUserService, grpc.IsUserServiceFailure)
+	userClient := grpc.NewBreakerUserClient(grpcUserClient, userServiceBreaker)
 
 	// payment service
 	databaseRepository := repository.NewPaymentDatabase(db)
-	publisherRepository := repository.NewKafkaPublisher(kafkaWriter)
-	paymentService := service.NewPaymentService(databaseRepository, publisherRepository)
+	publisherRepository := repository.NewKafkaPublisher(kafkaWriter, cfg.Kafka)
+	redisRepository := repository.NewPaymentRedis(redisClient)
+	paymentService := service.NewPaymentService(databaseRepository, redisRepository)
 	paymentUsecase := usecase.NewPaymentUsecase(paymentService)
-	paymentHandler := handler.NewPaymentHandler(paymentUsecase, cfg.Xendit.WebhookToken)
 
-	// xendit service
-	xenditRepository := repository.NewXenditClient(cfg.Xendit.SecretApiKey)
-	xenditService := service.NewXenditService(databaseRepository, xenditRepository, grpcUserClient)
-	xenditUsacase := usecase.NewXenditUsecase(xenditService)
+	// payment gateways
+	xenditBreaker := resource.InitCircuitBreaker(&cfg, constant.CircuitBreakerXendit, repository.IsXenditFailure)
+	xenditRepository := repository.NewBreakerXenditClient(repository.NewXenditClient(cfg.Xendit), xenditBreaker)
+	midtransBreaker := resource.InitCircuitBreaker(&cfg, constant.CircuitBreakerMidtrans, repository.IsMidtransFailure)
+	paymentGateways := resource.InitPaymentGateways(&cfg, xenditRepository, midtransBreaker)
+
+	// webhook inbox
+	webhookInboxService := service.NewWebhookInboxService(databaseRepository, redisRepository)
+	webhookInboxUsecase := usecase.NewWebhookInboxUsecase(webhookInboxService, paymentUsecase, paymentGateways)
+	paymentHandler := handler.NewPaymentHandler(paymentUsecase, webhookInboxUsecase)
+
+	// invoice service
+	invoiceService := service.NewInvoiceService(databaseRepository, paymentGateways, userClient)
+	invoiceUsecase := usecase.NewInvoiceUsecase(invoiceService)
+
+	// refund service
+	refundService := service.NewRefundService(databaseRepository, paymentGateways)
+	refundUsecase := usecase.NewRefundUsecase(refundService)
+	refundHandler := handler.NewRefundHandler(refundUsecase)
+
+	// anomaly service
+	anomalyService := service.NewAnomalyService(databaseRepository, paymentService)
+	anomalyUsecase := usecase.NewAnomalyUsecase(anomalyService)
+	anomalyHandler := handler.NewAnomalyHandler(anomalyUsecase)
+
+	// failed event service
+	failedEventService := service.NewFailedEventService(databaseRepository)
+	failedEventUsecase := usecase.NewFailedEventUsecase(failedEventService)
+	failedEventHandler := handler.NewFailedEventHandler(failedEventUsecase)
+
+	// reconciliation service
+	reconciliationService := service.NewReconciliationService(databaseRepository, xenditRepository)
+	reconciliationUsecase := usecase.NewReconciliationUsecase(reconciliationService)
+	reconciliationHandler := handler.NewReconciliationHandler(reconciliationUsecase)
+
+	// report service
+	reportService := service.NewReportService(databaseRepository)
+	reportUsecase := usecase.NewReportUsecase(reportService)
+	reportHandler := handler.NewReportHandler(reportUsecase)
+
+	// outbox relay
+	outboxService := service.NewOutboxService(databaseRepository, publisherRepository)
 
 	// scheduler service
+	instanceID := instanceID()
 	schedulerService := service.SchedulerService{
-		Database:       databaseRepository,
-		Xendit:         xenditRepository,
-		Publisher:      publisherRepository,
-		PaymentService: paymentService,
-	}
-
-	// start scheduler
-	schedulerService.StartCheckPendingInvoices()
-	schedulerService.StartProcessPendingPaymentRequests()
-	schedulerService.StartProcessFailedPaymentRequests()
-	schedulerService.StartProcessExpiredPendingPayments()
-
-	// kafka consumer
-	// potential not effienct when traffic is high, consider using a more robust solution like a message queue
-	kafka.StartOrderConsumer(cfg.Kafka.Broker, cfg.Kafka.Topics[constant.KafkaTopicOrderCreated],
-		func(event models.OrderCreatedEvent) {
+		Database:           databaseRepository,
+		Gateways:           paymentGateways,
+		Publisher:          publisherRepository,
+		PaymentService:     paymentService,
+		RefundService:      refundService,
+		FailedEventService: failedEventService,
+		OutboxService:      outboxService,
+		UserClient:         userClient,
+
+		ReconciliationService: reconciliationService,
+
+		InstanceID:              instanceID,
+		PaymentRequestBatchSize: cfg.Scheduler.PaymentRequestBatchSize,
+		PaymentRequestClaimTTL:  cfg.Scheduler.PaymentRequestClaimTTL,
+	}
+
+	// start scheduler jobs, each job is only run by the replica holding its lease
+	jobRunner := job.NewRunner(redisRepository, repository.NewJobStore(redisClient), instanceID)
+	for _, schedulerJob := range schedulerService.Jobs() {
+		jobRunner.Register(applyJobConfig(schedulerJob, cfg.Jobs[schedulerJob.Name]))
+	}
+	jobRunner.Start(ctx)
+
+	jobUsecase := usecase.NewJobUsecase(jobRunner)
+	jobHandler := handler.NewJobHandler(jobUsecase)
+
+	circuitBreakerUsecase := usecase.NewCircuitBreakerUsecase(xenditBreaker, midtransBreaker, userServiceBreaker)
+	circuitBreakerHandler := handler.NewCircuitBreakerHandler(circuitBreakerUsecase)
+
+	// webhook inbox worker
+	webhookInboxUsecase.StartWorker(ctx)
+
+	// kafka consumer, failed event is retried and forwarded to dlq by consumer
+	consumerStopped := kafka.StartOrderConsumer(ctx, cfg.Kafka, kafkaWriter,
+		func(ctx context.Context, event models.OrderCreatedEvent) error {
+			// amount is rounded to the currency, e.g. IDR has no decimals
+			total, err := event.Total()
+			if event.OrderID <= 0 || event.UserID <= 0 || err != nil || !total.IsPositive() {
+				return kafka.Permanent(fmt.Errorf("invalid order_created event: %+v", event))
+			}
+
 			// async process
 			if cfg.Toggle.DisableCreateInvoiceDirectly {
-				if err := paymentUsecase.ProcessPaymentRequest(context.Background(), event); err != nil {
-					log.Logger.Println("Failed handling order_created event:", err.Error())
-				}
-			} else { // sync process
-				if err := xenditUsacase.CreateInvoice(context.Background(), event); err != nil {
-					log.Logger.Println("Failed handling order_created event", err.Error())
-				}
+				return paymentUsecase.ProcessPaymentRequest(ctx, event)
 			}
+
+			// sync process
+			return invoiceUsecase.CreateInvoice(ctx, event)
 		})
 
 	port := cfg.App.Port
 	router := gin.Default()
-	routes.SetupRoutes(router, paymentHandler)
+	routes.SetupRoutes(router, paymentHandler, refundHandler, anomalyHandler, failedEventHandler, jobHandler, reconciliationHandler, reportHandler, circuitBreakerHandler, cfg.Secret.JWTSecret)
+
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
+	}
+
+	go func() {
+		log.Logger.Printf("Server listening on port: %s", port)
+
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Logger.Errorf("server.ListenAndServe() got error: %v", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	log.Logger.Println("Shutting down...")
+
+	shutdownTimeout := cfg.App.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = DefaultShutdownTimeout
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-	router.Run(":" + port)
+	// stop accepting request and wait for in-flight request, including webhook
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Logger.Errorf("server.Shutdown() got error: %v", err)
+	}
+
+	// webhook which is stored but not processed yet is recovered from inbox after restart
+	if err := webhookInboxUsecase.Shutdown(shutdownCtx); err != nil {
+		log.Logger.Errorf("webhookInboxUsecase.Shutdown() got error: %v", err)
+	}
+
+	if err := jobRunner.Wait(shutdownCtx); err != nil {
+		log.Logger.Errorf("jobRunner.Wait() got error: %v", err)
+	}
+
+	select {
+	case <-consumerStopped:
+	case <-shutdownCtx.Done():
+		log.Logger.Errorf("kafka consumer is not stopped: %v", shutdownCtx.Err())
+	}
+
+	// writer is closed after consumer and scheduler, both of them publish with it
+	if err := kafkaWriter.Close(); err != nil {
+		log.Logger.Errorf("kafkaWriter.Close() got error: %v", err)
+	}
+
+	if err := grpcUserClient.Close(); err != nil {
+		log.Logger.Errorf("grpcUserClient.Close() got error: %v", err)
+	}
+
+	if err := redisClient.Close(); err != nil {
+		log.Logger.Errorf("redisClient.Close() got error: %v", err)
+	}
+
+	if sqlDB, err := db.DB(); err == nil {
+		if err = sqlDB.Close(); err != nil {
+			log.Logger.Errorf("sqlDB.Close() got error: %v", err)
+		}
+	}
+
+	log.Logger.Println("Server stopped")
+}
+
+// instanceID identify this replica, e.g. pod name and process id.
+func instanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// applyJobConfig override the default schedule of the job with configured value.
+func applyJobConfig(schedulerJob job.Job, jobConfig config.JobConfig) job.Job {
+	if jobConfig.Enabled != nil {
+		schedulerJob.Enabled = *jobConfig.Enabled
+	}
+
+	if jobConfig.Interval > 0 {
+		schedulerJob.Interval = jobConfig.Interval
+	}
+
+	if jobConfig.Jitter > 0 {
+		schedulerJob.Jitter = jobConfig.Jitter
+	}
+
+	if jobConfig.Timeout > 0 {
+		schedulerJob.Timeout = jobConfig.Timeout
+	}
 
-	log.Logger.Printf("Server listening on port: %s", port)
+	return schedulerJob
 }
@@ -59,7 +59,21 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		c.Set("user_id", claims["user_id"].(float64))
+		userID, ok := claims["user_id"].(float64)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error_messages": "Invalid token.",
+			})
+			c.Abort()
+
+			return
+		}
+
+		// role is optional, only required by admin endpoints
+		role, _ := claims["role"].(string)
+
+		c.Set("user_id", userID)
+		c.Set("role", role)
 		c.Next()
 	}
 }
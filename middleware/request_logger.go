@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"payment/infrastructure/log"
+	"payment/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,12 +13,17 @@ import (
 
 func RequestLogger(timeout time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestID := uuid.New().String()
+		// keep request id from upstream, so it can be used as correlation id of published events
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		c.Header("X-Request-ID", requestID)
 
 		timeoutCtx, cancel := context.WithTimeout(context.Background(), timeout*time.Second)
 		defer cancel()
 
-		ctx := context.WithValue(timeoutCtx, "request_id", requestID)
+		ctx := utils.WithRequestID(timeoutCtx, requestID)
 		c.Request = c.Request.WithContext(ctx)
 
 		startTime := time.Now()
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventEnvelope wrap every event published to kafka.
+// Consumer must check event_type and schema_version before decoding the payload,
+// and use event_id to drop duplicate event since events are published at least once.
+type EventEnvelope[T any] struct {
+	EventID       string    `json:"event_id"`
+	EventType     string    `json:"event_type"`
+	SchemaVersion int       `json:"schema_version"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	Payload       T         `json:"payload"`
+}
+
+func NewEventEnvelope[T any](eventType string, schemaVersion int, correlationID string, payload T) EventEnvelope[T] {
+	return EventEnvelope[T]{
+		EventID:       uuid.NewString(),
+		EventType:     eventType,
+		SchemaVersion: schemaVersion,
+		OccurredAt:    time.Now().UTC(),
+		CorrelationID: correlationID,
+		Payload:       payload,
+	}
+}
@@ -6,9 +6,30 @@ type FailedEvents struct {
 	ID         int64     `json:"id"`
 	OrderID    int64     `json:"order_id"`
 	ExternalID string    `json:"external_id"`
+	EventType  string    `json:"event_type"` // kafka topic of the failed event
 	FailedType int       `json:"failed_type"`
 	Status     int       `json:"status"`
+	RetryCount int       `json:"retry_count"`
+	Payload    string    `json:"payload"` // extra data needed to replay the event
 	Notes      string    `json:"notes"`
 	CreateTime time.Time `json:"create_time"`
 	UpdateTime time.Time `json:"update_time"`
 }
+
+type FailedEventFilter struct {
+	Status int
+	Limit  int
+	Offset int
+}
+
+type FailedEventReplayResult struct {
+	ID      int64  `json:"id"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// payload of failed payment.refunded event
+type FailedRefundEventPayload struct {
+	Refund        Refund `json:"refund"`
+	FullyRefunded bool   `json:"fully_refunded"`
+}
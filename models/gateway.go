@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// ChargeRequest ask the payment gateway to create an invoice or charge which is paid by the customer.
+type ChargeRequest struct {
+	ExternalID    string
+	Amount        Money
+	Description   string
+	PayerEmail    string
+	PayerName     string
+	PayerPhone    string
+	PaymentMethod string
+	Items         []OrderItem
+}
+
+// Charge is the invoice or charge at the payment gateway, status is one of constant.GatewayStatus*
+// or the raw provider status when it is unknown.
+type Charge struct {
+	ID            string
+	ExternalID    string
+	Amount        Money
+	Status        string
+	PaymentMethod string
+	PaymentURL    string
+	ExpiryTime    time.Time
+	PaidTime      time.Time
+}
+
+type GatewayRefundRequest struct {
+	// charge id at the gateway and our external id, each gateway use the one its api needs
+	ChargeID    string
+	ExternalID  string
+	ReferenceID string
+	Amount      Money
+	Reason      string
+}
+
+// GatewayRefund status is one of constant.RefundStatus*.
+type GatewayRefund struct {
+	ID          string
+	ReferenceID string
+	Amount      Money
+	Status      string
+	FailureCode string
+}
+
+// GatewayWebhook is a payment status notification parsed by the gateway which sent it.
+type GatewayWebhook struct {
+	Provider string
+	// identify the notification for dedup, e.g. xendit invoice id
+	CallbackID    string
+	ExternalID    string
+	Status        string
+	Amount        Money
+	PaymentMethod string
+	PaidAt        time.Time
+}
@@ -0,0 +1,78 @@
+package models
+
+type MidtransSnapRequest struct {
+	TransactionDetails MidtransTransactionDetails `json:"transaction_details"`
+	CustomerDetails    *MidtransCustomerDetails   `json:"customer_details,omitempty"`
+	ItemDetails        []MidtransItemDetails      `json:"item_details,omitempty"`
+	EnabledPayments    []string                   `json:"enabled_payments,omitempty"`
+	Expiry             *MidtransExpiry            `json:"expiry,omitempty"`
+}
+
+type MidtransExpiry struct {
+	Unit     string `json:"unit"`
+	Duration int    `json:"duration"`
+}
+
+type MidtransTransactionDetails struct {
+	OrderID     string `json:"order_id"`
+	GrossAmount int64  `json:"gross_amount"`
+}
+
+type MidtransCustomerDetails struct {
+	FirstName string `json:"first_name,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Phone     string `json:"phone,omitempty"`
+}
+
+type MidtransItemDetails struct {
+	Name     string `json:"name"`
+	Price    int64  `json:"price"`
+	Quantity int    `json:"quantity"`
+	Category string `json:"category,omitempty"`
+}
+
+type MidtransSnapResponse struct {
+	Token         string   `json:"token"`
+	RedirectURL   string   `json:"redirect_url"`
+	ErrorMessages []string `json:"error_messages"`
+}
+
+// MidtransTransactionStatus is returned by status api and sent as http notification.
+type MidtransTransactionStatus struct {
+	StatusCode        string `json:"status_code"`
+	StatusMessage     string `json:"status_message"`
+	TransactionID     string `json:"transaction_id"`
+	OrderID           string `json:"order_id"`
+	GrossAmount       string `json:"gross_amount"`
+	PaymentType       string `json:"payment_type"`
+	TransactionStatus string `json:"transaction_status"`
+	FraudStatus       string `json:"fraud_status"`
+	// in Asia/Jakarta, formatted as 2006-01-02 15:04:05
+	TransactionTime string           `json:"transaction_time"`
+	SettlementTime  string           `json:"settlement_time"`
+	ExpiryTime      string           `json:"expiry_time"`
+	SignatureKey    string           `json:"signature_key"`
+	Refunds         []MidtransRefund `json:"refunds"`
+}
+
+type MidtransRefundRequest struct {
+	RefundKey string `json:"refund_key"`
+	Amount    int64  `json:"amount"`
+	Reason    string `json:"reason"`
+}
+
+type MidtransRefundResponse struct {
+	StatusCode         string `json:"status_code"`
+	StatusMessage      string `json:"status_message"`
+	TransactionStatus  string `json:"transaction_status"`
+	RefundKey          string `json:"refund_key"`
+	RefundAmount       string `json:"refund_amount"`
+	RefundChargebackID int64  `json:"refund_chargeback_id"`
+}
+
+type MidtransRefund struct {
+	RefundChargebackID int64  `json:"refund_chargeback_id"`
+	RefundKey          string `json:"refund_key"`
+	RefundAmount       string `json:"refund_amount"`
+	Reason             string `json:"reason"`
+}
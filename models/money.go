@@ -0,0 +1,216 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ISO 4217 currencies accepted by the payment service.
+const (
+	CurrencyIDR = "IDR"
+	CurrencyPHP = "PHP"
+	CurrencyMYR = "MYR"
+	CurrencyTHB = "THB"
+	CurrencyVND = "VND"
+	CurrencySGD = "SGD"
+	CurrencyUSD = "USD"
+
+	// DefaultCurrency is used when an order or a stored row has no currency.
+	DefaultCurrency = CurrencyIDR
+)
+
+// number of decimals of the minor unit of each currency, amounts are rounded to it
+var currencyExponents = map[string]int{
+	CurrencyIDR: 0,
+	CurrencyPHP: 2,
+	CurrencyMYR: 2,
+	CurrencyTHB: 2,
+	CurrencyVND: 0,
+	CurrencySGD: 2,
+	CurrencyUSD: 2,
+}
+
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrInvalidMoney        = errors.New("invalid money amount")
+	ErrCurrencyMismatch    = errors.New("currency mismatch")
+)
+
+// Money is an amount in the minor unit of its currency, e.g. cent for USD and rupiah for IDR which has no decimals.
+// Zero value is zero amount without currency, it takes the currency of the other operand in arithmetic.
+type Money struct {
+	Amount   int64  `json:"-"`
+	Currency string `json:"-"`
+}
+
+// CurrencyExponent return the number of decimals of the currency minor unit.
+func CurrencyExponent(currency string) (int, error) {
+	exponent, ok := currencyExponents[strings.ToUpper(currency)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, currency)
+	}
+
+	return exponent, nil
+}
+
+// NewMoney create money from amount in minor unit.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// ParseMoney parse decimal amount in major unit, e.g. "10000" IDR or "12.345" USD. It is rounded half away from zero
+// to the decimals of the currency, so 12.345 USD is 1235 cent and 10000.5 IDR is 10001.
+func ParseMoney(value string, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	exponent, err := CurrencyExponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, value)
+	}
+
+	rat.Mul(rat, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)))
+
+	// round half away from zero
+	quotient, remainder := new(big.Int).QuoRem(rat.Num(), rat.Denom(), new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(rat.Denom()) >= 0 {
+		if rat.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	if !quotient.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, value)
+	}
+
+	return Money{Amount: quotient.Int64(), Currency: currency}, nil
+}
+
+// Decimal return the exact amount in major unit, e.g. 1235 cent USD is 12.35.
+func (m Money) Decimal() json.Number {
+	exponent, err := CurrencyExponent(m.Currency)
+	if err != nil || exponent == 0 {
+		return json.Number(strconv.FormatInt(m.Amount, 10))
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := fmt.Sprintf("%0*d", exponent+1, amount)
+
+	return json.Number(sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:])
+}
+
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal().String()
+	}
+
+	return m.Currency + " " + m.Decimal().String()
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// Equal is true when both amount and currency are the same.
+func (m Money) Equal(other Money) bool {
+	cmp, err := m.Cmp(other)
+	return err == nil && cmp == 0
+}
+
+// Cmp return -1, 0 or 1 when m is less, equal or greater than other, error when the currencies differ.
+func (m Money) Cmp(other Money) (int, error) {
+	if _, err := m.currencyWith(other); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.currencyWith(other)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	currency, err := m.currencyWith(other)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}, nil
+}
+
+// currencyWith return the currency of the result of m and other, zero amount without currency take the other one.
+func (m Money) currencyWith(other Money) (string, error) {
+	switch {
+	case m.Currency == other.Currency:
+		return m.Currency, nil
+	case m.Currency == "" && m.Amount == 0:
+		return other.Currency, nil
+	case other.Currency == "" && other.Amount == 0:
+		return m.Currency, nil
+	default:
+		return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+}
+
+type moneyJSON struct {
+	Value    json.Number `json:"value"`
+	Currency string      `json:"currency"`
+}
+
+// MarshalJSON write the amount in major unit, e.g. {"value": 12.35, "currency": "USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Value: m.Decimal(), Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var value moneyJSON
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	currency := value.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	money, err := ParseMoney(value.Value.String(), currency)
+	if err != nil {
+		return err
+	}
+
+	*m = money
+
+	return nil
+}
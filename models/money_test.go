@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseMoney(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		currency  string
+		want      Money
+		wantError error
+	}{
+		{name: "idr_integer", value: "150000", currency: "IDR", want: NewMoney(150000, CurrencyIDR)},
+		{name: "idr_has_no_decimals", value: "150000.00", currency: "IDR", want: NewMoney(150000, CurrencyIDR)},
+		{name: "idr_round_half_up", value: "10000.5", currency: "IDR", want: NewMoney(10001, CurrencyIDR)},
+		{name: "idr_round_down", value: "10000.49", currency: "IDR", want: NewMoney(10000, CurrencyIDR)},
+		{name: "usd_cent", value: "12.34", currency: "USD", want: NewMoney(1234, CurrencyUSD)},
+		{name: "usd_round_half_up", value: "12.345", currency: "USD", want: NewMoney(1235, CurrencyUSD)},
+		{name: "negative_round_half_away_from_zero", value: "-12.345", currency: "USD", want: NewMoney(-1235, CurrencyUSD)},
+		// float64 0.1 + 0.2 is 0.30000000000000004
+		{name: "float_rounding_noise", value: "0.30000000000000004", currency: "PHP", want: NewMoney(30, CurrencyPHP)},
+		{name: "lowercase_currency", value: "1", currency: "usd", want: NewMoney(100, CurrencyUSD)},
+		{name: "unsupported_currency", value: "1", currency: "XXX", wantError: ErrUnsupportedCurrency},
+		{name: "empty_value", value: "", currency: "IDR", wantError: ErrInvalidMoney},
+		{name: "not_a_number", value: "abc", currency: "IDR", wantError: ErrInvalidMoney},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseMoney(test.value, test.currency)
+			assert.True(t, errors.Is(err, test.wantError), "got error %v", err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func Test_Money_Decimal(t *testing.T) {
+	assert.Equal(t, json.Number("150000"), NewMoney(150000, CurrencyIDR).Decimal())
+	assert.Equal(t, json.Number("12.35"), NewMoney(1235, CurrencyUSD).Decimal())
+	assert.Equal(t, json.Number("0.05"), NewMoney(5, CurrencyUSD).Decimal())
+	assert.Equal(t, json.Number("-0.05"), NewMoney(-5, CurrencyUSD).Decimal())
+	assert.Equal(t, "USD 12.35", NewMoney(1235, CurrencyUSD).String())
+}
+
+func Test_Money_Arithmetic(t *testing.T) {
+	sum, err := NewMoney(1000, CurrencyIDR).Add(NewMoney(500, CurrencyIDR))
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(1500, CurrencyIDR), sum)
+
+	// zero value takes the currency of the other operand
+	difference, err := Money{}.Sub(NewMoney(500, CurrencyIDR))
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(-500, CurrencyIDR), difference)
+
+	_, err = NewMoney(1000, CurrencyIDR).Add(NewMoney(1000, CurrencyUSD))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	assert.True(t, NewMoney(1000, CurrencyIDR).Equal(NewMoney(1000, CurrencyIDR)))
+	assert.False(t, NewMoney(1000, CurrencyIDR).Equal(NewMoney(1000, CurrencyPHP)))
+}
+
+func Test_Money_JSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(1235, CurrencyUSD))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"value": 12.35, "currency": "USD"}`, string(data))
+
+	var money Money
+	require.NoError(t, json.Unmarshal(data, &money))
+	assert.Equal(t, NewMoney(1235, CurrencyUSD), money)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"value": 10000}`), &money))
+	assert.Equal(t, NewMoney(10000, CurrencyIDR), money)
+}
@@ -1,9 +1,41 @@
 package models
 
+import "encoding/json"
+
 type OrderCreatedEvent struct {
-	OrderID         int64   `json:"order_id"`
-	UserID          int64   `json:"user_id"`
-	TotalAmount     float64 `json:"amount"`
-	PaymentMethod   string  `json:"payment_method"`
-	ShippingAddress string  `json:"shipping_address"`
+	OrderID         int64       `json:"order_id"`
+	UserID          int64       `json:"user_id"`
+	TotalAmount     json.Number `json:"amount"`
+	PaymentMethod   string      `json:"payment_method"`
+	ShippingAddress string      `json:"shipping_address"`
+	// empty means DefaultCurrency
+	Currency      string      `json:"currency"`
+	CustomerName  string      `json:"customer_name"`
+	CustomerPhone string      `json:"customer_phone"`
+	Items         []OrderItem `json:"items"`
+}
+
+type OrderItem struct {
+	Name     string      `json:"name"`
+	Quantity int         `json:"quantity"`
+	Price    json.Number `json:"price"`
+	Category string      `json:"category"`
+}
+
+// Total return the order amount in its currency, amount with more decimals than the currency has is rounded.
+func (e OrderCreatedEvent) Total() (Money, error) {
+	return ParseMoney(e.TotalAmount.String(), e.currency())
+}
+
+// ItemPrice return the unit price of the item in the order currency.
+func (e OrderCreatedEvent) ItemPrice(item OrderItem) (Money, error) {
+	return ParseMoney(item.Price.String(), e.currency())
+}
+
+func (e OrderCreatedEvent) currency() string {
+	if e.Currency == "" {
+		return DefaultCurrency
+	}
+
+	return e.Currency
 }
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type Outbox struct {
+	ID          int64      `json:"id"`
+	EventID     string     `json:"event_id"`
+	AggregateID int64      `json:"aggregate_id"` // order id
+	EventType   string     `json:"event_type"`   // topic name, resolved to the configured topic on publish
+	MessageKey  string     `json:"message_key"`
+	Payload     string     `json:"payload"` // json encoded event envelope
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error"`
+	CreateTime  time.Time  `json:"create_time"`
+	PublishTime *time.Time `json:"publish_time"`
+	UpdateTime  time.Time  `json:"update_time"`
+}
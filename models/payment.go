@@ -3,25 +3,46 @@ package models
 import "time"
 
 type Payment struct {
-	ID          int64     `json:"id"`
-	OrderID     int64     `json:"order_id"`
-	UserID      int64     `json:"user_id"`
-	ExternalID  string    `json:"external_id"`
-	Amount      float64   `json:"amount"`
-	Status      string    `json:"status"`
-	ExpiredTime time.Time `json:"expired_time"`
-	CreateTime  time.Time `json:"create_time"`
-	UpdateTime  time.Time `json:"update_time"`
+	ID         int64         `json:"id"`
+	OrderID    int64         `json:"order_id"`
+	UserID     int64         `json:"user_id"`
+	ExternalID string        `json:"external_id"`
+	Amount     Money         `json:"amount" gorm:"embedded"`
+	Status     PaymentStatus `json:"status"`
+	Provider   string        `json:"provider"` // payment gateway, see constant.GatewayProvider*
+	// invoice or transaction id at the gateway, e.g. xendit invoice id
+	GatewayChargeID string     `json:"gateway_charge_id"`
+	InvoiceURL      string     `json:"invoice_url"`
+	PaymentMethod   string     `json:"payment_method"`
+	PaidTime        *time.Time `json:"paid_time"`
+	ExpiredTime     time.Time  `json:"expired_time"`
+	CreateTime      time.Time  `json:"create_time"`
+	UpdateTime      time.Time  `json:"update_time"`
+}
+
+// PaymentPaidDetail is taken from xendit webhook or invoice, it is stored when payment is marked as paid.
+type PaymentPaidDetail struct {
+	PaymentMethod string
+	PaidAt        time.Time
 }
 
 type PaymentRequests struct {
-	ID         int64     `json:"id"`
-	OrderID    int64     `json:"order_id"`
-	UserID     int64     `json:"user_id"`
-	Amount     float64   `json:"amount"`
-	Status     string    `json:"status"`
-	RetryCount int       `json:"retry_count"`
-	Notes      string    `json:"notes"`
-	CreateTime time.Time `json:"create_time"`
-	UpdateTime time.Time `json:"update_time"`
+	ID      int64 `json:"id"`
+	OrderID int64 `json:"order_id"`
+	UserID  int64 `json:"user_id"`
+	Amount  Money `json:"amount" gorm:"embedded"`
+	// payment method chosen by customer at order, it decides the payment gateway
+	PaymentMethod string `json:"payment_method"`
+	// taken from the order event, they are sent to the gateway when the charge is created
+	CustomerName  string      `json:"customer_name"`
+	CustomerPhone string      `json:"customer_phone"`
+	Items         []OrderItem `json:"items" gorm:"serializer:json"`
+	Status        string      `json:"status"`
+	RetryCount    int         `json:"retry_count"`
+	Notes         string      `json:"notes"`
+	// worker which claim the request to be processed, claim is released when lease expire time is passed
+	ClaimedBy       string     `json:"claimed_by"`
+	LeaseExpireTime *time.Time `json:"lease_expire_time"`
+	CreateTime      time.Time  `json:"create_time"`
+	UpdateTime      time.Time  `json:"update_time"`
 }
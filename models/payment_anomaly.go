@@ -11,4 +11,30 @@ type PaymentAnomaly struct {
 	Status      int       `json:"status"`
 	CreateTime  time.Time `json:"create_time"`
 	UpdateTime  time.Time `json:"update_time"`
+
+	// filled when anomaly is resolved manually
+	ResolvedBy      int64      `json:"resolved_by"`
+	ResolutionNotes string     `json:"resolution_notes"`
+	ResolvedTime    *time.Time `json:"resolved_time"`
+}
+
+type PaymentAnomalyFilter struct {
+	AnomalyType int
+	Status      int
+	StartTime   time.Time
+	EndTime     time.Time
+	Limit       int
+	Offset      int
+}
+
+type PaymentAnomalyDetail struct {
+	Anomaly   PaymentAnomaly    `json:"anomaly"`
+	Payment   *Payment          `json:"payment"`
+	AuditLogs []PaymentAuditLog `json:"audit_logs"`
+}
+
+type ResolvePaymentAnomalyRequest struct {
+	Status     int    `json:"status" binding:"required"`
+	Notes      string `json:"notes" binding:"required"`
+	ResolvedBy int64  `json:"-"`
 }
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Schema version of each event payload, bump it on breaking change and add the new schema to files/schemas.
+// v2 add currency, amount is a decimal in major unit of it, e.g. 150000 for IDR and 12.35 for USD.
+const (
+	PaymentSuccessSchemaVersion  = 2
+	PaymentExpiredSchemaVersion  = 2
+	PaymentFailedSchemaVersion   = 2
+	PaymentRefundedSchemaVersion = 2
+)
+
+// PaymentSuccessEvent is the payload of payment.success event.
+type PaymentSuccessEvent struct {
+	OrderID       int64       `json:"order_id"`
+	UserID        int64       `json:"user_id"`
+	ExternalID    string      `json:"external_id"`
+	Amount        json.Number `json:"amount"`
+	Currency      string      `json:"currency"`
+	Status        string      `json:"status"`
+	PaymentMethod string      `json:"payment_method"`
+	PaidAt        time.Time   `json:"paid_at"`
+}
+
+// PaymentClosedEvent is the payload of payment.expired and payment.failed event.
+type PaymentClosedEvent struct {
+	OrderID    int64       `json:"order_id"`
+	UserID     int64       `json:"user_id"`
+	ExternalID string      `json:"external_id"`
+	Amount     json.Number `json:"amount"`
+	Currency   string      `json:"currency"`
+	Status     string      `json:"status"`
+}
+
+// PaymentRefundedEvent is the payload of payment.refunded event, sent for every succeeded refund.
+type PaymentRefundedEvent struct {
+	OrderID     int64       `json:"order_id"`
+	UserID      int64       `json:"user_id"`
+	ExternalID  string      `json:"external_id"`
+	RefundID    int64       `json:"refund_id"`
+	ReferenceID string      `json:"reference_id"`
+	Amount      json.Number `json:"amount"`
+	Currency    string      `json:"currency"`
+	Reason      string      `json:"reason"`
+	Status      string      `json:"status"`
+}
+
+func NewPaymentSuccessEvent(payment Payment, detail PaymentPaidDetail) PaymentSuccessEvent {
+	return PaymentSuccessEvent{
+		OrderID:       payment.OrderID,
+		UserID:        payment.UserID,
+		ExternalID:    payment.ExternalID,
+		Amount:        payment.Amount.Decimal(),
+		Currency:      payment.Amount.Currency,
+		Status:        "paid",
+		PaymentMethod: detail.PaymentMethod,
+		PaidAt:        detail.PaidAt.UTC(),
+	}
+}
+
+// NewPaymentClosedEvent status is expired or failed.
+func NewPaymentClosedEvent(payment Payment, status PaymentStatus) PaymentClosedEvent {
+	closedStatus := "expired"
+	if status == PaymentStatusFailed {
+		closedStatus = "failed"
+	}
+
+	return PaymentClosedEvent{
+		OrderID:    payment.OrderID,
+		UserID:     payment.UserID,
+		ExternalID: payment.ExternalID,
+		Amount:     payment.Amount.Decimal(),
+		Currency:   payment.Amount.Currency,
+		Status:     closedStatus,
+	}
+}
+
+func NewPaymentRefundedEvent(refund Refund, fullyRefunded bool) PaymentRefundedEvent {
+	status := "partially_refunded"
+	if fullyRefunded {
+		status = "refunded"
+	}
+
+	return PaymentRefundedEvent{
+		OrderID:     refund.OrderID,
+		UserID:      refund.UserID,
+		ExternalID:  refund.ExternalID,
+		RefundID:    refund.ID,
+		ReferenceID: refund.ReferenceID,
+		Amount:      refund.Amount.Decimal(),
+		Currency:    refund.Amount.Currency,
+		Reason:      refund.Reason,
+		Status:      status,
+	}
+}
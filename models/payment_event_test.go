@@ -0,0 +1,207 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test ./models -run Test_PaymentEventContract -update
+var updateGolden = flag.Bool("update", false, "update golden files of published events")
+
+func Test_PaymentEventContract(t *testing.T) {
+	occurredAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	payment := Payment{
+		ID:         10,
+		OrderID:    123,
+		UserID:     45,
+		ExternalID: "order-123",
+		Amount:     NewMoney(150000, CurrencyIDR),
+	}
+	refund := Refund{
+		ID:          7,
+		OrderID:     123,
+		UserID:      45,
+		ExternalID:  "order-123",
+		ReferenceID: "refund-123-abc",
+		Amount:      NewMoney(50000, CurrencyIDR),
+		Reason:      "REQUESTED_BY_CUSTOMER",
+	}
+
+	tests := []struct {
+		name     string
+		envelope interface{}
+	}{
+		{
+			name: "payment.success.v2",
+			envelope: EventEnvelope[PaymentSuccessEvent]{
+				EventType:     "payment.success",
+				SchemaVersion: PaymentSuccessSchemaVersion,
+				Payload: NewPaymentSuccessEvent(payment, PaymentPaidDetail{
+					PaymentMethod: "BANK_TRANSFER",
+					PaidAt:        time.Date(2026, 1, 2, 10, 4, 0, 0, time.FixedZone("WIB", 7*60*60)),
+				}),
+			},
+		},
+		{
+			name: "payment.expired.v2",
+			envelope: EventEnvelope[PaymentClosedEvent]{
+				EventType:     "payment.expired",
+				SchemaVersion: PaymentExpiredSchemaVersion,
+				Payload:       NewPaymentClosedEvent(payment, PaymentStatusExpired),
+			},
+		},
+		{
+			name: "payment.failed.v2",
+			envelope: EventEnvelope[PaymentClosedEvent]{
+				EventType:     "payment.failed",
+				SchemaVersion: PaymentFailedSchemaVersion,
+				Payload:       NewPaymentClosedEvent(payment, PaymentStatusFailed),
+			},
+		},
+		{
+			name: "payment.refunded.v2",
+			envelope: EventEnvelope[PaymentRefundedEvent]{
+				EventType:     "payment.refunded",
+				SchemaVersion: PaymentRefundedSchemaVersion,
+				Payload:       NewPaymentRefundedEvent(refund, false),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(test.envelope)
+			assert.NoError(t, err)
+
+			// fixed envelope metadata, so golden file is stable
+			var message map[string]interface{}
+			assert.NoError(t, json.Unmarshal(data, &message))
+			message["event_id"] = "00000000-0000-0000-0000-000000000001"
+			message["occurred_at"] = occurredAt.Format(time.RFC3339Nano)
+			message["correlation_id"] = "request-1"
+
+			got, err := json.MarshalIndent(message, "", "  ")
+			assert.NoError(t, err)
+			got = append(got, '\n')
+
+			goldenPath := filepath.Join("testdata", test.name+".golden.json")
+			if *updateGolden {
+				assert.NoError(t, os.WriteFile(goldenPath, got, 0o644))
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			assert.NoError(t, err)
+			assert.Equal(t, string(want), string(got), "published event changed, bump schema version on breaking change and run with -update")
+
+			// golden file must match the published json schema
+			schemaData, err := os.ReadFile(filepath.Join("..", "files", "schemas", test.name+".json"))
+			assert.NoError(t, err)
+
+			var schema map[string]interface{}
+			assert.NoError(t, json.Unmarshal(schemaData, &schema))
+
+			var decoded interface{}
+			decoder := json.NewDecoder(bytes.NewReader(got))
+			decoder.UseNumber()
+			assert.NoError(t, decoder.Decode(&decoded))
+			assert.NoError(t, validateSchema(schema, decoded, "$"))
+		})
+	}
+}
+
+func Test_EventEnvelope_Decode(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "payment.success.v2.golden.json"))
+	assert.NoError(t, err)
+
+	var envelope EventEnvelope[PaymentSuccessEvent]
+	assert.NoError(t, json.Unmarshal(data, &envelope))
+	assert.Equal(t, "payment.success", envelope.EventType)
+	assert.Equal(t, "request-1", envelope.CorrelationID)
+	assert.Equal(t, int64(123), envelope.Payload.OrderID)
+	assert.Equal(t, "BANK_TRANSFER", envelope.Payload.PaymentMethod)
+	assert.Equal(t, CurrencyIDR, envelope.Payload.Currency)
+	assert.True(t, envelope.Payload.PaidAt.Equal(time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)))
+}
+
+// validateSchema support the subset of json schema used in files/schemas.
+func validateSchema(schema map[string]interface{}, value interface{}, path string) error {
+	if constValue, ok := schema["const"]; ok && fmt.Sprint(constValue) != fmt.Sprint(value) {
+		return fmt.Errorf("%s: want const %v, got %v", path, constValue, value)
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, item := range enum {
+			found = found || fmt.Sprint(item) == fmt.Sprint(value)
+		}
+
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: want object", path)
+		}
+
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, key := range required {
+			if _, ok := object[key.(string)]; !ok {
+				return fmt.Errorf("%s: missing required property %s", path, key)
+			}
+		}
+
+		for key, item := range object {
+			propertySchema, ok := properties[key].(map[string]interface{})
+			if !ok {
+				if schema["additionalProperties"] == false {
+					return fmt.Errorf("%s: property %s is not declared", path, key)
+				}
+
+				continue
+			}
+
+			if err := validateSchema(propertySchema, item, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: want string", path)
+		}
+
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, text); err != nil {
+				return fmt.Errorf("%s: want date-time, got %s", path, text)
+			}
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: want integer", path)
+		}
+
+		if _, err := number.Int64(); err != nil {
+			return fmt.Errorf("%s: want integer, got %s", path, number)
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return fmt.Errorf("%s: want number", path)
+		}
+	}
+
+	return nil
+}
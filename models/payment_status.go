@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPending  PaymentStatus = "PENDING"
+	PaymentStatusPaid     PaymentStatus = "PAID"
+	PaymentStatusExpired  PaymentStatus = "EXPIRED"
+	PaymentStatusFailed   PaymentStatus = "FAILED"
+	PaymentStatusRefunded PaymentStatus = "REFUNDED"
+)
+
+var ErrInvalidPaymentStatusTransition = errors.New("invalid payment status transition")
+
+// allowed payment status transitions, the only place where payment lifecycle is defined.
+var paymentStatusTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusPending: {PaymentStatusPaid, PaymentStatusExpired, PaymentStatusFailed},
+	PaymentStatusPaid:    {PaymentStatusRefunded},
+}
+
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, allowed := range paymentStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateTransition return ErrInvalidPaymentStatusTransition when moving to next status is not allowed.
+func (s PaymentStatus) ValidateTransition(next PaymentStatus) error {
+	if !s.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidPaymentStatusTransition, s, next)
+	}
+
+	return nil
+}
+
+// PreviousPaymentStatuses return statuses which are allowed to move to the given status.
+func PreviousPaymentStatuses(next PaymentStatus) []PaymentStatus {
+	var statuses []PaymentStatus
+	for from, allowed := range paymentStatusTransitions {
+		for _, status := range allowed {
+			if status == next {
+				statuses = append(statuses, from)
+			}
+		}
+	}
+
+	return statuses
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PaymentStatus_ValidateTransition(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      PaymentStatus
+		to        PaymentStatus
+		wantError bool
+	}{
+		{name: "pending_to_paid", from: PaymentStatusPending, to: PaymentStatusPaid},
+		{name: "pending_to_expired", from: PaymentStatusPending, to: PaymentStatusExpired},
+		{name: "pending_to_failed", from: PaymentStatusPending, to: PaymentStatusFailed},
+		{name: "paid_to_refunded", from: PaymentStatusPaid, to: PaymentStatusRefunded},
+		{name: "paid_to_expired", from: PaymentStatusPaid, to: PaymentStatusExpired, wantError: true},
+		{name: "expired_to_paid", from: PaymentStatusExpired, to: PaymentStatusPaid, wantError: true},
+		{name: "pending_to_refunded", from: PaymentStatusPending, to: PaymentStatusRefunded, wantError: true},
+		{name: "refunded_to_paid", from: PaymentStatusRefunded, to: PaymentStatusPaid, wantError: true},
+		{name: "lowercase_legacy_status", from: PaymentStatus("paid"), to: PaymentStatusRefunded, wantError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.from.ValidateTransition(test.to)
+			assert.Equal(t, test.wantError, errors.Is(err, ErrInvalidPaymentStatusTransition))
+		})
+	}
+}
+
+func Test_PreviousPaymentStatuses(t *testing.T) {
+	assert.ElementsMatch(t, []PaymentStatus{PaymentStatusPending}, PreviousPaymentStatuses(PaymentStatusPaid))
+	assert.ElementsMatch(t, []PaymentStatus{PaymentStatusPaid}, PreviousPaymentStatuses(PaymentStatusRefunded))
+	assert.Empty(t, PreviousPaymentStatuses(PaymentStatusPending))
+}
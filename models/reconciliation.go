@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// ReconciliationRun is a single comparison of xendit invoices and payments created in [PeriodStart, PeriodEnd).
+type ReconciliationRun struct {
+	ID                 int64     `json:"id"`
+	PeriodStart        time.Time `json:"period_start"`
+	PeriodEnd          time.Time `json:"period_end"`
+	XenditInvoiceCount int       `json:"xendit_invoice_count"`
+	LocalPaymentCount  int       `json:"local_payment_count"`
+	DiscrepancyCount   int       `json:"discrepancy_count"`
+	TriggeredBy        string    `json:"triggered_by"`
+	CreateTime         time.Time `json:"create_time"`
+}
+
+type ReconciliationDiscrepancy struct {
+	ID              int64     `json:"id"`
+	RunID           int64     `json:"run_id"`
+	DiscrepancyType string    `json:"discrepancy_type"`
+	OrderID         int64     `json:"order_id"`
+	ExternalID      string    `json:"external_id"`
+	XenditInvoiceID string    `json:"xendit_invoice_id"`
+	LocalStatus     string    `json:"local_status"`
+	XenditStatus    string    `json:"xendit_status"`
+	LocalAmount     Money     `json:"local_amount" gorm:"embedded;embeddedPrefix:local_"`
+	XenditAmount    Money     `json:"xendit_amount" gorm:"embedded;embeddedPrefix:xendit_"`
+	Notes           string    `json:"notes"`
+	CreateTime      time.Time `json:"create_time"`
+}
+
+type ReconciliationRunFilter struct {
+	// filter by period start
+	StartTime time.Time
+	EndTime   time.Time
+	Limit     int
+	Offset    int
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Refund struct {
+	ID              int64     `json:"id"`
+	OrderID         int64     `json:"order_id"`
+	PaymentID       int64     `json:"payment_id"`
+	UserID          int64     `json:"user_id"`
+	ExternalID      string    `json:"external_id"`
+	ReferenceID     string    `json:"reference_id"`
+	GatewayRefundID string    `json:"gateway_refund_id"`
+	Amount          Money     `json:"amount" gorm:"embedded"`
+	Reason          string    `json:"reason"`
+	Status          string    `json:"status"` // PENDING, SUCCEEDED, FAILED
+	Notes           string    `json:"notes"`
+	RequestedBy     int64     `json:"requested_by"`
+	CreateTime      time.Time `json:"create_time"`
+	UpdateTime      time.Time `json:"update_time"`
+}
+
+type RefundRequest struct {
+	OrderID int64 `json:"-"`
+	// decimal in the currency of the payment, empty amount means refund all remaining paid amount
+	Amount      json.Number `json:"amount"`
+	Reason      string      `json:"reason"`
+	RequestedBy int64       `json:"-"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// SettlementSummary is the aggregate of one category of payments for a day, payment method and currency.
+type SettlementSummary struct {
+	Day           string `json:"day"`
+	PaymentMethod string `json:"payment_method"`
+	Category      string `json:"category"`
+	Count         int    `json:"count"`
+	Amount        Money  `json:"amount" gorm:"embedded"`
+}
+
+type SettlementReportRow struct {
+	// empty day or payment method means the row is a total, amounts of different currencies are never added
+	Day            string `json:"day"`
+	PaymentMethod  string `json:"payment_method"`
+	Currency       string `json:"currency"`
+	PaidCount      int    `json:"paid_count"`
+	PaidAmount     Money  `json:"paid_amount"`
+	RefundedCount  int    `json:"refunded_count"`
+	RefundedAmount Money  `json:"refunded_amount"`
+	ExpiredCount   int    `json:"expired_count"`
+	ExpiredAmount  Money  `json:"expired_amount"`
+	// paid amount minus refunded amount
+	NetAmount Money `json:"net_amount"`
+}
+
+// SettlementReport cover [StartTime, EndTime), rows are ordered by day, payment method then currency.
+// Every total is per currency.
+type SettlementReport struct {
+	StartTime    time.Time             `json:"start_time"`
+	EndTime      time.Time             `json:"end_time"`
+	Rows         []SettlementReportRow `json:"rows"`
+	DailyTotals  []SettlementReportRow `json:"daily_totals"`
+	MethodTotals []SettlementReportRow `json:"method_totals"`
+	Totals       []SettlementReportRow `json:"totals"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type WebhookInbox struct {
+	ID            int64      `json:"id"`
+	Source        string     `json:"source"` // provider which sent the webhook, see constant.GatewayProvider*
+	ExternalID    string     `json:"external_id"`
+	RequestID     string     `json:"request_id"`
+	Headers       string     `json:"headers"`  // json encoded request headers, without callback token
+	RawBody       string     `json:"raw_body"` // request body as received
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	Result        string     `json:"result"`
+	ReceivedTime  time.Time  `json:"received_time"`
+	ProcessedTime *time.Time `json:"processed_time"`
+	UpdateTime    time.Time  `json:"update_time"`
+}
+
+type WebhookInboxFilter struct {
+	Status    string
+	StartTime time.Time
+	EndTime   time.Time
+	Limit     int
+}
+
+type WebhookInboxReplayResult struct {
+	ID      int64  `json:"id"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
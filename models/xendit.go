@@ -1,17 +1,92 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type XenditInvoiceRequest struct {
-	ExternalID  string  `json:"external_id"`
-	Amount      float64 `json:"amount"`
-	Description string  `json:"description"`
-	PayerEmail  string  `json:"payer_email"`
+	ExternalID  string      `json:"external_id"`
+	Amount      json.Number `json:"amount"`
+	Description string      `json:"description"`
+	PayerEmail  string      `json:"payer_email"`
+	Currency    string      `json:"currency,omitempty"`
+	// seconds
+	InvoiceDuration    int64               `json:"invoice_duration,omitempty"`
+	PaymentMethods     []string            `json:"payment_methods,omitempty"`
+	SuccessRedirectURL string              `json:"success_redirect_url,omitempty"`
+	FailureRedirectURL string              `json:"failure_redirect_url,omitempty"`
+	Customer           *XenditCustomer     `json:"customer,omitempty"`
+	Items              []XenditInvoiceItem `json:"items,omitempty"`
+}
+
+type XenditCustomer struct {
+	GivenNames   string `json:"given_names,omitempty"`
+	Email        string `json:"email,omitempty"`
+	MobileNumber string `json:"mobile_number,omitempty"`
+}
+
+type XenditInvoiceItem struct {
+	Name     string      `json:"name"`
+	Quantity int         `json:"quantity"`
+	Price    json.Number `json:"price"`
+	Category string      `json:"category,omitempty"`
 }
 
 type XenditInvoiceResponse struct {
-	ID         string    `json:"id"`
-	ExpiryDate time.Time `json:"expiry_date"`
-	InvoiceURL string    `json:"invoice_url"`
-	Status     string    `json:"status"`
+	ID            string      `json:"id"`
+	ExternalID    string      `json:"external_id"`
+	Amount        json.Number `json:"amount"`
+	Currency      string      `json:"currency"`
+	ExpiryDate    time.Time   `json:"expiry_date"`
+	InvoiceURL    string      `json:"invoice_url"`
+	Status        string      `json:"status"`
+	PaymentMethod string      `json:"payment_method"`
+	PaidAt        time.Time   `json:"paid_at"`
+	Created       time.Time   `json:"created"`
+}
+
+// XenditListInvoicesRequest list invoices created in [CreatedAfter, CreatedBefore), ordered by xendit.
+type XenditListInvoicesRequest struct {
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Limit         int
+	// id of the last invoice in the previous page
+	LastInvoiceID string
+}
+
+type XenditRefundRequest struct {
+	InvoiceID   string      `json:"invoice_id"`
+	ReferenceID string      `json:"reference_id"`
+	Amount      json.Number `json:"amount"`
+	Reason      string      `json:"reason"`
+}
+
+type XenditRefundResponse struct {
+	ID          string      `json:"id"`
+	InvoiceID   string      `json:"invoice_id"`
+	ReferenceID string      `json:"reference_id"`
+	Amount      json.Number `json:"amount"`
+	Currency    string      `json:"currency"`
+	Status      string      `json:"status"`
+	Reason      string      `json:"reason"`
+	FailureCode string      `json:"failure_code"`
+	Created     time.Time   `json:"created"`
+}
+
+// Total return the invoice amount, xendit omit currency of IDR invoice in some payloads.
+func (r XenditInvoiceResponse) Total() (Money, error) {
+	return parseXenditAmount(r.Amount, r.Currency)
+}
+
+func (r XenditRefundResponse) Total() (Money, error) {
+	return parseXenditAmount(r.Amount, r.Currency)
+}
+
+func parseXenditAmount(amount json.Number, currency string) (Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	return ParseMoney(amount.String(), currency)
 }
@@ -1,7 +1,20 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type XenditWebhookPayload struct {
-	ExternalID string  `json:"external_id"`
-	Status     string  `json:"status"`
-	Amount     float64 `json:"amount"`
+	ID            string      `json:"id"` // xendit invoice id
+	ExternalID    string      `json:"external_id"`
+	Status        string      `json:"status"`
+	Amount        json.Number `json:"amount"`
+	Currency      string      `json:"currency"`
+	PaymentMethod string      `json:"payment_method"`
+	PaidAt        time.Time   `json:"paid_at"`
+}
+
+func (p XenditWebhookPayload) Total() (Money, error) {
+	return parseXenditAmount(p.Amount, p.Currency)
 }
@@ -20,7 +20,9 @@ func GenerateInvoicePDF(payment models.Payment, outputPath string) error {
 	pdf.Ln(10)
 	pdf.Cell(40, 10, fmt.Sprintf("User ID: %d", payment.UserID))
 	pdf.Ln(10)
-	pdf.Cell(40, 10, fmt.Sprintf("Total AMount: %.2f", payment.Amount))
+	pdf.Cell(40, 10, fmt.Sprintf("Total Amount: %s", payment.Amount.Decimal()))
+	pdf.Ln(10)
+	pdf.Cell(40, 10, fmt.Sprintf("Currency: %s", payment.Amount.Currency))
 	pdf.Ln(10)
 	pdf.Cell(40, 10, fmt.Sprintf("Status: %s", payment.Status))
 	pdf.Ln(10)
@@ -0,0 +1,46 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"payment/models"
+	"strconv"
+	"time"
+)
+
+var reconciliationDiscrepancyHeader = []string{
+	"id", "run_id", "discrepancy_type", "order_id", "external_id", "xendit_invoice_id",
+	"local_status", "xendit_status", "local_amount", "xendit_amount", "notes", "create_time",
+}
+
+// WriteReconciliationDiscrepanciesCSV write discrepancies as csv with a header row.
+func WriteReconciliationDiscrepanciesCSV(w io.Writer, discrepancies []models.ReconciliationDiscrepancy) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(reconciliationDiscrepancyHeader); err != nil {
+		return err
+	}
+
+	for _, d := range discrepancies {
+		err := writer.Write([]string{
+			strconv.FormatInt(d.ID, 10),
+			strconv.FormatInt(d.RunID, 10),
+			d.DiscrepancyType,
+			strconv.FormatInt(d.OrderID, 10),
+			d.ExternalID,
+			d.XenditInvoiceID,
+			d.LocalStatus,
+			d.XenditStatus,
+			d.LocalAmount.String(),
+			d.XenditAmount.String(),
+			d.Notes,
+			d.CreateTime.Format(time.DateTime),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,168 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"payment/models"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// label of total row in day or payment method column
+const settlementTotalLabel = "TOTAL"
+
+var settlementHeader = []string{
+	"day", "payment_method", "currency", "paid_count", "paid_amount", "refunded_count", "refunded_amount",
+	"expired_count", "expired_amount", "net_amount",
+}
+
+// settlementRows flatten the report into a single table. Rows of a day are followed by the day totals of each
+// currency, then totals per payment method and the grand totals are added at the end.
+func settlementRows(report *models.SettlementReport) []models.SettlementReportRow {
+	var rows []models.SettlementReportRow
+
+	// daily totals are ordered by day then currency
+	dailyTotals := make(map[string][]models.SettlementReportRow, len(report.DailyTotals))
+	for _, total := range report.DailyTotals {
+		dailyTotals[total.Day] = append(dailyTotals[total.Day], total)
+	}
+
+	for i, row := range report.Rows {
+		rows = append(rows, row)
+
+		lastOfDay := i == len(report.Rows)-1 || report.Rows[i+1].Day != row.Day
+		if lastOfDay {
+			for _, total := range dailyTotals[row.Day] {
+				total.PaymentMethod = settlementTotalLabel
+				rows = append(rows, total)
+			}
+		}
+	}
+
+	for _, total := range report.MethodTotals {
+		total.Day = settlementTotalLabel
+		rows = append(rows, total)
+	}
+
+	for _, total := range report.Totals {
+		total.Day = settlementTotalLabel
+		total.PaymentMethod = settlementTotalLabel
+		rows = append(rows, total)
+	}
+
+	return rows
+}
+
+// WriteSettlementReportCSV write the report as a single csv table with a header row.
+func WriteSettlementReportCSV(w io.Writer, report *models.SettlementReport) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(settlementHeader); err != nil {
+		return err
+	}
+
+	for _, row := range settlementRows(report) {
+		err := writer.Write([]string{
+			row.Day,
+			row.PaymentMethod,
+			row.Currency,
+			strconv.Itoa(row.PaidCount),
+			row.PaidAmount.Decimal().String(),
+			strconv.Itoa(row.RefundedCount),
+			row.RefundedAmount.Decimal().String(),
+			strconv.Itoa(row.ExpiredCount),
+			row.ExpiredAmount.Decimal().String(),
+			row.NetAmount.Decimal().String(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// amountCell is the amount in major unit, excel keep numbers as float anyway.
+func amountCell(amount models.Money) float64 {
+	value, _ := amount.Decimal().Float64()
+	return value
+}
+
+// WriteSettlementReportXLSX write the same table as csv in the first sheet, amounts are stored as numbers.
+func WriteSettlementReportXLSX(w io.Writer, report *models.SettlementReport) error {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	sheet := "Settlement"
+	if err := file.SetSheetName(file.GetSheetName(0), sheet); err != nil {
+		return err
+	}
+
+	headerStyle, err := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+
+	amountStyle, err := file.NewStyle(&excelize.Style{NumFmt: 4}) // #,##0.00
+	if err != nil {
+		return err
+	}
+
+	// currency without decimals, e.g. IDR
+	wholeAmountStyle, err := file.NewStyle(&excelize.Style{NumFmt: 3}) // #,##0
+	if err != nil {
+		return err
+	}
+
+	if err = file.SetSheetRow(sheet, "A1", &settlementHeader); err != nil {
+		return err
+	}
+
+	rows := settlementRows(report)
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return err
+		}
+
+		err = file.SetSheetRow(sheet, cell, &[]interface{}{
+			row.Day,
+			row.PaymentMethod,
+			row.Currency,
+			row.PaidCount,
+			amountCell(row.PaidAmount),
+			row.RefundedCount,
+			amountCell(row.RefundedAmount),
+			row.ExpiredCount,
+			amountCell(row.ExpiredAmount),
+			amountCell(row.NetAmount),
+		})
+		if err != nil {
+			return err
+		}
+
+		style := amountStyle
+		if exponent, _ := models.CurrencyExponent(row.Currency); exponent == 0 {
+			style = wholeAmountStyle
+		}
+
+		for _, column := range []string{"E", "G", "I", "J"} {
+			cell := column + strconv.Itoa(i+2)
+			if err = file.SetCellStyle(sheet, cell, cell, style); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = file.SetRowStyle(sheet, 1, 1, headerStyle); err != nil {
+		return err
+	}
+
+	if err = file.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+
+	return file.Write(w)
+}
@@ -7,9 +7,31 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine, paymentHandler handler.PaymentHandler) {
+func SetupRoutes(router *gin.Engine, paymentHandler handler.PaymentHandler, refundHandler handler.RefundHandler, anomalyHandler handler.AnomalyHandler, failedEventHandler handler.FailedEventHandler, jobHandler handler.JobHandler, reconciliationHandler handler.ReconciliationHandler, reportHandler handler.ReportHandler, circuitBreakerHandler handler.CircuitBreakerHandler, jwtSecret string) {
 	// context timeout and logger
 	router.Use(middleware.RequestLogger(2))
 	router.POST("/v1/payment/webhook", paymentHandler.HandleXenditWebhook)
+	router.POST("/v1/payment/webhook/midtrans", paymentHandler.HandleMidtransWebhook)
 	router.GET("/v1/payment/invoice/:order_id/pdf", paymentHandler.HandlerDownloadPDFInvoice)
+
+	// authenticated user
+	authMiddleware := middleware.AuthMiddleware(jwtSecret)
+	router.GET("/v1/payment/:order_id", authMiddleware, paymentHandler.HandlerGetPaymentInfo)
+
+	// admin only
+	router.POST("/v1/payment/:order_id/refund", authMiddleware, middleware.AdminMiddleware(), refundHandler.HandlerCreateRefund)
+
+	admin := router.Group("/v1/admin", authMiddleware, middleware.AdminMiddleware())
+	admin.GET("/anomalies", anomalyHandler.HandlerGetPaymentAnomalies)
+	admin.GET("/anomalies/:id", anomalyHandler.HandlerGetPaymentAnomalyDetail)
+	admin.POST("/anomalies/:id/resolve", anomalyHandler.HandlerResolvePaymentAnomaly)
+	admin.GET("/failed-events", failedEventHandler.HandlerGetFailedEvents)
+	admin.POST("/failed-events/replay", failedEventHandler.HandlerReplayFailedEvents)
+	admin.POST("/failed-events/close", failedEventHandler.HandlerCloseFailedEvents)
+	admin.GET("/jobs", jobHandler.HandlerGetJobs)
+	admin.POST("/jobs/:name/:action", jobHandler.HandlerControlJob)
+	admin.GET("/reconciliations", reconciliationHandler.HandlerGetReconciliationRuns)
+	admin.GET("/reconciliations/:id/discrepancies", reconciliationHandler.HandlerGetReconciliationDiscrepancies)
+	admin.GET("/reports/settlement", reportHandler.HandlerGetSettlementReport)
+	admin.GET("/circuit-breakers", circuitBreakerHandler.HandlerGetCircuitBreakers)
 }
@@ -0,0 +1,17 @@
+package utils
+
+import "context"
+
+type contextKey string
+
+const requestIDKey contextKey = "request_id"
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext return empty string when context has no request id, e.g. started by scheduler.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
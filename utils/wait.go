@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// WaitContext wait for wg, return ctx error when ctx is done before every goroutine is finished.
+func WaitContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}